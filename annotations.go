@@ -0,0 +1,65 @@
+package redant
+
+import "strconv"
+
+// mergeAnnotations fills inv.Annotations with the annotations of the
+// command and its ancestors. Nearer commands override their ancestors and
+// values already set on the invocation override them all. The merged map
+// is a copy, so neither the commands nor the caller's map are modified.
+func (inv *Invocation) mergeAnnotations() {
+	var merged map[string]any
+	var lineage []*Command
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		lineage = append(lineage, cmd)
+	}
+	for i := len(lineage) - 1; i >= 0; i-- {
+		for k, v := range lineage[i].Annotations {
+			if merged == nil {
+				merged = make(map[string]any)
+			}
+			merged[k] = v
+		}
+	}
+	if merged == nil {
+		return
+	}
+	for k, v := range inv.Annotations {
+		merged[k] = v
+	}
+	inv.Annotations = merged
+}
+
+// Annotation returns the annotation stored under key, see
+// Command.Annotations.
+func (inv *Invocation) Annotation(key string) (any, bool) {
+	v, ok := inv.Annotations[key]
+	return v, ok
+}
+
+// AnnotationString returns the annotation under key if it is a string,
+// or "" otherwise.
+func (inv *Invocation) AnnotationString(key string) string {
+	s, _ := GetAnnotation[string](inv, key)
+	return s
+}
+
+// AnnotationBool reports whether the annotation under key is true: the
+// bool true or a string such as "true" or "1" accepted by
+// strconv.ParseBool.
+func (inv *Invocation) AnnotationBool(key string) bool {
+	switch v := inv.Annotations[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// GetAnnotation returns the annotation under key as a T. The result is
+// false when the annotation is missing or of another type.
+func GetAnnotation[T any](inv *Invocation, key string) (T, bool) {
+	v, ok := inv.Annotations[key].(T)
+	return v, ok
+}
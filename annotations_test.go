@@ -0,0 +1,96 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	errUnauthorized := errors.New("unauthorized")
+	var got map[string]any
+	root := &Command{
+		Use:         "app",
+		Annotations: map[string]any{"requires-auth": true, "team": "platform"},
+		Middleware: func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) error {
+				if inv.AnnotationBool("requires-auth") && inv.AnnotationString("token") == "" {
+					return errUnauthorized
+				}
+				return next(ctx, inv)
+			}
+		},
+	}
+	handler := func(ctx context.Context, inv *Invocation) error {
+		got = inv.Annotations
+		return nil
+	}
+	root.Children = []*Command{
+		{Use: "deploy", Annotations: map[string]any{"team": "release", "retries": 3}, Handler: handler},
+		{Use: "version", Annotations: map[string]any{"requires-auth": "false"}, Handler: handler},
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		preset  map[string]any
+		want    map[string]any
+		wantErr error
+	}{
+		{name: "inherited annotation enforced", args: []string{"deploy"}, wantErr: errUnauthorized},
+		{
+			name:   "child overrides parent and invocation overrides both",
+			args:   []string{"deploy"},
+			preset: map[string]any{"token": "t", "retries": 5},
+			want:   map[string]any{"requires-auth": true, "team": "release", "retries": 5, "token": "t"},
+		},
+		{
+			name: "string value opts out",
+			args: []string{"version"},
+			want: map[string]any{"requires-auth": "false", "team": "platform"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			inv.Annotations = tt.preset
+			err := inv.Run()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("annotation %q: expected %v, got %v", k, v, got[k])
+				}
+			}
+		})
+	}
+
+	if len(root.Children[0].Annotations) != 2 {
+		t.Fatalf("command annotations modified: %v", root.Children[0].Annotations)
+	}
+}
+
+func TestGetAnnotation(t *testing.T) {
+	inv := &Invocation{Annotations: map[string]any{"retries": 3, "name": "x"}}
+	if n, ok := GetAnnotation[int](inv, "retries"); !ok || n != 3 {
+		t.Fatalf("expected 3, got %v %v", n, ok)
+	}
+	if _, ok := GetAnnotation[int](inv, "name"); ok {
+		t.Fatal("expected type mismatch to report false")
+	}
+	if _, ok := GetAnnotation[string](inv, "missing"); ok {
+		t.Fatal("expected missing key to report false")
+	}
+}
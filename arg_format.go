@@ -0,0 +1,103 @@
+package redant
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ArgFormat is a structured format of positional args, such as query
+// strings or JSON, whose keys bind to args by name.
+type ArgFormat interface {
+	// Name identifies the format. It is reported as the ArgSource of the
+	// args it sets.
+	Name() string
+	// Detect reports whether arg is written in the format.
+	Detect(arg string) bool
+	// Parse returns the values of arg by key. Values without a key, like
+	// JSON array items, use the empty key.
+	Parse(arg string) (map[string][]string, error)
+}
+
+var (
+	argFormatsMu sync.RWMutex
+	// argFormats are tried in order; applications' formats come before
+	// the built-in ones.
+	argFormats        = []ArgFormat{queryArgFormat{}, formArgFormat{}, jsonArgFormat{}}
+	builtinArgFormats = len(argFormats)
+)
+
+// RegisterArgFormat adds a structured arg format, e.g. CSV or key:value
+// pairs. Registered formats are detected before the built-in query, form
+// and JSON formats, in registration order. Registering a name twice panics.
+func RegisterArgFormat(f ArgFormat) {
+	argFormatsMu.Lock()
+	defer argFormatsMu.Unlock()
+	if slices.ContainsFunc(argFormats, func(g ArgFormat) bool { return g.Name() == f.Name() }) {
+		panic(fmt.Sprintf("redant: arg format %q registered twice", f.Name()))
+	}
+	custom := len(argFormats) - builtinArgFormats
+	argFormats = slices.Insert(argFormats, custom, f)
+}
+
+// ArgFormats returns the registered arg formats in detection order.
+func ArgFormats() []ArgFormat {
+	argFormatsMu.RLock()
+	defer argFormatsMu.RUnlock()
+	return slices.Clone(argFormats)
+}
+
+// parseKeyedArg parses a token in the first arg format detecting it into
+// its values by key. ok is false for plain positional and unparsable tokens.
+func parseKeyedArg(s string) (values map[string][]string, source ArgSource, ok bool) {
+	for _, f := range ArgFormats() {
+		if !f.Detect(s) {
+			continue
+		}
+		values, err := f.Parse(s)
+		return values, ArgSource(f.Name()), err == nil && len(values) > 0
+	}
+	return nil, ArgSourceNone, false
+}
+
+func isKeyValueArg(s string) bool {
+	return strings.Contains(s, "=") && !strings.HasPrefix(s, "-")
+}
+
+// queryArgFormat is name=value&a=b.
+type queryArgFormat struct{}
+
+func (queryArgFormat) Name() string { return string(ArgSourceQuery) }
+
+func (queryArgFormat) Detect(s string) bool {
+	return isKeyValueArg(s) && (strings.Contains(s, "&") || !strings.Contains(s, " "))
+}
+
+func (queryArgFormat) Parse(s string) (map[string][]string, error) { return ParseQueryArgs(s) }
+
+// formArgFormat is name=value name2="value 2".
+type formArgFormat struct{}
+
+func (formArgFormat) Name() string { return string(ArgSourceForm) }
+
+func (formArgFormat) Detect(s string) bool {
+	return isKeyValueArg(s) && !strings.Contains(s, "&") && strings.Contains(s, " ")
+}
+
+func (formArgFormat) Parse(s string) (map[string][]string, error) { return ParseFormArgs(s) }
+
+// jsonArgFormat is {"key":"value"} or ["value1","value2"].
+type jsonArgFormat struct{}
+
+func (jsonArgFormat) Name() string { return string(ArgSourceJSON) }
+
+func (jsonArgFormat) Detect(s string) bool {
+	s = strings.TrimSpace(s)
+	return (strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")) ||
+		(strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"))
+}
+
+func (jsonArgFormat) Parse(s string) (map[string][]string, error) {
+	return ParseJSONArgs(strings.TrimSpace(s))
+}
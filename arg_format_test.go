@@ -0,0 +1,66 @@
+package redant
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// colonArgFormat parses "kv:key:value,key2:value2" tokens.
+type colonArgFormat struct{}
+
+func (colonArgFormat) Name() string { return "kv" }
+
+func (colonArgFormat) Detect(s string) bool { return strings.HasPrefix(s, "kv:") }
+
+func (colonArgFormat) Parse(s string) (map[string][]string, error) {
+	values := map[string][]string{}
+	for _, pair := range strings.Split(strings.TrimPrefix(s, "kv:"), ",") {
+		k, v, _ := strings.Cut(pair, ":")
+		values[k] = append(values[k], v)
+	}
+	return values, nil
+}
+
+func TestRegisterArgFormat(t *testing.T) {
+	// The registry is global; stay safe under -count=N.
+	if !slices.ContainsFunc(ArgFormats(), func(f ArgFormat) bool { return f.Name() == "kv" }) {
+		RegisterArgFormat(colonArgFormat{})
+	}
+
+	formats := ArgFormats()
+	if formats[0].Name() != "kv" || formats[len(formats)-1].Name() != "json" {
+		t.Fatalf("custom formats must be detected before built-ins, got %v", formats)
+	}
+
+	var user, role string
+	var sources [2]ArgSource
+	cmd := &Command{
+		Use: "grant",
+		Args: ArgSet{
+			{Name: "user", Value: StringOf(&user)},
+			{Name: "role", Value: StringOf(&role)},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			sources = [2]ArgSource{inv.ArgSource("user"), inv.ArgSource("role")}
+			return nil
+		},
+	}
+	if err := cmd.Invoke("kv:user:bob,role:admin").Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if user != "bob" || role != "admin" {
+		t.Fatalf("user=%q role=%q", user, role)
+	}
+	if sources != [2]ArgSource{"kv", "kv"} {
+		t.Fatalf("sources = %v, want kv", sources)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a format name twice must panic")
+		}
+	}()
+	RegisterArgFormat(colonArgFormat{})
+}
@@ -4,9 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
-
-	"github.com/spf13/pflag"
 )
 
 // Command format specification
@@ -49,11 +48,12 @@ import (
 //   --name,-n int, short, env:[$ABC]
 //
 // Default global flags:
-// --list-commands List all commands, including subcommands
+// --list-commands[=tags] List all commands, or only those with the tags
 // --list-flags List all flags
 // --help,-h
 // --version,-v
-// --debug
+// --quiet Suppress regular output
+// --verbose Print verbose output, repeat for debug output
 
 // Parameter type description
 // TextArg is a single argument to a command. 'user is hello and age is 18'
@@ -65,6 +65,28 @@ import (
 
 const internalArgsOverrideFlag = "args"
 
+// Identifiers of the built-in global flags. They stay attached to the options
+// returned by GlobalFlags() so that renamed built-ins keep their behavior.
+const (
+	builtinHelp           = "help"
+	builtinListCommands   = "list-commands"
+	builtinListFlags      = "list-flags"
+	builtinShowHidden     = "show-hidden"
+	builtinExplain        = "explain"
+	builtinQuiet          = "quiet"
+	builtinVerbose        = "verbose"
+	builtinNonInteractive = "non-interactive"
+	builtinEnv            = "env"
+	builtinEnvFile        = "env-file"
+	builtinPprofCPU       = "pprof-cpu"
+	builtinPprofMem       = "pprof-mem"
+	builtinTrace          = "trace"
+	builtinLang           = "lang"
+	builtinNoColor        = "no-color"
+	builtinHelpFormat     = "help-format"
+	builtinArgs           = internalArgsOverrideFlag
+)
+
 type ArgSet []Arg
 
 type Arg struct {
@@ -79,7 +101,262 @@ type Arg struct {
 
 	// Value includes the types listed in values.go.
 	// Used for type determination and automatic parsing.
-	Value pflag.Value `json:"value,omitempty"`
+	Value Value `json:"value,omitempty"`
+
+	// Variadic makes the last Arg collect all remaining positionals
+	// (e.g. `files...`) into Value, which must then be a slice value such
+	// as StringArrayOf. MinCount and MaxCount bound the number of values
+	// collected; zero means unbounded.
+	Variadic bool `json:"variadic,omitempty"`
+	MinCount int  `json:"minCount,omitempty"`
+	MaxCount int  `json:"maxCount,omitempty"`
+
+	// CompletionHandler suggests values for the arg in shell completion,
+	// e.g. environment names or remote resources. inv.Args holds the
+	// positionals typed so far, the last one being completed; see CurWords.
+	CompletionHandler func(inv *Invocation) []string `json:"-"`
+
+	// Aliases are alternate keys binding the arg in query, form and JSON
+	// args, e.g. "environment" for an arg named "env".
+	Aliases []string `json:"aliases,omitempty"`
+	// DeprecatedAliases are former keys of the arg. They still bind it but
+	// print a warning pointing to Name.
+	DeprecatedAliases []string `json:"deprecatedAliases,omitempty"`
+
+	// Action is called after the args are bound, for args that received a
+	// value (including their Default), like Option.Action for flags. It can
+	// validate the value or derive state such as opened files before the
+	// handler runs. If Action returns an error, command execution will fail.
+	Action func(val Value) error `json:"-"`
+}
+
+// hasKey reports whether key binds the arg by name or alias.
+func (a Arg) hasKey(key string) bool {
+	return a.Name == key || slices.Contains(a.Aliases, key) || slices.Contains(a.DeprecatedAliases, key)
+}
+
+// argByKey returns the index of the arg of args bound by key, or -1.
+func argByKey(args ArgSet, key string) int {
+	if key == "" {
+		return -1
+	}
+	return slices.IndexFunc(args, func(a Arg) bool { return a.hasKey(key) })
+}
+
+// validate reports misconfigurations of the arg at index i of args.
+func (a Arg) validate(i int, args ArgSet) error {
+	name := a.displayName(i)
+	if err := a.validateEnumDefault(name); err != nil {
+		return err
+	}
+	if !a.Variadic {
+		return nil
+	}
+	if i != len(args)-1 {
+		return fmt.Errorf("arg %q: only the last arg can be variadic", name)
+	}
+	if _, ok := a.Value.(SliceValue); a.Value != nil && !ok {
+		return fmt.Errorf("arg %q: variadic args require an array value", name)
+	}
+	if a.MinCount < 0 || a.MaxCount < 0 || a.MaxCount > 0 && a.MinCount > a.MaxCount {
+		return fmt.Errorf("arg %q: invalid count bounds [%d, %d]", name, a.MinCount, a.MaxCount)
+	}
+	return nil
+}
+
+// validateEnumDefault checks the Default of args with a fixed set of enum
+// choices. Choices computed by ChoicesFunc are only checked when parsing.
+func (a Arg) validateEnumDefault(name string) error {
+	if a.Default == "" {
+		return nil
+	}
+	items := []string{a.Default}
+	var choices []string
+	switch v := a.Value.(type) {
+	case *Enum:
+		if v.ChoicesFunc == nil {
+			choices = v.Choices
+		}
+	case *EnumArray:
+		if v.ChoicesFunc == nil {
+			choices = v.Choices
+		}
+		items, _ = readAsCSV(a.Default)
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	for _, item := range items {
+		if !slices.ContainsFunc(choices, func(c string) bool { return strings.EqualFold(c, item) }) {
+			return fmt.Errorf("arg %q: default %q is not one of %v", name, item, choices)
+		}
+	}
+	return nil
+}
+
+// displayName returns the arg name, or argN for unnamed args at index i.
+func (a Arg) displayName(i int) string {
+	if a.Name == "" {
+		return fmt.Sprintf("arg%d", i+1)
+	}
+	return a.Name
+}
+
+// setVariadic binds the remaining positionals vals to a variadic arg,
+// checking its count bounds.
+func (a Arg) setVariadic(i int, vals []string, sources map[string]ArgSource) error {
+	name := a.displayName(i)
+	if len(vals) == 0 && a.Default != "" {
+		if a.Value == nil {
+			return nil
+		}
+		if err := a.Value.Set(a.Default); err != nil {
+			return fmt.Errorf("setting default value for %q: %w", name, err)
+		}
+		sources[name] = ArgSourceDefault
+		return nil
+	}
+
+	minCount := a.MinCount
+	if a.Required && minCount == 0 {
+		minCount = 1
+	}
+	if len(vals) < minCount {
+		return fmt.Errorf("argument %q needs at least %d value(s), got %d", name, minCount, len(vals))
+	}
+	if a.MaxCount > 0 && len(vals) > a.MaxCount {
+		return fmt.Errorf("argument %q accepts at most %d value(s), got %d", name, a.MaxCount, len(vals))
+	}
+	if slice, ok := a.Value.(SliceValue); ok && len(vals) > 0 {
+		if err := slice.Replace(vals); err != nil {
+			return fmt.Errorf("setting value for arg %q: %w", name, err)
+		}
+	}
+	if len(vals) > 0 {
+		sources[name] = ArgSourcePositional
+	}
+	return nil
+}
+
+// ArgSource tells where the value of an Arg came from. Args set from a
+// registered ArgFormat report its Name.
+type ArgSource string
+
+const (
+	// ArgSourceNone means the arg received no value.
+	ArgSourceNone ArgSource = ""
+	// ArgSourcePositional is a plain positional token.
+	ArgSourcePositional ArgSource = "positional"
+	// ArgSourceQuery is a key of a query string token (name=value&a=b).
+	ArgSourceQuery ArgSource = "query"
+	// ArgSourceForm is a key of a form data token (name=value a=b).
+	ArgSourceForm ArgSource = "form"
+	// ArgSourceJSON is a key of a JSON object token.
+	ArgSourceJSON ArgSource = "json"
+	// ArgSourceDefault is the Arg's Default.
+	ArgSourceDefault ArgSource = "default"
+)
+
+// ArgSource returns where the command arg called name got its value, or
+// ArgSourceNone when it got none. Unnamed args are called argN.
+func (inv *Invocation) ArgSource(name string) ArgSource {
+	return inv.argSources[name]
+}
+
+// Arg returns the Value bound to the command arg called name, or nil when
+// the command declares no such arg or the arg has no Value. Type-assert the
+// result, or use ArgString, instead of indexing inv.Args.
+func (inv *Invocation) Arg(name string) Value {
+	if i := inv.argIndex(name); i >= 0 {
+		return inv.boundArgs()[i].Value
+	}
+	return nil
+}
+
+// ArgString returns the value of the command arg called name as a string.
+// Args declared without a Value resolve to their positional value, or their
+// Default when it was not given. It returns "" for unknown args.
+func (inv *Invocation) ArgString(name string) string {
+	i := inv.argIndex(name)
+	if i < 0 {
+		return ""
+	}
+	arg := inv.boundArgs()[i]
+	if arg.Value != nil {
+		return arg.Value.String()
+	}
+	if i < len(inv.Args) {
+		return inv.Args[i]
+	}
+	return arg.Default
+}
+
+func (inv *Invocation) argIndex(name string) int {
+	return slices.IndexFunc(inv.boundArgs(), func(a Arg) bool { return a.Name == name })
+}
+
+// boundArgs returns BoundArgs, or the command's Args before Run bound them.
+func (inv *Invocation) boundArgs() ArgSet {
+	if inv.BoundArgs != nil {
+		return inv.BoundArgs
+	}
+	return inv.Command.Args
+}
+
+// bindOptionArgs sets the options named by keys of query, form and JSON
+// args, for commands with OptionsFromArgs, and returns args without the
+// consumed keys. Tokens left with other keys are re-encoded as a query.
+func (inv *Invocation) bindOptionArgs(args []string) ([]string, error) {
+	cliSet := make(map[string]bool)
+	inv.Flags.Visit(func(f *Flag) { cliSet[f.Name] = true })
+
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		values, _, ok := parseKeyedArg(arg)
+		if !ok || len(values[""]) > 0 {
+			out = append(out, arg)
+			continue
+		}
+		remaining := url.Values{}
+		consumed := false
+		for key, vals := range values {
+			flag := inv.optionArgFlag(key)
+			if flag == nil {
+				remaining[key] = vals
+				continue
+			}
+			consumed = true
+			if cliSet[flag.Name] {
+				continue
+			}
+			for _, v := range vals {
+				if err := inv.Flags.Set(flag.Name, v); err != nil {
+					return nil, fmt.Errorf("setting option %q from args: %w", key, err)
+				}
+			}
+		}
+		switch {
+		case !consumed:
+			out = append(out, arg)
+		case len(remaining) > 0:
+			out = append(out, remaining.Encode())
+		}
+	}
+	return out, nil
+}
+
+// optionArgFlag returns the flag of the non-builtin option called key, or
+// nil when there is none or key names an Arg of the command.
+func (inv *Invocation) optionArgFlag(key string) *Flag {
+	if argByKey(inv.Command.Args, key) >= 0 {
+		return nil
+	}
+	for _, opt := range inv.Command.FullOptions() {
+		if opt.Flag == key && opt.builtin == "" {
+			return inv.Flags.Lookup(opt.Flag)
+		}
+	}
+	return nil
 }
 
 // ParseQueryArgs parses query string formatted arguments into a map
@@ -251,7 +528,9 @@ func ParseJSONArgs(jsonStr string) (map[string][]string, error) {
 	return nil, fmt.Errorf("invalid JSON format")
 }
 
-// GlobalFlags returns the default global flags that should be added to every command
+// GlobalFlags returns the default global flags that should be added to every command.
+// The root command can customize them with SetGlobalFlags or drop them with
+// DisableDefaultGlobals.
 func GlobalFlags() OptionSet {
 	return OptionSet{
 		{
@@ -259,35 +538,156 @@ func GlobalFlags() OptionSet {
 			Shorthand:   "h",
 			Description: "Show help for command.",
 			Value:       BoolOf(new(bool)),
+			builtin:     builtinHelp,
 		},
 		{
 			Flag:        "list-commands",
-			Description: "List all commands, including subcommands.",
-			Value:       BoolOf(new(bool)),
+			Description: "List all commands, including subcommands; given tags (repeatable or CSV), only those with all of them.",
+			Value:       new(listCommandsValue),
+			builtin:     builtinListCommands,
 		},
 		{
 			Flag:        "list-flags",
 			Description: "List all flags.",
 			Value:       BoolOf(new(bool)),
+			builtin:     builtinListFlags,
+		},
+		{
+			Flag:        "explain",
+			Description: "Print what the command would do instead of running it.",
+			Value:       BoolOf(new(bool)),
+			builtin:     builtinExplain,
+		},
+		{
+			Flag:        "quiet",
+			Description: "Suppress output other than errors.",
+			Value:       BoolOf(new(bool)),
+			builtin:     builtinQuiet,
+		},
+		{
+			Flag:        "verbose",
+			Description: "Print verbose output; repeat for debug output.",
+			Value:       new(countValue),
+			builtin:     builtinVerbose,
+		},
+		{
+			Flag:        "non-interactive",
+			Description: "Never prompt for input; fail on missing values instead.",
+			Value:       BoolOf(new(bool)),
+			builtin:     builtinNonInteractive,
+		},
+		{
+			Flag:        "no-color",
+			Description: "Disable colored output; also set by the NO_COLOR environment variable.",
+			Value:       BoolOf(new(bool)),
+			builtin:     builtinNoColor,
+		},
+		{
+			Flag:        "help-format",
+			Description: "Format of --help output: text, or json for tools building their own presentation.",
+			Value:       EnumOf(new(string), "text", "json"),
+			Hidden:      true,
+			builtin:     builtinHelpFormat,
+		},
+		{
+			Flag:        "show-hidden",
+			Description: "Include hidden commands and options in help and listings.",
+			Value:       BoolOf(new(bool)),
+			Hidden:      true,
+			builtin:     builtinShowHidden,
 		},
 		{
 			Flag:        "env",
 			Shorthand:   "e",
 			Description: "Set environment variables (format: KEY=VALUE). Supports repeat and CSV.",
 			Value:       StringArrayOf(new([]string)),
+			builtin:     builtinEnv,
 		},
 		{
 			Flag:        "env-file",
 			Description: "Load environment variables from file(s). Supports repeat and CSV.",
 			Value:       StringArrayOf(new([]string)),
+			builtin:     builtinEnvFile,
+		},
+		{
+			Flag:        "pprof-cpu",
+			Description: "Write a CPU profile of the command to the file.",
+			Value:       StringOf(new(string)),
+			Hidden:      true,
+			builtin:     builtinPprofCPU,
+		},
+		{
+			Flag:        "pprof-mem",
+			Description: "Write a heap profile to the file when the command ends.",
+			Value:       StringOf(new(string)),
+			Hidden:      true,
+			builtin:     builtinPprofMem,
+		},
+		{
+			Flag:        "trace",
+			Description: "Write an execution trace of the command to the file.",
+			Value:       StringOf(new(string)),
+			Hidden:      true,
+			builtin:     builtinTrace,
 		},
 		{
 			Flag:        internalArgsOverrideFlag,
 			Description: "Internal: override parsed args using repeated/CSV values.",
 			Value:       StringArrayOf(new([]string)),
 			Hidden:      true,
+			builtin:     builtinArgs,
 		},
 	}
 }
 
 // PrintCommands and PrintFlags have been moved to help.go for better formatting
+
+// ArgsPolicy validates the positional args of an invocation before they are
+// parsed into the command's Args. See Command.ArgsPolicy.
+type ArgsPolicy func(inv *Invocation) error
+
+// NoArgs rejects any positional arg; with subcommands, a stray arg is
+// reported as an unrecognized subcommand.
+func NoArgs(inv *Invocation) error {
+	return checkArgsRange(inv, 0, 0)
+}
+
+// ArbitraryArgs accepts any number of positional args.
+func ArbitraryArgs(inv *Invocation) error {
+	return nil
+}
+
+// ExactArgs requires exactly n positional args.
+func ExactArgs(n int) ArgsPolicy {
+	return RangeArgs(n, n)
+}
+
+// MinimumNArgs requires at least n positional args.
+func MinimumNArgs(n int) ArgsPolicy {
+	return RangeArgs(n, -1)
+}
+
+// RangeArgs requires between minArgs and maxArgs positional args, inclusive.
+// A maxArgs of -1 means no upper bound.
+func RangeArgs(minArgs, maxArgs int) ArgsPolicy {
+	if minArgs < 0 || maxArgs != -1 && minArgs > maxArgs {
+		panic(fmt.Sprintf("invalid args range [%d, %d]", minArgs, maxArgs))
+	}
+	return func(inv *Invocation) error {
+		return checkArgsRange(inv, minArgs, maxArgs)
+	}
+}
+
+// runArgActions calls the Action of every arg that received a value.
+func (inv *Invocation) runArgActions() error {
+	for i, arg := range inv.Command.Args {
+		name := arg.displayName(i)
+		if arg.Action == nil || inv.argSources[name] == ArgSourceNone {
+			continue
+		}
+		if err := arg.Action(arg.Value); err != nil {
+			return fmt.Errorf("action for arg %q failed: %w", name, err)
+		}
+	}
+	return nil
+}
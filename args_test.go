@@ -0,0 +1,682 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVariadicArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		arg       Arg
+		args      []string
+		wantFiles []string
+		wantErr   string
+	}{
+		{name: "collects remaining", args: []string{"build", "a.go", "b.go"}, wantFiles: []string{"a.go", "b.go"}},
+		{name: "empty", args: []string{"build"}},
+		{name: "default", arg: Arg{Default: "main.go"}, args: []string{"build"}, wantFiles: []string{"main.go"}},
+		{name: "required", arg: Arg{Required: true}, args: []string{"build"}, wantErr: `argument "files" needs at least 1 value(s), got 0`},
+		{name: "min count", arg: Arg{MinCount: 2}, args: []string{"build", "a.go"}, wantErr: `needs at least 2 value(s), got 1`},
+		{name: "max count", arg: Arg{MaxCount: 1}, args: []string{"build", "a.go", "b.go"}, wantErr: `accepts at most 1 value(s), got 2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				target string
+				files  []string
+			)
+			filesArg := tt.arg
+			filesArg.Name, filesArg.Variadic, filesArg.Value = "files", true, StringArrayOf(&files)
+			cmd := &Command{
+				Use:     "tool",
+				Args:    ArgSet{{Name: "target", Value: StringOf(&target)}, filesArg},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if target != "build" {
+				t.Fatalf("target = %q, want build", target)
+			}
+			if !slices.Equal(files, tt.wantFiles) {
+				t.Fatalf("files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestVariadicArgsInitValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    ArgSet
+		wantErr string
+	}{
+		{
+			name:    "not last",
+			args:    ArgSet{{Name: "files", Variadic: true}, {Name: "dest"}},
+			wantErr: `arg "files": only the last arg can be variadic`,
+		},
+		{
+			name:    "scalar value",
+			args:    ArgSet{{Name: "files", Variadic: true, Value: StringOf(new(string))}},
+			wantErr: `arg "files": variadic args require an array value`,
+		},
+		{
+			name:    "bounds",
+			args:    ArgSet{{Name: "files", Variadic: true, MinCount: 3, MaxCount: 1}},
+			wantErr: `arg "files": invalid count bounds [3, 1]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{
+				Use:     "tool",
+				Args:    tt.args,
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			err := cmd.Invoke().Run()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestInvocationArgAccessors(t *testing.T) {
+	var count int64
+	var got struct {
+		name, count, mode, missing string
+		countVal                   Value
+		unknown                    Value
+	}
+	cmd := &Command{
+		Use: "greet",
+		Args: ArgSet{
+			{Name: "name", Value: StringOf(new(string))},
+			{Name: "count", Value: Int64Of(&count)},
+			{Name: "mode", Default: "plain"},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			got.name = inv.ArgString("name")
+			got.count = inv.ArgString("count")
+			got.mode = inv.ArgString("mode")
+			got.missing = inv.ArgString("missing")
+			got.countVal = inv.Arg("count")
+			got.unknown = inv.Arg("missing")
+			return nil
+		},
+	}
+	if err := cmd.Invoke("alice", "3").Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got.name != "alice" || got.count != "3" || got.mode != "plain" || got.missing != "" {
+		t.Fatalf("unexpected ArgString results: %+v", got)
+	}
+	if v, ok := got.countVal.(*Int64); !ok || int64(*v) != 3 {
+		t.Fatalf("Arg(count) = %#v, want *Int64(3)", got.countVal)
+	}
+	if got.unknown != nil {
+		t.Fatalf("Arg(missing) = %#v, want nil", got.unknown)
+	}
+}
+
+func TestEnumArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     Arg
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "valid", args: []string{"prod"}, want: "prod"},
+		{name: "default", arg: Arg{Default: "dev"}, want: "dev"},
+		{name: "invalid", args: []string{"qa"}, wantErr: `setting value for arg "env": invalid choice: qa, should be one of [dev prod]`},
+		{name: "invalid default", arg: Arg{Default: "qa"}, wantErr: `arg "env": default "qa" is not one of [dev prod]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var env string
+			envArg := tt.arg
+			envArg.Name, envArg.Value = "env", EnumOf(&env, "dev", "prod")
+			cmd := &Command{
+				Use:     "deploy",
+				Args:    ArgSet{envArg},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if env != tt.want {
+				t.Fatalf("env = %q, want %q", env, tt.want)
+			}
+			if got := cmd.CompleteArgs(context.Background(), []string{"p"}); !slices.Equal(got, []string{"prod"}) {
+				t.Fatalf("CompleteArgs = %q, want [prod]", got)
+			}
+		})
+	}
+}
+
+func TestCommandArgsPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ArgsPolicy
+		children bool
+		args     []string
+		wantErr  string
+	}{
+		{name: "no args", policy: NoArgs},
+		{name: "no args rejects", policy: NoArgs, args: []string{"x"}, wantErr: "wanted no args but got 1 [x]"},
+		{name: "no args with children", policy: NoArgs, children: true, args: []string{"sttaus"}, wantErr: `unrecognized subcommand "sttaus"`},
+		{name: "arbitrary", policy: ArbitraryArgs, args: []string{"a", "b", "c"}},
+		{name: "exact", policy: ExactArgs(2), args: []string{"a", "b"}},
+		{name: "exact rejects", policy: ExactArgs(2), args: []string{"a"}, wantErr: "wanted 2 args but got 1 [a]"},
+		{name: "minimum", policy: MinimumNArgs(1), args: []string{"a", "b"}},
+		{name: "minimum rejects", policy: MinimumNArgs(1), wantErr: "wanted at least 1 args but got 0"},
+		{name: "range", policy: RangeArgs(1, 2), args: []string{"a"}},
+		{name: "range rejects", policy: RangeArgs(1, 2), args: []string{"a", "b", "c"}, wantErr: "wanted between 1 and 2 args but got 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{
+				Use:        "tool",
+				ArgsPolicy: tt.policy,
+				Handler:    func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			if tt.children {
+				cmd.Children = []*Command{{Use: "status", Handler: cmd.Handler}}
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("run failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("skipped for help", func(t *testing.T) {
+		cmd := &Command{Use: "tool", ArgsPolicy: ExactArgs(1), Handler: func(ctx context.Context, inv *Invocation) error { return nil }}
+		if err := cmd.Invoke("--help").Run(); err != nil {
+			t.Fatalf("help failed: %v", err)
+		}
+	})
+}
+
+func TestInvocationArgSource(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]ArgSource
+	}{
+		{
+			name: "positional and default",
+			args: []string{"alice"},
+			want: map[string]ArgSource{"user": ArgSourcePositional, "role": ArgSourceDefault, "note": ArgSourceNone},
+		},
+		{
+			name: "query",
+			args: []string{"role=admin&user=bob"},
+			want: map[string]ArgSource{"user": ArgSourceQuery, "role": ArgSourceQuery},
+		},
+		{
+			name: "form",
+			args: []string{"user=bob note='hi there'"},
+			want: map[string]ArgSource{"user": ArgSourceForm, "note": ArgSourceForm},
+		},
+		{
+			name: "json",
+			args: []string{`{"user":"bob"}`},
+			want: map[string]ArgSource{"user": ArgSourceJSON},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]ArgSource{}
+			cmd := &Command{
+				Use: "grant",
+				Args: ArgSet{
+					{Name: "user", Value: StringOf(new(string))},
+					{Name: "role", Default: "viewer", Value: StringOf(new(string))},
+					{Name: "note", Value: StringOf(new(string))},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					for name := range tt.want {
+						got[name] = inv.ArgSource(name)
+					}
+					return nil
+				},
+			}
+			if err := cmd.Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("ArgSource(%q) = %q, want %q", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandStrictArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		args     []string
+		wantUser string
+		wantErr  string
+	}{
+		{name: "lenient unknown key falls through", args: []string{"usr=bob"}, wantUser: "usr=bob"},
+		{name: "strict unknown key", strict: true, args: []string{"usr=bob"}, wantErr: "unknown argument key(s) usr, expected one of: user, role"},
+		{name: "strict unknown json key", strict: true, args: []string{`{"user":"bob","admin":true}`}, wantErr: "unknown argument key(s) admin"},
+		{name: "strict missing required", strict: true, args: []string{"role=admin"}, wantErr: `required argument "user" is missing`},
+		{name: "strict ok", strict: true, args: []string{"role=admin&user=bob"}, wantUser: "bob"},
+		{name: "strict positional", strict: true, args: []string{"bob"}, wantUser: "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user, role string
+			cmd := &Command{
+				Use:        "grant",
+				StrictArgs: tt.strict,
+				Args: ArgSet{
+					{Name: "user", Required: true, Value: StringOf(&user)},
+					{Name: "role", Value: StringOf(&role)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if user != tt.wantUser {
+				t.Fatalf("user = %q, want %q", user, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestArgSetFromStruct(t *testing.T) {
+	var params struct {
+		Env      string   `arg:"env,required" desc:"Target environment."`
+		Replicas int64    `arg:"replicas" default:"2"`
+		Services []string `arg:",variadic"`
+		Internal string
+	}
+	args, err := ArgSetFromStruct(&params)
+	if err != nil {
+		t.Fatalf("ArgSetFromStruct failed: %v", err)
+	}
+
+	var names []string
+	for _, a := range args {
+		names = append(names, a.Name)
+	}
+	if !slices.Equal(names, []string{"env", "replicas", "services"}) {
+		t.Fatalf("arg names = %q", names)
+	}
+	if !args[0].Required || args[0].Description != "Target environment." || !args[2].Variadic {
+		t.Fatalf("tag options not applied: %+v", args)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantEnv      string
+		wantReplicas int64
+		wantServices []string
+		wantErr      string
+	}{
+		{name: "positional", args: []string{"prod", "3", "api", "web"}, wantEnv: "prod", wantReplicas: 3, wantServices: []string{"api", "web"}},
+		{name: "query", args: []string{"env=dev&replicas=5"}, wantEnv: "dev", wantReplicas: 5},
+		{name: "default", args: []string{"dev"}, wantEnv: "dev", wantReplicas: 2},
+		{name: "missing required", wantErr: `required argument "env" is missing`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params.Env, params.Replicas, params.Services = "", 0, nil
+			cmd := &Command{
+				Use:     "deploy",
+				Args:    args,
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if params.Env != tt.wantEnv || params.Replicas != tt.wantReplicas || !slices.Equal(params.Services, tt.wantServices) {
+				t.Fatalf("params = %+v", params)
+			}
+		})
+	}
+}
+
+func TestArgSetFromStructErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       any
+		wantErr string
+	}{
+		{name: "not a pointer", v: struct{}{}, wantErr: "want a pointer to a struct"},
+		{name: "unsupported type", v: &struct {
+			Port int `arg:"port"`
+		}{}, wantErr: "field Port: unsupported type int"},
+		{name: "unknown option", v: &struct {
+			Name string `arg:"name,optional"`
+		}{}, wantErr: `unknown arg tag option "optional"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ArgSetFromStruct(tt.v)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCommandOptionsFromArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantName string
+		wantPort int64
+		wantTags []string
+		wantArgs []string
+	}{
+		{name: "query", args: []string{"name=x&port=99"}, wantName: "x", wantPort: 99},
+		{name: "json", args: []string{`{"name":"x","port":99}`}, wantName: "x", wantPort: 99},
+		{name: "form", args: []string{"name=x port=99"}, wantName: "x", wantPort: 99},
+		{name: "repeated array", args: []string{"tags=a&tags=b"}, wantPort: 8080, wantTags: []string{"a", "b"}},
+		{name: "command line wins", args: []string{"--port", "1", "port=99"}, wantPort: 1},
+		{name: "arg keys kept", args: []string{"target=api&port=99"}, wantPort: 99, wantArgs: []string{"target=api"}},
+		{name: "positional untouched", args: []string{"api"}, wantPort: 8080, wantArgs: []string{"api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				name, target string
+				port         int64
+				tags         []string
+				gotArgs      []string
+			)
+			cmd := &Command{
+				Use:             "serve",
+				OptionsFromArgs: true,
+				Options: OptionSet{
+					{Flag: "name", Value: StringOf(&name)},
+					{Flag: "port", Default: "8080", Value: Int64Of(&port)},
+					{Flag: "tags", Value: StringArrayOf(&tags)},
+				},
+				Args: ArgSet{{Name: "target", Value: StringOf(&target)}},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					gotArgs = inv.Args
+					return nil
+				},
+			}
+			if err := cmd.Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if name != tt.wantName || port != tt.wantPort || !slices.Equal(tags, tt.wantTags) {
+				t.Fatalf("name=%q port=%d tags=%q", name, port, tags)
+			}
+			if !slices.Equal(gotArgs, tt.wantArgs) {
+				t.Fatalf("inv.Args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestNegativeNumberArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantA      float64
+		wantB      float64
+		wantOffset int64
+		wantErr    string
+		stringArgs bool
+	}{
+		{name: "negative ints", args: []string{"-5", "3"}, wantA: -5, wantB: 3},
+		{name: "negative floats", args: []string{"1", "-1.5"}, wantA: 1, wantB: -1.5},
+		{name: "flag value", args: []string{"--offset", "-2", "-1", "-3"}, wantA: -1, wantB: -3, wantOffset: -2},
+		{name: "after flags", args: []string{"-v", "-7", "-8"}, wantA: -7, wantB: -8},
+		{name: "unknown flag", args: []string{"-x", "1", "2"}, wantErr: "unknown shorthand flag: 'x'"},
+		{name: "no numeric args", stringArgs: true, args: []string{"label", "-5"}, wantErr: "unknown shorthand flag: '5'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				a, b    float64
+				offset  int64
+				verbose bool
+			)
+			cmd := &Command{
+				Use: "sub",
+				Options: OptionSet{
+					{Flag: "offset", Value: Int64Of(&offset)},
+					{Flag: "verbose", Shorthand: "v", Value: BoolOf(&verbose)},
+				},
+				Args: ArgSet{
+					{Name: "a", Value: Float64Of(&a)},
+					{Name: "b", Value: Float64Of(&b)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			if tt.stringArgs {
+				cmd.Args = ArgSet{{Name: "a"}, {Name: "b"}}
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if a != tt.wantA || b != tt.wantB || offset != tt.wantOffset {
+				t.Fatalf("a=%v b=%v offset=%v", a, b, offset)
+			}
+		})
+	}
+}
+
+func TestArgAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		strict      bool
+		wantEnv     string
+		wantWarning bool
+		wantErr     string
+	}{
+		{name: "name", args: []string{"env=prod"}, wantEnv: "prod"},
+		{name: "alias", args: []string{"environment=prod"}, wantEnv: "prod"},
+		{name: "json alias", args: []string{`{"environment":"prod"}`}, wantEnv: "prod"},
+		{name: "deprecated alias", args: []string{"stage=prod"}, wantEnv: "prod", wantWarning: true},
+		{name: "strict alias", args: []string{"environment=prod"}, strict: true, wantEnv: "prod"},
+		{name: "strict unknown", args: []string{"region=eu"}, strict: true, wantErr: "unknown argument key(s) region"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var env string
+			var stderr bytes.Buffer
+			cmd := &Command{
+				Use:        "deploy",
+				StrictArgs: tt.strict,
+				Args: ArgSet{{
+					Name:              "env",
+					Value:             StringOf(&env),
+					Aliases:           []string{"environment"},
+					DeprecatedAliases: []string{"stage"},
+				}},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					if inv.ArgSource("env") == ArgSourceNone {
+						t.Error("env has no source")
+					}
+					return nil
+				},
+			}
+			inv := cmd.Invoke(tt.args...)
+			inv.Stderr = &stderr
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if env != tt.wantEnv {
+				t.Fatalf("env = %q, want %q", env, tt.wantEnv)
+			}
+			warned := strings.Contains(stderr.String(), `argument key "stage" is deprecated, use "env" instead`)
+			if warned != tt.wantWarning {
+				t.Fatalf("warning = %v, stderr: %q", warned, stderr.String())
+			}
+		})
+	}
+}
+
+func TestArgAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCall []string
+		wantErr  string
+	}{
+		{name: "positional", args: []string{"a.txt", "x"}, wantCall: []string{"file=a.txt", "mode=x"}},
+		{name: "default", args: []string{"a.txt"}, wantCall: []string{"file=a.txt", "mode=r"}},
+		{name: "unset optional", args: nil},
+		{name: "error", args: []string{"bad"}, wantErr: `action for arg "file" failed: cannot open bad`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file, mode, opt string
+			var calls []string
+			record := func(name string) func(Value) error {
+				return func(v Value) error {
+					if v.String() == "bad" {
+						return fmt.Errorf("cannot open %s", v)
+					}
+					calls = append(calls, name+"="+v.String())
+					return nil
+				}
+			}
+			cmd := &Command{
+				Use: "open",
+				Args: ArgSet{
+					{Name: "file", Value: StringOf(&file), Action: record("file")},
+					{Name: "mode", Value: StringOf(&mode), Default: "r", Action: record("mode")},
+					{Name: "opt", Value: StringOf(&opt), Action: record("opt")},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			if len(tt.args) == 0 {
+				cmd.Args = cmd.Args[2:]
+			}
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if !slices.Equal(calls, tt.wantCall) {
+				t.Fatalf("calls = %q, want %q", calls, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestAutoArgsStayOnInvocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		disable  bool
+		args     [][]string
+		wantArg1 []string
+		wantLen  []int
+	}{
+		{
+			name:     "auto args per invocation",
+			args:     [][]string{{"a", "b"}, {"c"}},
+			wantArg1: []string{"a", "c"},
+			wantLen:  []int{2, 1},
+		},
+		{
+			name:     "disabled",
+			disable:  true,
+			args:     [][]string{{"a", "b"}},
+			wantArg1: []string{""},
+			wantLen:  []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arg1 string
+			var bound int
+			cmd := &Command{
+				Use:             "echo",
+				DisableAutoArgs: tt.disable,
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					arg1 = inv.ArgString("arg1")
+					bound = len(inv.BoundArgs)
+					return nil
+				},
+			}
+			for i, args := range tt.args {
+				if err := cmd.Invoke(args...).Run(); err != nil {
+					t.Fatalf("run %d failed: %v", i, err)
+				}
+				if arg1 != tt.wantArg1[i] || bound != tt.wantLen[i] {
+					t.Fatalf("run %d: arg1 = %q, bound = %d", i, arg1, bound)
+				}
+				if len(cmd.Args) != 0 {
+					t.Fatalf("run %d mutated Command.Args: %v", i, cmd.Args)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package redant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes one run of a command for Audit. Flag values are
+// those set on the command line, with secrets redacted; positional args
+// are only counted.
+type AuditRecord struct {
+	Time     time.Time         `json:"time"`
+	Command  string            `json:"command"`
+	Flags    map[string]string `json:"flags,omitempty"`
+	Args     int               `json:"args"`
+	Duration time.Duration     `json:"duration"`
+	ExitCode int               `json:"exitCode"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// AuditSink receives the record of each audited run.
+type AuditSink func(ctx context.Context, rec AuditRecord)
+
+// Audit returns middleware recording every run of the command and its
+// descendants: the command path, the redacted flag values, the duration
+// and the exit status ExitCode maps the handler's error to. Runs rejected
+// with a usage error, e.g. for an unknown flag, and runs ending in a panic
+// are recorded too. Records go to sink, or are logged with
+// Invocation.Logger when sink is nil. Set it on the root command to audit
+// the whole CLI:
+//
+//	root.Middleware = redant.Audit(redant.JSONAuditSink(auditFile))
+func Audit(sink AuditSink) MiddlewareFunc {
+	if sink == nil {
+		sink = logAuditRecord
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) (err error) {
+			start := inv.Now()
+			defer func() {
+				r := recover()
+				rec := AuditRecord{
+					Time:     start,
+					Command:  inv.Command.FullName(),
+					Flags:    inv.redactedFlags(),
+					Args:     len(inv.Args),
+					Duration: inv.Now().Sub(start),
+					ExitCode: ExitCode(err),
+				}
+				switch {
+				case r != nil:
+					// The status of a Go program dying from a panic.
+					rec.ExitCode = 2
+					rec.Error = fmt.Sprintf("panic: %v", r)
+				case err != nil:
+					rec.Error = err.Error()
+				}
+				sink(ctx, rec)
+				if r != nil {
+					panic(r)
+				}
+			}()
+			return next(ctx, inv)
+		}
+	}
+}
+
+// JSONAuditSink returns a sink writing each record to w as a line of JSON.
+// It is safe for concurrent runs.
+func JSONAuditSink(w io.Writer) AuditSink {
+	var mu sync.Mutex
+	return func(_ context.Context, rec AuditRecord) {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(data, '\n'))
+	}
+}
+
+// logAuditRecord logs rec with the invocation logger.
+func logAuditRecord(ctx context.Context, rec AuditRecord) {
+	attrs := []any{
+		"command", rec.Command,
+		"args", rec.Args,
+		"duration", rec.Duration,
+		"exit_code", rec.ExitCode,
+	}
+	if len(rec.Flags) > 0 {
+		attrs = append(attrs, "flags", rec.Flags)
+	}
+	if rec.Error != "" {
+		attrs = append(attrs, "error", rec.Error)
+	}
+	LoggerFrom(ctx).Log(ctx, slog.LevelInfo, "command finished", attrs...)
+}
+
+// redactedFlags returns the values of the flags set on the command line,
+// with those of secret options and sensitive-looking flags redacted.
+func (inv *Invocation) redactedFlags() map[string]string {
+	if inv.Flags == nil {
+		return nil
+	}
+	opts := inv.Command.FullOptions()
+	flags := make(map[string]string)
+	inv.Flags.Visit(func(f *Flag) {
+		secret := IsSensitiveName(f.Name) || slices.ContainsFunc(opts, func(o Option) bool {
+			return o.Flag == f.Name && o.Secret
+		})
+		if secret {
+			flags[f.Name] = "<redacted>"
+			return
+		}
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
@@ -0,0 +1,119 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	var records bytes.Buffer
+	clock := &stepClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	root := &Command{
+		Use:        "ops",
+		Middleware: Audit(JSONAuditSink(&records)),
+		Children: []*Command{{
+			Use: "deploy",
+			Options: OptionSet{
+				{Flag: "stage", Value: StringOf(new(string))},
+				{Flag: "api-token", Value: StringOf(new(string))},
+				{Flag: "cert", Secret: true, Value: StringOf(new(string))},
+				{Flag: "replicas", Default: "1", Value: Int64Of(new(int64))},
+			},
+			Handler: func(ctx context.Context, inv *Invocation) error {
+				clock.now = clock.now.Add(1500 * time.Millisecond)
+				if inv.Args[0] == "fail" {
+					return errors.New("rollout failed")
+				}
+				return nil
+			},
+		}},
+	}
+
+	runs := [][]string{
+		{"deploy", "--stage=prod", "--api-token=t0k3n", "--cert", "pem", "web"},
+		{"deploy", "fail"},
+		{"deploy", "--bogus", "web"},
+	}
+	for _, args := range runs {
+		_ = root.Invoke(args...).WithClock(clock).Run()
+	}
+
+	lines := strings.Split(strings.TrimSpace(records.String()), "\n")
+	if len(lines) != len(runs) {
+		t.Fatalf("got %d records:\n%s", len(lines), records.String())
+	}
+	want := []AuditRecord{
+		{
+			Command:  "ops deploy",
+			Flags:    map[string]string{"stage": "prod", "api-token": "<redacted>", "cert": "<redacted>"},
+			Args:     1,
+			Duration: 1500 * time.Millisecond,
+		},
+		{
+			Command:  "ops deploy",
+			Args:     1,
+			Duration: 1500 * time.Millisecond,
+			ExitCode: ExitFailure,
+			Error:    "rollout failed",
+		},
+		{
+			Command:  "ops deploy",
+			ExitCode: ExitUsage,
+			Error:    `parsing flags ([--bogus web]) for "ops deploy": unknown flag: --bogus`,
+		},
+	}
+	for i, line := range lines {
+		var got AuditRecord
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		got.Time = time.Time{}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want[i])
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Fatalf("record %d = %s, want %s", i, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestAuditLogsByDefault(t *testing.T) {
+	root := &Command{
+		Use:        "ops",
+		Middleware: Audit(nil),
+		Handler:    func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	var stderr bytes.Buffer
+	if err := root.Invoke("--verbose").WithStderr(&stderr).Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := stderr.String(); !strings.Contains(got, `msg="command finished" command=ops args=0`) || !strings.Contains(got, "exit_code=0 flags=map[verbose:1]") {
+		t.Fatalf("unexpected log %q", got)
+	}
+}
+
+func TestAuditRecordsPanics(t *testing.T) {
+	var records []AuditRecord
+	root := &Command{
+		Use: "ops",
+		Middleware: Audit(func(_ context.Context, rec AuditRecord) {
+			records = append(records, rec)
+		}),
+		Handler: func(ctx context.Context, inv *Invocation) error { panic("boom") },
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("the panic was swallowed")
+			}
+		}()
+		_ = root.Invoke().Run()
+	}()
+	if len(records) != 1 || records[0].ExitCode != 2 || records[0].Error != "panic: boom" {
+		t.Fatalf("records %+v", records)
+	}
+}
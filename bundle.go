@@ -0,0 +1,146 @@
+package redant
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	bundlesMu sync.RWMutex
+	bundles   = map[string]func() OptionSet{}
+)
+
+// RegisterBundle registers a reusable option bundle that commands include
+// by name through Command.Bundles. fn is called once per including command
+// and must return fresh options. Registering a name twice panics.
+func RegisterBundle(name string, fn func() OptionSet) {
+	bundlesMu.Lock()
+	defer bundlesMu.Unlock()
+	if _, dup := bundles[name]; dup {
+		panic(fmt.Sprintf("redant: bundle %q registered twice", name))
+	}
+	bundles[name] = fn
+}
+
+// Bundle returns a fresh copy of the options of the named bundle, or nil if
+// no such bundle is registered.
+func Bundle(name string) OptionSet {
+	bundlesMu.RLock()
+	fn := bundles[name]
+	bundlesMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
+func init() {
+	RegisterBundle("logging", func() OptionSet {
+		return OptionSet{
+			{
+				Flag:        "log-level",
+				Description: "Log level.",
+				Envs:        []string{"LOG_LEVEL"},
+				Default:     "info",
+				Value:       EnumOf(new(string), "debug", "info", "warn", "error"),
+				Category:    "Logging",
+			},
+			{
+				Flag:        "log-format",
+				Description: "Log output format.",
+				Envs:        []string{"LOG_FORMAT"},
+				Default:     "text",
+				Value:       EnumOf(new(string), "text", "json"),
+				Category:    "Logging",
+			},
+		}
+	})
+	RegisterBundle("http-client", func() OptionSet {
+		return OptionSet{
+			{
+				Flag:        "timeout",
+				Description: "HTTP request timeout.",
+				Default:     "30s",
+				Value:       DurationOf(new(time.Duration)),
+				Category:    "HTTP Client",
+			},
+			{
+				Flag:        "retries",
+				Description: "Number of retries for failed HTTP requests.",
+				Default:     "0",
+				Min:         "0",
+				Value:       Int64Of(new(int64)),
+				Category:    "HTTP Client",
+			},
+			{
+				Flag:        "insecure",
+				Description: "Skip TLS certificate verification.",
+				Value:       BoolOf(new(bool)),
+				Category:    "HTTP Client",
+			},
+		}
+	})
+}
+
+// resolveBundles replaces the bundle options in c.Options with those of
+// c.Bundles. Options of bundles included by a previous init are reused so
+// their values stay bound, and c.Options is left untouched when the
+// bundles did not change.
+func (c *Command) resolveBundles() error {
+	existing := map[string]OptionSet{}
+	var options OptionSet
+	for _, opt := range c.Options {
+		if opt.bundle != "" {
+			existing[opt.bundle] = append(existing[opt.bundle], opt)
+		} else {
+			options = append(options, opt)
+		}
+	}
+	if len(c.Bundles) == 0 && len(existing) == 0 {
+		return nil
+	}
+
+	flags := map[string]string{}
+	shorthands := map[string]string{}
+	for _, opt := range slices.Concat(options, c.PersistentOptions) {
+		if opt.Flag != "" {
+			flags[opt.Flag] = "the command"
+		}
+		if opt.Shorthand != "" {
+			shorthands[opt.Shorthand] = "the command"
+		}
+	}
+
+	changed := len(existing) != len(c.Bundles)
+	for _, name := range c.Bundles {
+		opts, ok := existing[name]
+		if !ok {
+			changed = true
+			if opts = Bundle(name); opts == nil {
+				return fmt.Errorf("unknown option bundle %q", name)
+			}
+		}
+		for _, opt := range opts {
+			if owner, dup := flags[opt.Flag]; dup && opt.Flag != "" {
+				return fmt.Errorf("bundle %q: flag %q collides with %s", name, opt.Flag, owner)
+			}
+			if owner, dup := shorthands[opt.Shorthand]; dup && opt.Shorthand != "" {
+				return fmt.Errorf("bundle %q: shorthand %q collides with %s", name, opt.Shorthand, owner)
+			}
+			if opt.Flag != "" {
+				flags[opt.Flag] = fmt.Sprintf("bundle %q", name)
+			}
+			if opt.Shorthand != "" {
+				shorthands[opt.Shorthand] = fmt.Sprintf("bundle %q", name)
+			}
+			opt.bundle = name
+			options = append(options, opt)
+		}
+	}
+	if changed {
+		c.Options = options
+	}
+	return nil
+}
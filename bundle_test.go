@@ -0,0 +1,98 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandBundles(t *testing.T) {
+	var timeout time.Duration
+	var level string
+	cmd := &Command{
+		Use:     "fetch",
+		Bundles: []string{"logging", "http-client"},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			var err error
+			timeout, err = inv.Flags.GetDuration("timeout")
+			if err != nil {
+				return err
+			}
+			level = inv.Flags.Lookup("log-level").Value.String()
+			return nil
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		inv := cmd.Invoke("--timeout", "5s", "--log-level", "debug")
+		inv.Stdout = &bytes.Buffer{}
+		inv.Stderr = &bytes.Buffer{}
+		if err := inv.Run(); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		if timeout != 5*time.Second || level != "debug" {
+			t.Fatalf("run %d: timeout=%v level=%q", i, timeout, level)
+		}
+	}
+
+	count := 0
+	for _, opt := range cmd.Options {
+		if opt.bundle != "" {
+			count++
+		}
+	}
+	if count != 5 {
+		t.Fatalf("expected 5 bundle options after repeated init, got %d", count)
+	}
+}
+
+func TestCommandBundlesErrors(t *testing.T) {
+	var timeout string
+	tests := []struct {
+		name    string
+		cmd     *Command
+		wantErr string
+	}{
+		{
+			name:    "unknown",
+			cmd:     &Command{Use: "app", Bundles: []string{"nope"}},
+			wantErr: `unknown option bundle "nope"`,
+		},
+		{
+			name: "collision with command",
+			cmd: &Command{
+				Use:     "app",
+				Bundles: []string{"http-client"},
+				Options: OptionSet{{Flag: "timeout", Value: StringOf(&timeout)}},
+			},
+			wantErr: `bundle "http-client": flag "timeout" collides with the command`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cmd.Handler = func(ctx context.Context, inv *Invocation) error { return nil }
+			err := tt.cmd.Invoke().Run()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRegisterBundle(t *testing.T) {
+	RegisterBundle("test-bundle", func() OptionSet {
+		return OptionSet{{Flag: "profile", Value: StringOf(new(string))}}
+	})
+	if got := Bundle("test-bundle"); len(got) != 1 || got[0].Flag != "profile" {
+		t.Fatalf("Bundle() = %v", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic on duplicate registration")
+		}
+	}()
+	RegisterBundle("test-bundle", func() OptionSet { return nil })
+}
@@ -0,0 +1,46 @@
+package redant
+
+import "errors"
+
+// Defer registers fn to run once the handler returns, whether it
+// succeeded, failed, panicked or was canceled, so that teardown such as
+// removing a temp dir or closing a connection lives next to the setup:
+//
+//	dir, err := os.MkdirTemp("", "app")
+//	if err != nil {
+//		return err
+//	}
+//	inv.Defer(func() error { return os.RemoveAll(dir) })
+//
+// Functions registered by Provide hooks, middleware and the handler run in
+// reverse order of registration, and their errors are joined to the error
+// Run returns. Defer must not be called concurrently.
+func (inv *Invocation) Defer(fn func() error) {
+	inv.cleanups = append(inv.cleanups, fn)
+}
+
+// callWithCleanups calls fn, then the functions registered with Defer,
+// even when fn panics.
+func (inv *Invocation) callWithCleanups(fn func() error) (err error) {
+	defer func() {
+		if cleanupErr := inv.runCleanups(); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+	}()
+	return fn()
+}
+
+// runCleanups runs and clears the functions registered with Defer, latest
+// first, and returns their errors joined.
+func (inv *Invocation) runCleanups() error {
+	var errs []error
+	for len(inv.cleanups) > 0 {
+		last := len(inv.cleanups) - 1
+		fn := inv.cleanups[last]
+		inv.cleanups = inv.cleanups[:last]
+		if err := fn(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
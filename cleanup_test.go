@@ -0,0 +1,84 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDefer(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	errCleanup := errors.New("close failed")
+	tests := []struct {
+		name       string
+		provideErr error
+		handlerErr error
+		cleanupErr error
+		panics     bool
+		wantRan    []string
+		wantErrs   []error
+	}{
+		{name: "success", wantRan: []string{"handler", "conn", "middleware", "provide"}},
+		{name: "handler error", handlerErr: errHandler, wantRan: []string{"handler", "conn", "middleware", "provide"}, wantErrs: []error{errHandler}},
+		{name: "cleanup error", cleanupErr: errCleanup, wantRan: []string{"handler", "conn", "middleware", "provide"}, wantErrs: []error{errCleanup}},
+		{name: "both errors", handlerErr: errHandler, cleanupErr: errCleanup, wantRan: []string{"handler", "conn", "middleware", "provide"}, wantErrs: []error{errHandler, errCleanup}},
+		{name: "canceled", handlerErr: context.Canceled, wantRan: []string{"handler", "conn", "middleware", "provide"}, wantErrs: []error{context.Canceled}},
+		{name: "panic", panics: true, wantRan: []string{"handler", "conn", "middleware", "provide"}},
+		{name: "provide error", provideErr: errHandler, wantRan: []string{"provide"}, wantErrs: []error{errHandler}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			cleanup := func(name string, err error) func() error {
+				return func() error {
+					ran = append(ran, name)
+					return err
+				}
+			}
+			root := &Command{
+				Use: "app",
+				Provide: func(ctx context.Context, inv *Invocation) (context.Context, error) {
+					inv.Defer(cleanup("provide", nil))
+					return ctx, tt.provideErr
+				},
+				Middleware: func(next HandlerFunc) HandlerFunc {
+					return func(ctx context.Context, inv *Invocation) error {
+						inv.Defer(cleanup("middleware", nil))
+						return next(ctx, inv)
+					}
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					inv.Defer(cleanup("conn", tt.cleanupErr))
+					ran = append(ran, "handler")
+					if tt.panics {
+						panic("boom")
+					}
+					return tt.handlerErr
+				},
+			}
+
+			var err error
+			func() {
+				defer func() {
+					if r := recover(); r != nil && !tt.panics {
+						panic(r)
+					}
+				}()
+				err = root.Invoke().Run()
+			}()
+
+			if !slices.Equal(ran, tt.wantRan) {
+				t.Fatalf("ran %q, want %q", ran, tt.wantRan)
+			}
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Fatalf("error %v does not wrap %v", err, want)
+				}
+			}
+		})
+	}
+}
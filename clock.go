@@ -0,0 +1,69 @@
+package redant
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Clock abstracts the passage of time for framework behavior that depends on
+// it (cooldowns, retries, backoff, sampling), so tests can control it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock overrides the clock used by the invocation.
+func (inv *Invocation) WithClock(c Clock) *Invocation {
+	return inv.with(func(i *Invocation) {
+		i.clock = c
+	})
+}
+
+// Clock returns the invocation clock, defaulting to the real one.
+func (inv *Invocation) Clock() Clock {
+	if inv.clock == nil {
+		return realClock{}
+	}
+	return inv.clock
+}
+
+// Now returns the current time of the invocation clock.
+func (inv *Invocation) Now() time.Time {
+	return inv.Clock().Now()
+}
+
+// Sleep waits for d on the invocation clock, returning early with the
+// context error if ctx is done first.
+func (inv *Invocation) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-inv.Clock().After(d):
+		return nil
+	}
+}
+
+// WithRandSource overrides the randomness source used by the invocation.
+func (inv *Invocation) WithRandSource(src rand.Source) *Invocation {
+	return inv.with(func(i *Invocation) {
+		i.randSource = src
+	})
+}
+
+// Rand returns a random generator backed by the invocation source, or a
+// randomly seeded one when no source was set.
+func (inv *Invocation) Rand() *rand.Rand {
+	if inv.randSource == nil {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return rand.New(inv.randSource)
+}
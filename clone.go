@@ -0,0 +1,153 @@
+package redant
+
+import (
+	"maps"
+	"slices"
+)
+
+// ValueCopier returns an independent copy of v, or nil to leave v to the
+// next copier. See Command.Clone.
+type ValueCopier func(v Value) Value
+
+// ValueCloner is implemented by values that know how to copy themselves
+// for Command.Clone.
+type ValueCloner interface {
+	CloneValue() Value
+}
+
+// Clone returns a deep copy of the command tree rooted at c, detached from
+// c's parent. Commands, option and arg sets, aliases, examples, metadata
+// and annotations are copied, so the clone can be modified and run
+// without affecting c.
+//
+// The Values of options and args are copied too, holding their current
+// content in fresh storage: copiers are tried in order, then values
+// implementing ValueCloner and the value types of this package are copied.
+// Other values are shared with c. Handlers of a clone must read their
+// inputs through the invocation (inv.ParsedFlags, inv.Arg, ...) rather
+// than variables bound by the original definition, which the clone no
+// longer writes to.
+func (c *Command) Clone(copiers ...ValueCopier) *Command {
+	clone := c.cloneTree(copiers)
+	clone.parent = nil
+	return clone
+}
+
+func (c *Command) cloneTree(copiers []ValueCopier) *Command {
+	cpy := *c
+	cpy.Aliases = slices.Clone(c.Aliases)
+	cpy.SuggestFor = slices.Clone(c.SuggestFor)
+	cpy.Tags = slices.Clone(c.Tags)
+	cpy.NotifySignals = slices.Clone(c.NotifySignals)
+	cpy.Examples = slices.Clone(c.Examples)
+	cpy.SeeAlso = slices.Clone(c.SeeAlso)
+	cpy.Bundles = slices.Clone(c.Bundles)
+	cpy.Effects = slices.Clone(c.Effects)
+	cpy.Metadata = maps.Clone(c.Metadata)
+	cpy.Annotations = maps.Clone(c.Annotations)
+	cpy.Options = cloneOptions(c.Options, copiers)
+	cpy.PersistentOptions = cloneOptions(c.PersistentOptions, copiers)
+
+	cpy.Args = slices.Clone(c.Args)
+	for i := range cpy.Args {
+		cpy.Args[i].Value = copyValue(cpy.Args[i].Value, copiers)
+	}
+
+	cpy.Children = make([]*Command, len(c.Children))
+	for i, child := range c.Children {
+		cpy.Children[i] = child.cloneTree(copiers)
+		cpy.Children[i].parent = &cpy
+	}
+	return &cpy
+}
+
+func cloneOptions(opts OptionSet, copiers []ValueCopier) OptionSet {
+	opts = slices.Clone(opts)
+	for i := range opts {
+		opts[i].Envs = slices.Clone(opts[i].Envs)
+		opts[i].Value = copyValue(opts[i].Value, copiers)
+	}
+	return opts
+}
+
+// copyValue returns a copy of v holding its current content, see Clone.
+func copyValue(v Value, copiers []ValueCopier) Value {
+	if v == nil {
+		return nil
+	}
+	for _, copier := range copiers {
+		if cpy := copier(v); cpy != nil {
+			return cpy
+		}
+	}
+	if cloner, ok := v.(ValueCloner); ok {
+		return cloner.CloneValue()
+	}
+	if cloner, ok := v.(interface {
+		copyValue(copiers []ValueCopier) Value
+	}); ok {
+		return cloner.copyValue(copiers)
+	}
+
+	switch v := v.(type) {
+	case *Int64:
+		cpy := *v
+		return &cpy
+	case *Float64:
+		cpy := *v
+		return &cpy
+	case *Bool:
+		cpy := *v
+		return &cpy
+	case *String:
+		cpy := *v
+		return &cpy
+	case *StringArray:
+		cpy := slices.Clone(*v)
+		return &cpy
+	case *Duration:
+		cpy := *v
+		return &cpy
+	case *countValue:
+		cpy := *v
+		return &cpy
+	case *URL:
+		cpy := *v
+		return &cpy
+	case *HostPort:
+		cpy := *v
+		return &cpy
+	case *Regexp:
+		cpy := *v
+		return &cpy
+	case *Enum:
+		cpy := *v
+		cpy.Choices = slices.Clone(v.Choices)
+		if v.Value != nil {
+			val := *v.Value
+			cpy.Value = &val
+		}
+		return &cpy
+	case *EnumArray:
+		cpy := *v
+		cpy.Choices = slices.Clone(v.Choices)
+		if v.Value != nil {
+			val := slices.Clone(*v.Value)
+			cpy.Value = &val
+		}
+		return &cpy
+	}
+	return v
+}
+
+func (s *Struct[T]) copyValue([]ValueCopier) Value {
+	return &Struct[T]{Value: s.Value}
+}
+
+func (i *Validator[T]) copyValue(copiers []ValueCopier) Value {
+	inner, ok := copyValue(i.Value, copiers).(T)
+	if !ok {
+		return i
+	}
+	return &Validator[T]{Value: inner, validate: i.validate}
+}
@@ -0,0 +1,81 @@
+package redant
+
+import (
+	"context"
+	"testing"
+)
+
+type counterValue struct{ n int }
+
+func (c *counterValue) Set(string) error { c.n++; return nil }
+func (c *counterValue) String() string   { return "" }
+func (c *counterValue) Type() string     { return "counter" }
+
+func TestClone(t *testing.T) {
+	var name string
+	var tags []string
+	counter := &counterValue{}
+	var got string
+	root := &Command{
+		Use:         "app",
+		Annotations: map[string]any{"team": "core"},
+		Children: []*Command{{
+			Use:     "greet",
+			Aliases: []string{"g"},
+			Options: OptionSet{
+				{Flag: "name", Default: "world", Value: StringOf(&name)},
+				{Flag: "tag", Value: StringArrayOf(&tags)},
+				{Flag: "count", Value: counter},
+			},
+			Args: ArgSet{{Name: "level", Value: EnumOf(new(string), "info", "debug")}},
+			Handler: func(ctx context.Context, inv *Invocation) error {
+				got = inv.ParsedFlags().Lookup("name").Value.String() + "/" + inv.Arg("level").String()
+				return nil
+			},
+		}},
+	}
+
+	var copied []string
+	clone := root.Clone(func(v Value) Value {
+		if c, ok := v.(*counterValue); ok {
+			copied = append(copied, c.Type())
+			return &counterValue{n: c.n}
+		}
+		return nil
+	})
+
+	if err := clone.Invoke("greet", "debug", "--name", "clone", "--tag", "a", "--count", "1").Run(); err != nil {
+		t.Fatalf("run clone: %v", err)
+	}
+	if got != "clone/debug" {
+		t.Fatalf("clone handler read %q", got)
+	}
+	if name != "" || len(tags) != 0 || counter.n != 0 {
+		t.Fatalf("clone wrote to the original values: name=%q tags=%v count=%d", name, tags, counter.n)
+	}
+	if len(copied) != 1 {
+		t.Fatalf("expected the copier to copy the counter once, got %v", copied)
+	}
+
+	greet := clone.Children[0]
+	if greet.Parent() != clone || clone.Parent() != nil {
+		t.Fatal("clone parent links not set")
+	}
+	greet.Aliases[0] = "hi"
+	greet.Children = append(greet.Children, &Command{Use: "loud"})
+	clone.Annotations["team"] = "other"
+	orig := root.Children[0]
+	if orig.Aliases[0] != "g" || len(orig.Children) != 0 || root.Annotations["team"] != "core" {
+		t.Fatal("modifying the clone changed the original")
+	}
+	if orig.Options[0].Value == greet.Options[0].Value {
+		t.Fatal("clone shares option values with the original")
+	}
+
+	if err := root.Invoke("greet", "info").Run(); err != nil {
+		t.Fatalf("run original: %v", err)
+	}
+	if name != "world" || got != "world/info" {
+		t.Fatalf("original run: name=%q got=%q", name, got)
+	}
+}
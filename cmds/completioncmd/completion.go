@@ -6,11 +6,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/pubgo/redant"
 )
 
+// completeCommandName is the hidden subcommand generated scripts call to
+// complete positional args at runtime.
+const completeCommandName = "__complete"
+
 func New() *redant.Command {
 	var shell string
 	return &redant.Command{
@@ -49,9 +55,60 @@ See each sub-command's help for details on how to use the generated script.`,
 				return fmt.Errorf("unsupported shell: %s", shell)
 			}
 		},
+		Children: []*redant.Command{newCompleteCommand()},
+	}
+}
+
+// newCompleteCommand returns the hidden command printing, one per line,
+// the CompletionHandler suggestions for the words typed after the program
+// name, the last one being completed.
+func newCompleteCommand() *redant.Command {
+	return &redant.Command{
+		Use:     completeCommandName,
+		Short:   "Print completion candidates for positional args",
+		Hidden:  true,
+		RawArgs: true,
+		Handler: func(ctx context.Context, inv *redant.Invocation) error {
+			root := inv.Command
+			for root.Parent() != nil {
+				root = root.Parent()
+			}
+			words := inv.Args
+			if len(words) > 0 && words[0] == "--" {
+				words = words[1:]
+			}
+			for _, s := range root.CompleteArgs(ctx, words) {
+				if _, err := fmt.Fprintln(inv.Stdout, s); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
 	}
 }
 
+// completeInvocation returns the shell command calling the hidden
+// complete command of the completion command run by inv.
+func completeInvocation(inv *redant.Invocation, progName string) string {
+	path := strings.Fields(inv.Command.FullName())
+	return strings.Join(append(append([]string{progName}, path[1:]...), completeCommandName, "--"), " ")
+}
+
+// hasArgCompletion reports whether any positional arg of cmd has a
+// CompletionHandler or enum choices.
+func hasArgCompletion(cmd *redant.Command) bool {
+	for _, arg := range cmd.Args {
+		switch arg.Value.(type) {
+		case *redant.Enum, *redant.EnumArray:
+			return true
+		}
+		if arg.CompletionHandler != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // generateBashCompletion generates bash completion script
 func generateBashCompletion(ctx context.Context, inv *redant.Invocation) error {
 	cmd := inv.Command.Parent() // Get the root command
@@ -90,7 +147,7 @@ func generateBashCompletion(ctx context.Context, inv *redant.Invocation) error {
 
 	// Generate command completions
 	var commandsBuf bytes.Buffer
-	generateBashCommandCompletions(cmd, "", &commandsBuf, progName)
+	generateBashCommandCompletions(cmd, "", &commandsBuf, completeInvocation(inv, progName))
 
 	// Footer
 	footer := fmt.Sprintf(`    *)
@@ -117,15 +174,14 @@ complete -F %s %s
 }
 
 // generateBashCommandCompletions generates command completion for bash recursively
-func generateBashCommandCompletions(cmd *redant.Command, indent string, buf *bytes.Buffer, progName string) {
+func generateBashCommandCompletions(cmd *redant.Command, indent string, buf *bytes.Buffer, completeCmd string) {
 	cmdName := cmd.Name()
-	fullName := cmd.FullName()
 
 	// Generate option completions for this command
-	fmt.Fprintf(buf, `    %q)
+	fmt.Fprintf(buf, `    %s)
         # Completions for %s
         local opts="--help "
-`, fullName, cmdName)
+`, bashCaseLabel(cmd), cmdName)
 
 	// Add all options
 	for _, opt := range cmd.FullOptions() {
@@ -140,26 +196,32 @@ func generateBashCommandCompletions(cmd *redant.Command, indent string, buf *byt
 		}
 	}
 
+	// Add dynamic positional arg values
+	words := "$opts"
+	if hasArgCompletion(cmd) {
+		fmt.Fprintf(buf, "        local args=\"$(%s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null)\"\n", completeCmd)
+		words += " $args"
+	}
+
 	// Add subcommands
-	if len(cmd.Children) > 0 {
+	if slices.ContainsFunc(cmd.Children, func(c *redant.Command) bool { return !c.Hidden }) {
 		buf.WriteString("        local subcmds=\"")
 		for _, child := range cmd.Children {
 			if !child.Hidden {
-				buf.WriteString(child.Name() + " ")
+				buf.WriteString(strings.Join(commandNames(child), " ") + " ")
 			}
 		}
 		buf.WriteString("\"\n")
-		buf.WriteString("        COMPREPLY=( $(compgen -W \"$opts $subcmds\" -- \"$cur\") )")
-	} else {
-		buf.WriteString("        COMPREPLY=( $(compgen -W \"$opts\" -- \"$cur\") )")
+		words += " $subcmds"
 	}
+	fmt.Fprintf(buf, "        COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )", words)
 
 	buf.WriteString("\n        ;;")
 
 	// Generate completions for subcommands
 	for _, child := range cmd.Children {
 		if !child.Hidden {
-			generateBashCommandCompletions(child, indent+"    ", buf, progName)
+			generateBashCommandCompletions(child, indent+"    ", buf, completeCmd)
 		}
 	}
 }
@@ -199,7 +261,7 @@ compdef %s %s
 
 	// Generate command completions
 	var commandsBuf bytes.Buffer
-	generateZshCommandCases(cmd, nil, &commandsBuf)
+	generateZshCommandCases(cmd, []string{""}, &commandsBuf, completeInvocation(inv, progName))
 
 	// Footer
 	footer := `    esac
@@ -227,10 +289,15 @@ compdef %s %s
 	return nil
 }
 
-// generateZshCommandCases emits command-path based case branches for zsh completion.
-func generateZshCommandCases(cmd *redant.Command, path []string, buf *bytes.Buffer) {
-	caseKey := strings.Join(path, " ")
-	fmt.Fprintf(buf, "        %q)\n", caseKey)
+// generateZshCommandCases emits command-path based case branches for zsh
+// completion. keys are the paths reaching cmd, one per combination of
+// names and aliases.
+func generateZshCommandCases(cmd *redant.Command, keys []string, buf *bytes.Buffer, completeCmd string) {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = strconv.Quote(key)
+	}
+	fmt.Fprintf(buf, "        %s)\n", strings.Join(quoted, "|"))
 
 	buf.WriteString("            options=(\n")
 	for _, opt := range cmd.FullOptions() {
@@ -250,16 +317,29 @@ func generateZshCommandCases(cmd *redant.Command, path []string, buf *bytes.Buff
 		if child.Hidden {
 			continue
 		}
-		fmt.Fprintf(buf, "                '%s:%s'\n", child.Name(), escapeZshDescription(child.Short))
+		for _, name := range commandNames(child) {
+			fmt.Fprintf(buf, "                '%s:%s'\n", name, escapeZshDescription(child.Short))
+		}
 	}
 	buf.WriteString("            )\n")
+	if hasArgCompletion(cmd) {
+		buf.WriteString("            local -a args\n")
+		fmt.Fprintf(buf, "            args=(${(f)\"$(%s \"${(@)words[2,CURRENT]}\" 2>/dev/null)\"})\n", completeCmd)
+		buf.WriteString("            (( ${#args[@]} > 0 )) && compadd -a args\n")
+	}
 	buf.WriteString("            ;;\n")
 
 	for _, child := range cmd.Children {
 		if child.Hidden {
 			continue
 		}
-		generateZshCommandCases(child, append(path, child.Name()), buf)
+		var childKeys []string
+		for _, key := range keys {
+			for _, name := range commandNames(child) {
+				childKeys = append(childKeys, strings.TrimSpace(key+" "+name))
+			}
+		}
+		generateZshCommandCases(child, childKeys, buf, completeCmd)
 	}
 }
 
@@ -296,7 +376,7 @@ complete -c %s -n "__fish_use_subcommand" -a "completion" -d "Generate the autoc
 
 	// Generate command completions
 	var commandsBuf bytes.Buffer
-	generateFishCommandCompletions(cmd, &commandsBuf)
+	generateFishCommandCompletions(cmd, &commandsBuf, completeInvocation(inv, progName))
 
 	// Write the full script
 	if _, err := fmt.Fprint(inv.Stdout, header); err != nil {
@@ -310,10 +390,9 @@ complete -c %s -n "__fish_use_subcommand" -a "completion" -d "Generate the autoc
 }
 
 // generateFishCommandCompletions generates command completion for fish recursively
-func generateFishCommandCompletions(cmd *redant.Command, buf *bytes.Buffer) {
+func generateFishCommandCompletions(cmd *redant.Command, buf *bytes.Buffer, completeCmd string) {
 	cmdName := cmd.Name()
-	fullCmdPath := cmd.FullName()
-	cmdParts := strings.Split(fullCmdPath, " ")
+	cmdParts := fishCommandParts(cmd)
 
 	// Generate completions for this command's options
 	for _, opt := range cmd.FullOptions() {
@@ -351,15 +430,25 @@ func generateFishCommandCompletions(cmd *redant.Command, buf *bytes.Buffer) {
 			if !child.Hidden {
 				fmt.Fprintf(buf, "complete -c %s -n \"__fish_seen_subcommand_from %s\" -a \"%s\" -d \"%s\"\n",
 					cmdParts[0], strings.Join(cmdParts[1:], " "),
-					child.Name(), child.Short)
+					strings.Join(commandNames(child), " "), child.Short)
 			}
 		}
 	}
 
+	// Generate dynamic completions for positional args
+	if hasArgCompletion(cmd) {
+		condition := "__fish_use_subcommand"
+		if len(cmdParts) > 1 {
+			condition = "__fish_seen_subcommand_from " + strings.Join(cmdParts[1:], " ")
+		}
+		fmt.Fprintf(buf, "complete -c %s -n \"%s\" -f -a \"(%s (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)\"\n",
+			cmdParts[0], condition, completeCmd)
+	}
+
 	// Recursively generate completions for subcommands
 	for _, child := range cmd.Children {
 		if !child.Hidden {
-			generateFishCommandCompletions(child, buf)
+			generateFishCommandCompletions(child, buf, completeCmd)
 		}
 	}
 }
@@ -368,3 +457,56 @@ func generateFishCommandCompletions(cmd *redant.Command, buf *bytes.Buffer) {
 func AddCompletionCommand(rootCmd *redant.Command) {
 	rootCmd.Children = append(rootCmd.Children, New())
 }
+
+// commandNames returns the name of cmd followed by its aliases.
+func commandNames(cmd *redant.Command) []string {
+	names := []string{cmd.Name()}
+	for _, alias := range cmd.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// bashCaseLabel returns the case pattern matching the full name of cmd
+// with any of its own or its parents' aliases.
+func bashCaseLabel(cmd *redant.Command) string {
+	paths := []string{""}
+	var chain []*redant.Command
+	for c := cmd; c != nil; c = c.Parent() {
+		chain = append([]*redant.Command{c}, chain...)
+	}
+	for i, c := range chain {
+		names := []string{c.Name()}
+		if i > 0 {
+			names = commandNames(c)
+		}
+		var next []string
+		for _, p := range paths {
+			for _, name := range names {
+				next = append(next, strings.TrimSpace(p+" "+name))
+			}
+		}
+		paths = next
+	}
+	for i, p := range paths {
+		paths[i] = strconv.Quote(p)
+	}
+	return strings.Join(paths, "|")
+}
+
+// fishCommandParts returns the root name of cmd followed by the names and
+// aliases of the commands on its path, as matched by
+// __fish_seen_subcommand_from.
+func fishCommandParts(cmd *redant.Command) []string {
+	var parts []string
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Parent() == nil {
+			parts = append([]string{c.Name()}, parts...)
+			break
+		}
+		parts = append(commandNames(c), parts...)
+	}
+	return parts
+}
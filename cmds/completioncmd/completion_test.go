@@ -216,7 +216,10 @@ func newCompletionTestRoot() *redant.Command {
 		Use:   "project",
 		Short: "manage projects",
 		Args: redant.ArgSet{
-			{Name: "project_name", Required: false, Value: redant.StringOf(new(string)), Description: "project name"},
+			{
+				Name: "project_name", Required: false, Value: redant.StringOf(new(string)), Description: "project name",
+				CompletionHandler: func(inv *redant.Invocation) []string { return []string{"alpha", "beta", "gamma"} },
+			},
 		},
 		Options: redant.OptionSet{
 			{Flag: "namespace", Description: "project namespace", Value: redant.StringOf(&projectNS)},
@@ -225,8 +228,9 @@ func newCompletionTestRoot() *redant.Command {
 	}
 
 	repoCmd := &redant.Command{
-		Use:   "repo",
-		Short: "manage repositories",
+		Use:     "repo",
+		Aliases: []string{"r"},
+		Short:   "manage repositories",
 		Args: redant.ArgSet{
 			{Name: "repo_name", Required: false, Value: redant.StringOf(new(string)), Description: "repository name"},
 		},
@@ -260,3 +264,30 @@ func newCompletionTestRoot() *redant.Command {
 	AddCompletionCommand(rootCmd)
 	return rootCmd
 }
+
+func TestCompleteCommandPrintsArgCandidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		want  string
+	}{
+		{name: "all", words: []string{"project", ""}, want: "alpha\nbeta\ngamma\n"},
+		{name: "prefix", words: []string{"project", "--namespace", "ns", "g"}, want: "gamma\n"},
+		{name: "no handler", words: []string{"project", "repo", ""}, want: ""},
+		{name: "alias", words: []string{"project", "r", ""}, want: ""},
+		{name: "flag", words: []string{"project", "--na"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			inv := newCompletionTestRoot().Invoke(append([]string{"completion", "__complete", "--"}, tt.words...)...)
+			inv.Stdout = stdout
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run __complete: %v", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Fatalf("candidates = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package envcmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pubgo/redant"
+)
+
+func New() *redant.Command {
+	return &redant.Command{
+		Use:   "env",
+		Short: "列出命令树读取的环境变量",
+		Long:  "按命令分组列出所有选项读取的环境变量及其当前值与对应标志（仅由环境变量设置的选项显示为 -），敏感值显示为 <redacted>，便于容器化部署时核对配置。",
+		Handler: func(ctx context.Context, inv *redant.Invocation) error {
+			root := inv.Command
+			for root.Parent() != nil {
+				root = root.Parent()
+			}
+
+			w := tabwriter.NewWriter(inv.Stdout, 0, 0, 2, ' ', 0)
+			_ = root.Walk(func(cmd *redant.Command) error {
+				var lines []string
+				for _, opt := range slices.Concat(cmd.Options, cmd.PersistentOptions) {
+					for _, name := range opt.Envs {
+						flag := "-"
+						if opt.Flag != "" {
+							flag = "--" + opt.Flag
+						}
+						lines = append(lines, fmt.Sprintf("  %s\t%s\t%s", name, envValue(inv, opt, name), flag))
+					}
+				}
+				if len(lines) > 0 {
+					_, _ = fmt.Fprintln(w, cmd.FullName())
+					_, _ = fmt.Fprintln(w, strings.Join(lines, "\n"))
+				}
+				return nil
+			})
+			return w.Flush()
+		},
+	}
+}
+
+// envValue is the current value of the env var name read by opt, as shown:
+// redacted when the option is secret or the name looks sensitive.
+func envValue(inv *redant.Invocation, opt redant.Option, name string) string {
+	val, ok := inv.LookupEnv(name)
+	switch {
+	case !ok:
+		return "(unset)"
+	case opt.IsSecret(), redant.IsSensitiveName(name):
+		return "<redacted>"
+	default:
+		return val
+	}
+}
+
+// AddEnvCommand adds the env command, listing the environment variables
+// read by the command tree, to the root command.
+func AddEnvCommand(rootCmd *redant.Command) {
+	rootCmd.Children = append(rootCmd.Children, New())
+}
@@ -0,0 +1,49 @@
+package envcmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pubgo/redant"
+)
+
+func TestEnvCommand(t *testing.T) {
+	root := &redant.Command{
+		Use: "app",
+		Options: redant.OptionSet{
+			{Flag: "region", Envs: []string{"APP_REGION"}, Value: redant.StringOf(new(string))},
+			{Flag: "api-token", Envs: []string{"APP_TOKEN"}, Value: redant.StringOf(new(string))},
+			{Flag: "author", Envs: []string{"GIT_AUTHOR_NAME"}, Value: redant.StringOf(new(string))},
+			{Flag: "keyboard-layout", Envs: []string{"KEYBOARD"}, Value: redant.StringOf(new(string))},
+		},
+	}
+	root.Children = append(root.Children, &redant.Command{
+		Use: "deploy",
+		Options: redant.OptionSet{
+			{Envs: []string{"DEPLOY_KEY"}, Secret: true, Value: redant.StringOf(new(string))},
+			{Flag: "force", Envs: []string{"DEPLOY_FORCE"}, Value: redant.BoolOf(new(bool))},
+			{Flag: "db", Envs: []string{"DB_PASSWORD"}, Value: redant.StringOf(new(string))},
+		},
+	}, &redant.Command{Use: "status"})
+	AddEnvCommand(root)
+
+	stdout := &bytes.Buffer{}
+	inv := root.Invoke("env").WithEnviron([]string{"APP_REGION=eu-west-1", "APP_TOKEN=s3cr3t", "DEPLOY_KEY=k3y", "DB_PASSWORD=hunter2", "GIT_AUTHOR_NAME=Ann", "KEYBOARD=dvorak"})
+	inv.Stdout = stdout
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err != nil {
+		t.Fatalf("env: %v", err)
+	}
+	want := "app\n" +
+		"  APP_TOKEN        <redacted>  --api-token\n" +
+		"  GIT_AUTHOR_NAME  Ann         --author\n" +
+		"  KEYBOARD         dvorak      --keyboard-layout\n" +
+		"  APP_REGION       eu-west-1   --region\n" +
+		"app deploy\n" +
+		"  DEPLOY_KEY    <redacted>  -\n" +
+		"  DB_PASSWORD   <redacted>  --db\n" +
+		"  DEPLOY_FORCE  (unset)     --force\n"
+	if got := stdout.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package installlinkscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pubgo/redant"
+)
+
+func New() *redant.Command {
+	var (
+		dir    string
+		force  bool
+		dryRun bool
+	)
+
+	return &redant.Command{
+		Use:   "install-links",
+		Short: "为子命令创建 busybox 风格软链接",
+		Long:  "在目标目录中为根命令的每个可见子命令及其别名创建指向当前可执行文件的软链接，通过软链接名调用时直接执行对应子命令。",
+		Options: redant.OptionSet{
+			{
+				Flag:        "dir",
+				Description: "软链接所在目录，默认为可执行文件所在目录",
+				Value:       redant.StringOf(&dir),
+			},
+			{
+				Flag:        "force",
+				Description: "覆盖已存在的同名文件",
+				Value:       redant.BoolOf(&force),
+			},
+			{
+				Flag:        "dry-run",
+				Description: "仅输出将要创建的软链接",
+				Value:       redant.BoolOf(&dryRun),
+			},
+		},
+		Handler: func(ctx context.Context, inv *redant.Invocation) error {
+			self := inv.Command
+			root := self
+			for root.Parent() != nil {
+				root = root.Parent()
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("locating executable: %w", err)
+			}
+			if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+				exe = resolved
+			}
+			if dir == "" {
+				dir = filepath.Dir(exe)
+			}
+
+			for _, name := range root.MultiCallNames() {
+				if name == self.Name() {
+					continue
+				}
+				link := filepath.Join(dir, name)
+				if dryRun {
+					_, _ = fmt.Fprintf(inv.Stdout, "%s -> %s\n", link, exe)
+					continue
+				}
+				if err := createLink(exe, link, force); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(inv.Stdout, "%s -> %s\n", link, exe)
+			}
+			return nil
+		},
+	}
+}
+
+// createLink creates link pointing to target. An existing link to target
+// is kept; any other existing file is replaced only with force.
+func createLink(target, link string, force bool) error {
+	if existing, err := os.Readlink(link); err == nil && existing == target {
+		return nil
+	}
+	if _, err := os.Lstat(link); err == nil {
+		if !force {
+			return fmt.Errorf("%s already exists, use --force to replace it", link)
+		}
+		if err := os.Remove(link); err != nil {
+			return fmt.Errorf("removing %s: %w", link, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Symlink(target, link); err != nil {
+		return fmt.Errorf("creating link %s: %w", link, err)
+	}
+	return nil
+}
+
+func AddInstallLinksCommand(rootCmd *redant.Command) {
+	rootCmd.Children = append(rootCmd.Children, New())
+}
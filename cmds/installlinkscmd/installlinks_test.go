@@ -0,0 +1,87 @@
+package installlinkscmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pubgo/redant"
+)
+
+func TestInstallLinksCommand(t *testing.T) {
+	newRoot := func() *redant.Command {
+		root := &redant.Command{Use: "app"}
+		root.Children = append(root.Children,
+			&redant.Command{Use: "serve", Aliases: []string{"svc"}},
+			&redant.Command{Use: "secret", Hidden: true},
+		)
+		AddInstallLinksCommand(root)
+		return root
+	}
+	run := func(args ...string) (string, error) {
+		stdout := &bytes.Buffer{}
+		inv := newRoot().Invoke(args...)
+		inv.Stdout = stdout
+		inv.Stderr = &bytes.Buffer{}
+		err := inv.Run()
+		return stdout.String(), err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("executable: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	dir := t.TempDir()
+	out, err := run("install-links", "--dir", dir, "--dry-run")
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(out, filepath.Join(dir, "svc")+" -> "+exe) {
+		t.Fatalf("unexpected dry-run output:\n%s", out)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("dry run created %d entries", len(entries))
+	}
+
+	if _, err := run("install-links", "--dir", dir); err != nil {
+		t.Fatalf("install-links: %v", err)
+	}
+	for _, name := range []string{"serve", "svc"} {
+		target, err := os.Readlink(filepath.Join(dir, name))
+		if err != nil || target != exe {
+			t.Fatalf("link %s: target %q, err %v", name, target, err)
+		}
+	}
+	for _, name := range []string{"secret", "install-links"} {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			t.Fatalf("unexpected link %s", name)
+		}
+	}
+
+	// Re-running is a no-op for links already in place.
+	if _, err := run("install-links", "--dir", dir); err != nil {
+		t.Fatalf("re-run: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "svc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "svc"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("install-links", "--dir", dir); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if _, err := run("install-links", "--dir", dir, "--force"); err != nil {
+		t.Fatalf("install-links --force: %v", err)
+	}
+	if target, _ := os.Readlink(filepath.Join(dir, "svc")); target != exe {
+		t.Fatalf("svc not replaced, target %q", target)
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"unicode"
 
 	"github.com/chzyer/readline"
-	"github.com/spf13/pflag"
 
 	"github.com/pubgo/redant"
 )
@@ -573,17 +572,17 @@ func enumValuesFromOption(opt redant.Option) []string {
 	return uniqueSorted(vals)
 }
 
-func enumValuesFromValue(value pflag.Value) []string {
+func enumValuesFromValue(value redant.Value) []string {
 	if value == nil {
 		return nil
 	}
 
 	switch v := value.(type) {
 	case *redant.Enum:
-		return append([]string(nil), v.Choices...)
+		return append([]string(nil), v.AllowedChoices()...)
 	case *redant.EnumArray:
-		return append([]string(nil), v.Choices...)
-	case interface{ Underlying() pflag.Value }:
+		return append([]string(nil), v.AllowedChoices()...)
+	case interface{ Underlying() redant.Value }:
 		return enumValuesFromValue(v.Underlying())
 	default:
 		return nil
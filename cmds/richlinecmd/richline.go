@@ -16,7 +16,6 @@ import (
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/spf13/pflag"
 
 	"github.com/pubgo/redant"
 )
@@ -1797,16 +1796,16 @@ func enumValuesFromOption(opt redant.Option) []string {
 	return uniqueSorted(vals)
 }
 
-func enumValuesFromValue(value pflag.Value) []string {
+func enumValuesFromValue(value redant.Value) []string {
 	if value == nil {
 		return nil
 	}
 	switch v := value.(type) {
 	case *redant.Enum:
-		return append([]string(nil), v.Choices...)
+		return append([]string(nil), v.AllowedChoices()...)
 	case *redant.EnumArray:
-		return append([]string(nil), v.Choices...)
-	case interface{ Underlying() pflag.Value }:
+		return append([]string(nil), v.AllowedChoices()...)
+	case interface{ Underlying() redant.Value }:
 		return enumValuesFromValue(v.Underlying())
 	default:
 		return nil
@@ -0,0 +1,59 @@
+package usagestatscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pubgo/redant"
+)
+
+func New() *redant.Command {
+	var reset bool
+
+	return &redant.Command{
+		Use:   "usage-stats",
+		Short: "查看本地命令与标志使用统计",
+		Long:  "输出根命令开启 TrackUsage 后在 StateDir 中累计的命令与标志使用次数，数据仅保存在本地，用于辅助弃用决策。",
+		Options: redant.OptionSet{
+			{
+				Flag:        "reset",
+				Description: "清空已记录的使用统计",
+				Value:       redant.BoolOf(&reset),
+			},
+		},
+		Handler: func(ctx context.Context, inv *redant.Invocation) error {
+			root := inv.Command
+			for root.Parent() != nil {
+				root = root.Parent()
+			}
+
+			if root.StateDir == "" {
+				return errors.New("usage tracking requires StateDir on the root command")
+			}
+
+			if reset {
+				return redant.ResetUsageStats(root.StateDir)
+			}
+
+			stats, err := redant.LoadUsageStats(root.StateDir)
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintln(inv.Stdout, "Commands:")
+			for _, c := range stats.SortedCommands() {
+				_, _ = fmt.Fprintf(inv.Stdout, "  %6d  %s\n", c.Count, c.Name)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, "Flags:")
+			for _, c := range stats.SortedFlags() {
+				_, _ = fmt.Fprintf(inv.Stdout, "  %6d  %s\n", c.Count, c.Name)
+			}
+			return nil
+		},
+	}
+}
+
+func AddUsageStatsCommand(rootCmd *redant.Command) {
+	rootCmd.Children = append(rootCmd.Children, New())
+}
@@ -0,0 +1,60 @@
+package usagestatscmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pubgo/redant"
+)
+
+func TestUsageStatsCommand(t *testing.T) {
+	var force bool
+	root := &redant.Command{Use: "app", StateDir: t.TempDir(), TrackUsage: true}
+	root.Children = append(root.Children, &redant.Command{
+		Use: "deploy",
+		Options: redant.OptionSet{
+			{Flag: "force", Value: redant.BoolOf(&force)},
+		},
+		Handler: func(ctx context.Context, inv *redant.Invocation) error { return nil },
+	})
+	AddUsageStatsCommand(root)
+
+	for _, args := range [][]string{{"deploy", "--force"}, {"deploy"}} {
+		inv := root.Invoke(args...)
+		inv.Stdout = &bytes.Buffer{}
+		inv.Stderr = &bytes.Buffer{}
+		if err := inv.Run(); err != nil {
+			t.Fatalf("run %v: %v", args, err)
+		}
+	}
+
+	stdout := &bytes.Buffer{}
+	inv := root.Invoke("usage-stats")
+	inv.Stdout = stdout
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err != nil {
+		t.Fatalf("usage-stats: %v", err)
+	}
+	out := stdout.String()
+	for _, want := range []string{"2  app deploy", "1  --force"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+
+	inv = root.Invoke("usage-stats", "--reset")
+	inv.Stdout = &bytes.Buffer{}
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err != nil {
+		t.Fatalf("usage-stats --reset: %v", err)
+	}
+	stats, err := redant.LoadUsageStats(root.StateDir)
+	if err != nil {
+		t.Fatalf("load stats: %v", err)
+	}
+	if len(stats.Commands) != 0 || len(stats.Flags) != 0 {
+		t.Fatalf("expected empty stats after reset, got %+v", stats)
+	}
+}
@@ -0,0 +1,60 @@
+package redant
+
+import (
+	"slices"
+	"strings"
+)
+
+// lookupColonPath returns the descendant of c named by token, either a
+// single name or alias or a colon-separated path of them such as
+// "repo:commit" or "r:ci", or nil when some segment names no subcommand.
+func (c *Command) lookupColonPath(token string) *Command {
+	if token == "" {
+		return nil
+	}
+	cmd := c
+	for _, name := range strings.Split(token, ":") {
+		child, ok := cmd.children()[name]
+		if !ok {
+			return nil
+		}
+		child.setParent(cmd)
+		cmd = child
+	}
+	return cmd
+}
+
+// ColonPath returns the colon-separated path of c below the root, such as
+// "repo:commit", which invokes c as a single argument. The root's is "".
+func (c *Command) ColonPath() string {
+	var names []string
+	for cmd := c; cmd.parent != nil; cmd = cmd.parent {
+		names = append(names, cmd.Name())
+	}
+	slices.Reverse(names)
+	return strings.Join(names, ":")
+}
+
+// completeColonPath returns the colon paths, relative to c, of the visible
+// descendants of c that start with prefix.
+func (c *Command) completeColonPath(prefix string) []string {
+	var out []string
+	base := c.ColonPath()
+	_ = c.Walk(func(cmd *Command) error {
+		if cmd == c {
+			return nil
+		}
+		if cmd.Hidden {
+			return SkipChildren
+		}
+		path := cmd.ColonPath()
+		if base != "" {
+			path = strings.TrimPrefix(path, base+":")
+		}
+		if strings.HasPrefix(path, prefix) {
+			out = append(out, path)
+		}
+		return nil
+	})
+	return out
+}
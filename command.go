@@ -7,15 +7,16 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
-
-	"github.com/spf13/pflag"
+	"time"
 )
 
 // Command describes an executable command.
@@ -26,12 +27,24 @@ type Command struct {
 	// Children is a list of direct descendants.
 	Children []*Command
 
-	// Use is provided in form "command [flags] [args...]".
+	// Use is provided in form "command [flags] [args...]". When it is only
+	// the command name, the usage shown in help is generated from the
+	// command's Options and Args instead.
 	Use string
 
 	// Aliases is a list of alternative names for the command.
 	Aliases []string
 
+	// SuggestFor lists words that should suggest this command when given
+	// as an unknown subcommand of its parent, in addition to names within
+	// a small edit distance, e.g. {"rm", "remove"} for "delete".
+	SuggestFor []string
+
+	// Tags categorize the command, e.g. {"database", "dangerous"}. They are
+	// shown by --list-commands, which lists only the commands carrying
+	// every tag given as its value, e.g. --list-commands=database.
+	Tags []string
+
 	// Short is a one-line description of the command.
 	Short string
 
@@ -43,6 +56,22 @@ type Command struct {
 	// If set, the value is used as the deprecation message.
 	Deprecated string `json:"deprecated,omitempty"`
 
+	// RemovedIn is the application version in which a deprecated command is
+	// removed. Once the root Version reaches it, invoking the command fails
+	// instead of printing a warning.
+	RemovedIn string `json:"removedIn,omitempty"`
+
+	// RedirectTo renames a command: invoking it runs the command at this
+	// space separated path from the root instead, e.g. "config show" on an
+	// old "cfg show" node, after a single deprecation notice. Args and flags
+	// are those of the target; combine with Hidden to drop the old name
+	// from help.
+	RedirectTo string `json:"redirectTo,omitempty"`
+
+	// Version is the application version. It is only read from the root
+	// command and is used to enforce deprecation schedules (RemovedIn).
+	Version string `json:"version,omitempty"`
+
 	// Metadata stores extensible command annotations for higher-level behaviors
 	// (for example: mode=agent, agent.command=true).
 	Metadata map[string]string `json:"metadata,omitempty"`
@@ -52,18 +81,266 @@ type Command struct {
 	// its own flags.
 	RawArgs bool
 
+	// PassthroughArgs sits between normal parsing and RawArgs: flags
+	// declared for the command (including inherited and global ones) are
+	// parsed, while every other arg, unknown flags included, is kept
+	// verbatim and in order in Invocation.Args, as `docker run` does for
+	// the command it starts. Flag parsing still stops at "--".
+	PassthroughArgs bool
+
+	// StrictArgs binds key=value style args (query, form and JSON object
+	// tokens) only to declared Arg names: unknown keys are an error instead
+	// of falling through to positional assignment, and every required Arg
+	// must be supplied by some token.
+	StrictArgs bool
+
+	// OptionsFromArgs lets query, form and JSON object args set options
+	// of the same name, so `port=99` or {"port":99} work like --port 99
+	// and API-style invocations share the flag declarations. Keys naming an
+	// Arg still bind to it, and flags given on the command line win.
+	OptionsFromArgs bool
+
+	// StdinArgs appends newline-delimited args piped on stdin to those of
+	// the command line before they are bound, so that `ls | app rm` works
+	// without xargs. Flags among them are parsed too; nothing is read when
+	// stdin is a terminal or help is requested.
+	StdinArgs bool
+
+	// DefaultChild names (or aliases) the subcommand run when the command
+	// is invoked without a subcommand or args, e.g. "serve" so that `app`
+	// alone starts a daemon instead of printing help. --help still shows the
+	// command's own usage.
+	DefaultChild string
+
+	// ArgsPolicy validates the number of positional args before they are
+	// parsed, e.g. ExactArgs(1) or NoArgs, instead of wiring RequireNArgs
+	// middleware by hand. It is skipped when help is requested.
+	ArgsPolicy ArgsPolicy
+
+	// DisableAutoArgs stops Run from creating the arg1, arg2, ... args of
+	// Invocation.BoundArgs when the command declares no Args; positionals
+	// are then only available as Invocation.Args.
+	DisableAutoArgs bool
+
 	// Long is a detailed description of the command,
 	// presented on its help page. It may contain examples.
-	Long    string
+	Long string
+
+	// Examples are shown in an Examples section of the help page.
+	Examples []Example `json:"examples,omitempty"`
+
+	// SeeAlso lists related commands, as space separated paths from the
+	// root such as "config show", or URLs, in a See Also section of the
+	// help page. Lint reports paths naming no command.
+	SeeAlso []string `json:"seeAlso,omitempty"`
+
 	Options OptionSet
 	Args    ArgSet
 
+	// PersistentOptions are options declared on this command that cascade to
+	// all of its descendants. Unlike root options they are not treated as
+	// global flags, so a subtree can share flags without affecting the rest
+	// of the application.
+	PersistentOptions OptionSet
+
 	// Middleware is called before the Handler.
 	// Use Chain() to combine multiple middlewares.
+	// It also wraps runs rejected with a usage error, the handler being
+	// replaced by one returning the UsageError.
 	Middleware            MiddlewareFunc
 	Handler               HandlerFunc
 	ResponseHandler       ResponseHandler
 	ResponseStreamHandler ResponseStreamHandler
+
+	// PreRun and PostRun run right before and after the handler. The
+	// Persistent variants apply to the command and all of its descendants:
+	// the PersistentPreRun hooks run from the root down, before PreRun, and
+	// the PersistentPostRun hooks from the command up, after PostRun.
+	// Middleware wraps the hooks and the handler as a whole. A failing hook
+	// or handler stops the sequence, so post-run hooks only run on success.
+	PreRun            HandlerFunc
+	PostRun           HandlerFunc
+	PersistentPreRun  HandlerFunc
+	PersistentPostRun HandlerFunc
+
+	// SkipParentMiddleware excludes the middleware of the command's
+	// ancestors, e.g. the root's auth or logging middleware for `completion`
+	// or `version`. The command's own middleware, and that of the commands
+	// between it and the invoked descendant, still apply.
+	SkipParentMiddleware bool
+
+	// FallbackHandler runs instead of the handler when the command has
+	// children but its first positional arg names none of them, e.g. to
+	// dispatch `app foo` to an external app-foo binary git-style or to treat
+	// `app get mypod` as a resource name. inv.Args holds the unmatched
+	// tokens, starting with the unknown subcommand; Args and ArgsPolicy are
+	// not applied. Middleware still wraps it.
+	FallbackHandler HandlerFunc
+
+	// Annotations are arbitrary metadata for middleware and handlers, e.g.
+	// {"requires-auth": true}. Before middleware runs they are merged into
+	// Invocation.Annotations, a command's annotations overriding those of
+	// its ancestors. See Invocation.Annotation and GetAnnotation.
+	Annotations map[string]any
+
+	// Provide runs once per Run before middleware, for the command and all
+	// of its descendants, and returns the context passed to middleware and
+	// handlers. It is typically set on the root command to attach shared
+	// clients or loggers that handlers fetch with From. Provide hooks run
+	// from the root down; none run for help.
+	Provide ProvideFunc
+
+	// NotifySignals are the signals that cancel the context of the command
+	// and its descendants while they run, SIGINT and SIGTERM when no
+	// command in the path sets them. An empty non-nil slice disables the
+	// handling, leaving signals to their default behavior. Once a signal
+	// arrives, a second one terminates the process. A command returning
+	// the cancellation exits with 128 plus the signal number, e.g. 130 for
+	// SIGINT and 143 for SIGTERM, see ExitCode.
+	NotifySignals []os.Signal
+
+	// SignalGracePeriod bounds how long a command and its descendants may
+	// take to return after a notify signal canceled their context; the
+	// process then exits with the status of the signal. Zero inherits the
+	// period of the nearest ancestor setting one, or else
+	// DefaultSignalGracePeriod; a negative period never forces the exit.
+	SignalGracePeriod time.Duration
+
+	// NewLogger builds the Invocation.Logger for the command and its
+	// descendants, typically set on the root to configure one logger for
+	// all middleware and handlers. It is called after flags are parsed.
+	// The default logs text to Stderr, see Invocation.Logger.
+	NewLogger func(inv *Invocation) *slog.Logger
+
+	// Reporter, set on the root command, is told about the start and end
+	// of every run in the tree, for usage metrics.
+	Reporter Reporter
+
+	// RenderError, set on the root command, prints the error Run returned
+	// when running through Main, instead of DefaultErrorRenderer.
+	RenderError ErrorRenderer
+
+	// OnUsageError is called with the *UsageError of a command line
+	// rejected by the command or its descendants, e.g. an unknown flag or
+	// invalid args, and returns the error Run reports instead, to reword it
+	// or add a hint. The nearest command setting it applies. A returned
+	// error that is not a UsageError is wrapped in one, keeping ExitUsage;
+	// nil keeps the original error.
+	OnUsageError func(inv *Invocation, err error) error
+
+	// HelpHeader and HelpFooter, set on the root command, return text
+	// printed above and below the help page of cmd, such as support links,
+	// license notices or tips. Empty text prints nothing.
+	HelpHeader func(cmd *Command) string
+	HelpFooter func(cmd *Command) string
+
+	// Theme, set on the root command, replaces the DefaultTheme colors of
+	// help and listings.
+	Theme *Theme
+
+	// Localizer, set on the root command, translates help and the
+	// framework's messages into the language chosen with the --lang global
+	// flag it adds, or the LANG environment variable. See Catalog.
+	Localizer Localizer
+
+	// PromptMissing asks for the values of missing required options when
+	// Stdin is a terminal, for the command and its descendants, instead of
+	// failing right away. Secret options are read without echo. The
+	// built-in --non-interactive flag restores the error.
+	PromptMissing bool
+
+	// FlagOrder, set on the root command, is the order in which the options
+	// of every command are listed in help. Options are sorted by name by
+	// default.
+	FlagOrder FlagOrder `json:"flagOrder,omitempty"`
+
+	// StrictLint, set on the root command, makes Run fail when the command
+	// tree has any of the definition mistakes reported by Lint.
+	StrictLint bool
+
+	// Bundles lists registered option bundles (see RegisterBundle) whose
+	// options are added to Options, e.g. "logging" or "http-client".
+	// Flags colliding with the command's own options are an error.
+	Bundles []string
+
+	// StateDir is the directory where the framework persists local state,
+	// such as usage counters. Only read from the root command; stateful
+	// features are disabled when empty. See DefaultStateDir.
+	StateDir string
+
+	// TrackUsage enables local usage counters of commands and flags under
+	// StateDir, for data-driven deprecation decisions. No data leaves the
+	// machine. Only flags set on the command line are counted. Counters are
+	// approximate: the stats file is updated without a lock, so concurrent
+	// runs may lose increments. Only read from the root command.
+	TrackUsage bool
+
+	// Cooldown is the minimum time between two runs of this command,
+	// enforced through the state store, for commands that hammer external
+	// APIs when run in tight loops. Requires StateDir on the root command.
+	Cooldown time.Duration
+
+	// Effects declares the side effects of running the command, such as
+	// "creates a deployment" or "writes ~/.config/app/token", shown by
+	// --explain. Docs links shown there come from the "docs" Metadata key.
+	Effects []string `json:"effects,omitempty"`
+
+	// Limits bounds the resources used while the handler runs, for
+	// plugin-style commands. Applied via setrlimit where supported, which
+	// is process-wide: see Limits.
+	Limits Limits
+
+	// CrashReports writes a report file under StateDir and prints its path
+	// when a handler panics, before the panic continues. Errors returned by
+	// handlers are not reported; call Invocation.WriteCrashReport for those
+	// that deserve it. Only read from the root command.
+	CrashReports bool
+
+	// SlashFlags accepts Windows style "/flag:value", "/flag value" and
+	// "/?" on Windows builds by translating them to their "--flag" forms,
+	// easing migration of legacy tools. Only read from the root command.
+	SlashFlags bool
+
+	// ResponseFiles expands "@path" args into the newline-separated args
+	// listed in the file at path before parsing, for invocations too long
+	// for the command line. Relative paths are resolved against the
+	// invocation Dir. Only read from the root command.
+	ResponseFiles bool
+
+	// ArgvHook rewrites the args before anything else looks at them,
+	// including ResponseFiles and SlashFlags, e.g. to expand aliases or to
+	// translate the syntax of a legacy tool. An error aborts the run.
+	// Only read from the root command.
+	ArgvHook func(args []string) ([]string, error)
+
+	// NormalizeFlagName, if set, maps flag names typed by the user and
+	// declared by options to a canonical form before matching, so e.g.
+	// --Port and --PORT resolve to --port. It applies to the command and
+	// its descendants unless they set their own. See FoldFlagCase.
+	NormalizeFlagName func(name string) string
+
+	// DisableGlobalFlags keeps the built-in global flags (help,
+	// list-commands, env, ...) out of the command's flag set, for commands
+	// such as exec or run that pass arbitrary flags on; with RawArgs or
+	// PassthroughArgs, `app exec --help` then hands --help to the command.
+	// Application flags of the root command are still inherited.
+	DisableGlobalFlags bool
+
+	// DisableDefaultGlobals prevents the built-in global flags (help,
+	// list-commands, list-flags, env, env-file) from being added.
+	// Only meaningful on the root command.
+	DisableDefaultGlobals bool
+
+	// globalFlagsFn customizes the built-in global flags, see SetGlobalFlags.
+	globalFlagsFn func(defaults OptionSet) OptionSet
+}
+
+// Example is a sample invocation of a command shown in its help.
+type Example struct {
+	// Command is the command line, e.g. "app deploy --env prod".
+	Command string `json:"command"`
+	// Description explains what the example does.
+	Description string `json:"description,omitempty"`
 }
 
 func ascendingSortFn[T cmp.Ordered](a, b T) int {
@@ -77,7 +354,11 @@ func ascendingSortFn[T cmp.Ordered](a, b T) int {
 
 func appendMissingGlobalOptions(base, globals OptionSet) OptionSet {
 	existing := make(map[string]struct{}, len(base))
+	shorthands := make(map[string]struct{}, len(base))
 	for _, opt := range base {
+		if opt.Shorthand != "" {
+			shorthands[opt.Shorthand] = struct{}{}
+		}
 		if opt.Flag == "" {
 			continue
 		}
@@ -86,19 +367,124 @@ func appendMissingGlobalOptions(base, globals OptionSet) OptionSet {
 
 	for _, opt := range globals {
 		if opt.Flag == "" {
-			base = append(base, opt)
+			if !slices.ContainsFunc(base, func(o Option) bool { return o.Flag == "" && slices.Equal(o.Envs, opt.Envs) }) {
+				base = append(base, opt)
+			}
 			continue
 		}
 		if _, ok := existing[opt.Flag]; ok {
 			continue
 		}
+		// Application flags own their shorthand; the global keeps its long name.
+		if _, ok := shorthands[opt.Shorthand]; ok {
+			opt.Shorthand = ""
+		}
 		base = append(base, opt)
 		existing[opt.Flag] = struct{}{}
+		if opt.Shorthand != "" {
+			shorthands[opt.Shorthand] = struct{}{}
+		}
 	}
 
 	return base
 }
 
+// sameBuiltinOptions reports whether a and b declare the same built-in
+// global flags, in any order.
+func sameBuiltinOptions(a, b OptionSet) bool {
+	keys := func(opts OptionSet) []string {
+		var out []string
+		for _, opt := range opts {
+			if opt.builtin != "" {
+				out = append(out, fmt.Sprintf("%s|%s|%s|%t", opt.builtin, opt.Flag, opt.Shorthand, opt.Hidden))
+			}
+		}
+		slices.Sort(out)
+		return out
+	}
+	return slices.Equal(keys(a), keys(b))
+}
+
+// SetGlobalFlags installs a hook that receives the built-in global flags and
+// returns the set that is added to the root command. The hook may rename,
+// hide, drop or replace built-ins; renamed built-ins keep their behavior.
+// Only meaningful on the root command.
+func (c *Command) SetGlobalFlags(fn func(defaults OptionSet) OptionSet) {
+	c.globalFlagsFn = fn
+}
+
+// defaultGlobalFlags returns the built-in global flags configured for c.
+func (c *Command) defaultGlobalFlags() OptionSet {
+	if c.DisableDefaultGlobals {
+		return nil
+	}
+	globals := GlobalFlags()
+	if c.Localizer != nil {
+		globals = append(globals, langOption())
+	}
+	if c.globalFlagsFn != nil {
+		globals = c.globalFlagsFn(globals)
+	}
+	return globals
+}
+
+// root returns the root command of the tree c belongs to.
+func (c *Command) root() *Command {
+	root := c
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// flagNormalizer returns the nearest NormalizeFlagName in the command's
+// ancestry, or nil.
+func (c *Command) flagNormalizer() func(string) string {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.NormalizeFlagName != nil {
+			return cmd.NormalizeFlagName
+		}
+	}
+	return nil
+}
+
+// builtinOption returns the root option created for the built-in global flag
+// id, or nil if the application disabled or replaced it.
+func (c *Command) builtinOption(id string) *Option {
+	root := c.root()
+	for i := range root.Options {
+		if root.Options[i].builtin == id && root.Options[i].Flag != "" {
+			return &root.Options[i]
+		}
+	}
+	return nil
+}
+
+// builtinFlagName returns the effective flag name of the built-in global flag id.
+func (c *Command) builtinFlagName(id string) string {
+	if opt := c.builtinOption(id); opt != nil {
+		return opt.Flag
+	}
+	return ""
+}
+
+// envFlagNames returns the effective names of the built-in env preload flags.
+func (c *Command) envFlagNames() envFlagNames {
+	var names envFlagNames
+	if opt := c.builtinOption(builtinEnv); opt != nil {
+		names.env = opt.Flag
+		names.envShort = opt.Shorthand
+	}
+	names.envFile = c.builtinFlagName(builtinEnvFile)
+	return names
+}
+
+// FoldFlagCase is a NormalizeFlagName function that matches flag names
+// case-insensitively.
+func FoldFlagCase(name string) string {
+	return strings.ToLower(name)
+}
+
 // init performs initialization and linting on the command and all its children.
 func (c *Command) init() error {
 	if c.Use == "" {
@@ -106,52 +492,159 @@ func (c *Command) init() error {
 	}
 	var merr error
 
-	// Add global flags to the root command only
+	// Add global flags to the root command only. Like the rest of init,
+	// this only writes to the command when something changes, so that
+	// initializing an initialized tree is safe while other invocations read it.
+	// The built-ins are reconciled with the current configuration, so
+	// toggling DisableDefaultGlobals or SetGlobalFlags between runs or
+	// attaching an initialized root under another command takes effect.
+	isBuiltin := func(opt Option) bool { return opt.builtin != "" }
 	if c.parent == nil {
-		globalFlags := GlobalFlags()
-		c.Options = appendMissingGlobalOptions(c.Options, globalFlags)
+		own := slices.DeleteFunc(slices.Clone(c.Options), isBuiltin)
+		if opts := appendMissingGlobalOptions(own, c.defaultGlobalFlags()); len(opts) != len(c.Options) || !sameBuiltinOptions(opts, c.Options) {
+			c.Options = opts
+		}
+	} else if slices.ContainsFunc(c.Options, isBuiltin) {
+		c.Options = slices.DeleteFunc(slices.Clone(c.Options), isBuiltin)
 	}
 
-	for i := range c.Options {
-		opt := &c.Options[i]
-		// Validate that option has an identifier (Flag or Env)
-		if opt.Flag == "" && len(opt.Envs) == 0 {
-			merr = errors.Join(merr, fmt.Errorf("option must have a Flag or Env field"))
+	if err := c.resolveBundles(); err != nil {
+		merr = errors.Join(merr, err)
+	}
+
+	for _, opts := range []OptionSet{c.Options, c.PersistentOptions} {
+		for i := range opts {
+			opt := &opts[i]
+			// Validate that option has an identifier (Flag or Env)
+			if opt.Flag == "" && len(opt.Envs) == 0 {
+				merr = errors.Join(merr, fmt.Errorf("option must have a Flag or Env field"))
+			}
+			if opt.Description != "" {
+				if desc := strings.Trim(strings.ToTitle(strings.TrimSpace(opt.Description)), ".") + "."; desc != opt.Description {
+					opt.Description = desc
+				}
+			}
+			if err := opt.validateRange(); err != nil {
+				merr = errors.Join(merr, err)
+			}
+			if _, ok := opt.Value.(SliceValue); opt.NoSplit && !ok {
+				merr = errors.Join(merr, fmt.Errorf("flag %q: NoSplit requires an array value", opt.name()))
+			}
 		}
-		if opt.Description != "" {
-			opt.Description = strings.Trim(strings.ToTitle(strings.TrimSpace(opt.Description)), ".") + "."
+	}
+	for i, arg := range c.Args {
+		if err := arg.validate(i, c.Args); err != nil {
+			merr = errors.Join(merr, err)
 		}
 	}
 
-	if _, err := c.resolveConfiguredHandler(); err != nil {
+	for _, opt := range c.PersistentOptions {
+		if opt.Flag == "" {
+			continue
+		}
+		for _, local := range c.Options {
+			if local.Flag == opt.Flag {
+				merr = errors.Join(merr, fmt.Errorf("flag %q is declared in both Options and PersistentOptions", opt.Flag))
+			}
+		}
+	}
+
+	if err := c.Limits.validate(); err != nil {
+		merr = errors.Join(merr, err)
+	}
+	if err := c.checkChildNames(); err != nil {
 		merr = errors.Join(merr, err)
 	}
+	if _, ok := c.children()[c.DefaultChild]; c.DefaultChild != "" && !ok {
+		merr = errors.Join(merr, fmt.Errorf("default child %q is not a subcommand of %q", c.DefaultChild, c.Name()))
+	}
+	if c.Cooldown > 0 && c.stateDir() == "" {
+		merr = errors.Join(merr, fmt.Errorf("Cooldown requires StateDir on the root command"))
+	}
 
-	slices.SortFunc(c.Options, func(a, b Option) int {
-		// Use Flag for sorting, fallback to Env if Flag is empty
-		nameA := a.Flag
-		if nameA == "" && len(a.Envs) > 0 {
-			nameA = a.Envs[0]
-		}
-		nameB := b.Flag
-		if nameB == "" && len(b.Envs) > 0 {
-			nameB = b.Envs[0]
+	if normalize := c.flagNormalizer(); normalize != nil {
+		declared := make(map[string]string)
+		for _, opt := range c.localOptions() {
+			if opt.Flag == "" {
+				continue
+			}
+			key := normalize(opt.Flag)
+			if prev, ok := declared[key]; ok {
+				merr = errors.Join(merr, fmt.Errorf("flags %q and %q collide after name normalization", prev, opt.Flag))
+				continue
+			}
+			declared[key] = opt.Flag
 		}
-		return ascendingSortFn(nameA, nameB)
-	})
-	slices.SortFunc(c.Children, func(a, b *Command) int {
+	}
+
+	if _, err := c.resolveConfiguredHandler(); err != nil {
+		merr = errors.Join(merr, err)
+	}
+
+	sortChildren := func(a, b *Command) int {
 		return ascendingSortFn(a.Name(), b.Name())
-	})
+	}
+	order := c.root().FlagOrder
+	order.sortOptions(c.Options)
+	order.sortOptions(c.PersistentOptions)
+	if !slices.IsSortedFunc(c.Children, sortChildren) {
+		slices.SortFunc(c.Children, sortChildren)
+	}
 	for _, child := range c.Children {
-		child.parent = c
+		child.setParent(c)
 		err := child.init()
 		if err != nil {
 			merr = errors.Join(merr, fmt.Errorf("command %v: %w", child.Name(), err))
 		}
 	}
+	if c.parent == nil && c.StrictLint {
+		merr = errors.Join(merr, c.lint())
+	}
+	return merr
+}
+
+// checkChildNames reports subcommand names and aliases used by two
+// children of c, which would make dispatch ambiguous.
+func (c *Command) checkChildNames() error {
+	type use struct {
+		index int
+		kind  string
+	}
+	describe := func(u use) string {
+		return fmt.Sprintf("%s of Children[%d] %q", u.kind, u.index, c.Children[u.index].FullName())
+	}
+
+	var merr error
+	owners := make(map[string]use)
+	for i, child := range c.Children {
+		child.setParent(c)
+		for j, name := range append([]string{child.Name()}, child.Aliases...) {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			u := use{index: i, kind: "name"}
+			if j > 0 {
+				u.kind = "alias"
+			}
+			if owner, ok := owners[name]; ok && c.Children[owner.index] != child {
+				merr = errors.Join(merr, fmt.Errorf("duplicate command name %q: %s and %s", name, describe(owner), describe(u)))
+				continue
+			}
+			owners[name] = u
+		}
+	}
 	return merr
 }
 
+// setParent links c to its parent command. The link is only written when
+// it changes, so that concurrent invocations only read an initialized tree.
+func (c *Command) setParent(parent *Command) {
+	if c.parent != parent {
+		c.parent = parent
+	}
+}
+
 // Name returns the first word in the Use string.
 func (c *Command) Name() string {
 	return strings.Split(c.Use, " ")[0]
@@ -197,12 +690,15 @@ func (c *Command) Parent() *Command {
 	return c.parent
 }
 
+// FullUsage returns the usage line of the command, as shown in help: its
+// parents' full name followed by Use, or by a usage generated from the
+// command's flags and args when Use is only the command name.
 func (c *Command) FullUsage() string {
 	var uses []string
 	if c.parent != nil {
 		uses = append(uses, c.parent.FullName())
 	}
-	uses = append(uses, c.Use)
+	uses = append(uses, c.usage())
 	return strings.Join(uses, " ")
 }
 
@@ -213,6 +709,23 @@ func (c *Command) FullOptions() OptionSet {
 		opts = append(opts, c.parent.FullOptions()...)
 	}
 	opts = append(opts, c.Options...)
+	opts = append(opts, c.PersistentOptions...)
+	return opts
+}
+
+// localOptions returns the options declared on the command itself,
+// including its persistent options.
+func (c *Command) localOptions() OptionSet {
+	return slices.Concat(c.Options, c.PersistentOptions)
+}
+
+// inheritedPersistentOptions returns the persistent options declared on the
+// command's ancestors, nearest ancestor first.
+func (c *Command) inheritedPersistentOptions() OptionSet {
+	var opts OptionSet
+	for p := c.parent; p != nil; p = p.parent {
+		opts = append(opts, p.PersistentOptions...)
+	}
 	return opts
 }
 
@@ -263,7 +776,7 @@ func (c *Command) Run(ctx context.Context) error {
 type Invocation struct {
 	ctx     context.Context
 	Command *Command
-	Flags   *pflag.FlagSet
+	Flags   *FlagSet
 
 	// Args is reduced into the remaining arguments after parsing flags
 	// during Run.
@@ -278,25 +791,153 @@ type Invocation struct {
 	Stderr io.Writer
 	Stdin  io.Reader
 
+	// Dir is the working directory of the invocation, used by Exec and
+	// Path and to resolve @path response files and the paths given to
+	// --pprof-cpu, --pprof-mem and --trace. Empty means the working
+	// directory of the process. Middleware
+	// such as PinToRepoRoot set it instead of changing the process-wide
+	// directory, so concurrent invocations do not affect each other.
+	Dir string
+
 	responseStream chan any
 	responseValue  any
 
+	// Logger is the logger shared by middleware and handlers, also
+	// available from their context through LoggerFrom. Unless set before
+	// Run, it is built by the nearest Command.NewLogger, or logs text to
+	// Stderr at the level of the --log-level flag when the command has one
+	// (see the "logging" bundle), or else at the level matching Verbosity.
+	Logger *slog.Logger
+
 	// Annotations is a map of arbitrary annotations to attach to the invocation.
+	// Run merges the Annotations of the executed command and its ancestors
+	// into it; values set here before Run take precedence.
 	Annotations map[string]any
 
+	// BoundArgs are the args bound during Run: the command's Args, or
+	// string args named arg1, arg2, ... created from the positionals when
+	// the command declares none. See Command.DisableAutoArgs.
+	BoundArgs ArgSet
+
+	// environ is the synthetic environment set by WithEnviron.
+	// When nil, the process environment is used.
+	environ mapEnv
+
+	// explicitWidth is set by SetWidth; cachedWidth memoizes Width.
+	explicitWidth int
+	cachedWidth   int
+
+	// features holds toggles enabled for this invocation.
+	features map[Feature]bool
+
+	// argSources records where each arg got its value, see ArgSource.
+	argSources map[string]ArgSource
+
+	// clock and randSource are set by WithClock and WithRandSource.
+	clock      Clock
+	randSource rand.Source
+
+	// promptInput buffers Stdin for Prompt and the other prompts.
+	promptInput *promptInput
+
+	// cleanups are the functions registered with Defer.
+	cleanups []func() error
+
+	// argvFlags are the names of the flags set on the command line, as
+	// opposed to from env vars or by redant itself, for usage tracking.
+	argvFlags map[string]bool
+
+	// helpAll is set by "help --all" to show the help of every command,
+	// helpSearch by "help --search" to the keyword to look for.
+	helpAll    bool
+	helpSearch string
+
+	// preset records the fields set by the With builders, which WithOS
+	// keeps.
+	preset presetFields
+
 	// testing
 	signalNotifyContext func(parent context.Context, signals ...os.Signal) (ctx context.Context, stop context.CancelFunc)
+	exitFn              func(code int)
+	// stdinTerminal overrides the terminal detection of Interactive.
+	stdinTerminal *bool
 }
 
+// presetFields is a set of Invocation fields configured by builders.
+type presetFields uint8
+
+const (
+	presetArgs presetFields = 1 << iota
+	presetArg0
+	presetStdin
+	presetStdout
+	presetStderr
+)
+
 // WithOS returns the invocation as a main package, filling in the invocation's unset
-// fields with OS defaults.
+// fields with OS defaults. Fields set by WithArgs, WithArgv0, WithStdin,
+// WithStdout and WithStderr are kept, so
+//
+//	cmd.Invoke().WithStdout(&buf).WithOS()
+//
+// runs with the process args and stdin but writes its output to buf.
+//
+// Arg0 is set from os.Args[0], so a binary started through a symlink named
+// after a root-level subcommand or one of its aliases runs that subcommand
+// when the command line names none (busybox-style multi-call). The
+// extension is ignored, making "deploy.exe" match "deploy". See
+// Command.MultiCallNames for the names that dispatch this way.
 func (inv *Invocation) WithOS() *Invocation {
 	return inv.with(func(i *Invocation) {
-		i.Stdout = os.Stdout
-		i.Stderr = os.Stderr
-		i.Stdin = os.Stdin
-		i.Arg0 = os.Args[0]
-		i.Args = os.Args[1:]
+		if i.preset&presetStdout == 0 {
+			i.Stdout = os.Stdout
+		}
+		if i.preset&presetStderr == 0 {
+			i.Stderr = os.Stderr
+		}
+		if i.preset&presetStdin == 0 {
+			i.Stdin = os.Stdin
+		}
+		if i.preset&presetArg0 == 0 {
+			i.Arg0 = os.Args[0]
+		}
+		if i.preset&presetArgs == 0 {
+			i.Args = os.Args[1:]
+		}
+	})
+}
+
+// WithArgs returns the invocation with args as its command line, not
+// including the executable name.
+func (inv *Invocation) WithArgs(args ...string) *Invocation {
+	return inv.with(func(i *Invocation) {
+		i.Args = args
+		i.preset |= presetArgs
+	})
+}
+
+// WithStdin returns the invocation reading its input from r.
+func (inv *Invocation) WithStdin(r io.Reader) *Invocation {
+	return inv.with(func(i *Invocation) {
+		i.Stdin = r
+		i.preset |= presetStdin
+	})
+}
+
+// WithStdout returns the invocation writing its output to w.
+func (inv *Invocation) WithStdout(w io.Writer) *Invocation {
+	return inv.with(func(i *Invocation) {
+		i.Stdout = w
+		i.preset |= presetStdout
+	})
+}
+
+// WithStderr returns the invocation writing its errors and diagnostics
+// to w.
+func (inv *Invocation) WithStderr(w io.Writer) *Invocation {
+	return inv.with(func(i *Invocation) {
+		i.Stderr = w
+		i.preset |= presetStderr
 	})
 }
 
@@ -306,6 +947,7 @@ func (inv *Invocation) WithOS() *Invocation {
 func (inv *Invocation) WithArgv0(arg0 string) *Invocation {
 	return inv.with(func(i *Invocation) {
 		i.Arg0 = arg0
+		i.preset |= presetArg0
 	})
 }
 
@@ -331,7 +973,7 @@ func (inv *Invocation) SignalNotifyContext(parent context.Context, signals ...os
 
 func (inv *Invocation) WithTestParsedFlags(
 	_ testing.TB, // ensure we only call this from tests
-	parsedFlags *pflag.FlagSet,
+	parsedFlags *FlagSet,
 ) *Invocation {
 	return inv.with(func(i *Invocation) {
 		i.Flags = parsedFlags
@@ -392,7 +1034,154 @@ func (inv *Invocation) clearResponse() {
 	inv.responseValue = nil
 }
 
-func (inv *Invocation) ParsedFlags() *pflag.FlagSet {
+// checkOptionRanges validates Min/Max of every option that received a value
+// from a flag, env var or default. Deeper commands win on duplicate flags.
+func (inv *Invocation) checkOptionRanges() error {
+	if inv.Flags == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		for _, opt := range cmd.localOptions() {
+			if opt.Flag == "" || seen[opt.Flag] {
+				continue
+			}
+			seen[opt.Flag] = true
+			f := inv.Flags.Lookup(opt.Flag)
+			if f == nil || (!f.Changed && opt.Default == "") {
+				continue
+			}
+			if err := opt.checkRange(f.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnvOnlyOptions sets the values of options that have Envs but no
+// Flag from their default and the first non-empty env var, and returns the
+// names of those set from env. Deeper commands win on duplicates.
+func (inv *Invocation) applyEnvOnlyOptions() (map[string]bool, error) {
+	set := make(map[string]bool)
+	seen := make(map[string]bool)
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		for _, opt := range cmd.localOptions() {
+			if opt.Flag != "" || len(opt.Envs) == 0 || opt.Value == nil {
+				continue
+			}
+			name := opt.name()
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			val := opt.wrapValue(opt.Value)
+			if opt.Default != "" {
+				if err := opt.set(val, opt.Default, inv.LookupEnv); err != nil {
+					return nil, fmt.Errorf("setting default of %s: %w", name, err)
+				}
+			}
+			for _, env := range opt.Envs {
+				raw, _ := inv.LookupEnv(env)
+				if raw == "" {
+					continue
+				}
+				if err := opt.setFromEnv(val, raw, inv.LookupEnv); err != nil {
+					return nil, fmt.Errorf("parsing $%s: %w", env, err)
+				}
+				if err := opt.checkRange(val); err != nil {
+					return nil, err
+				}
+				set[name] = true
+				break
+			}
+		}
+	}
+	return set, nil
+}
+
+// validateOptions runs the Validate hook of every option in effect for the
+// invocation. Deeper commands win on duplicate flags.
+func (inv *Invocation) validateOptions() error {
+	seen := make(map[string]bool)
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		for _, opt := range cmd.localOptions() {
+			name := opt.name()
+			if name != "" {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+			}
+			if opt.Validate == nil {
+				continue
+			}
+			val := opt.Value
+			if inv.Flags != nil && opt.Flag != "" {
+				if f := inv.Flags.Lookup(opt.Flag); f != nil {
+					val = f.Value
+				}
+			}
+			if err := opt.Validate(inv, val); err != nil {
+				return fmt.Errorf("validation for flag %q failed: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// builtinBool reports whether the built-in boolean global flag id is set.
+func (inv *Invocation) builtinBool(id string) bool {
+	if inv.Flags == nil {
+		return false
+	}
+	name := inv.Command.builtinFlagName(id)
+	if name == "" {
+		return false
+	}
+	v, err := inv.Flags.GetBool(name)
+	return err == nil && v
+}
+
+// builtinString returns the value of the built-in string global flag id.
+func (inv *Invocation) builtinString(id string) string {
+	if inv.Flags == nil {
+		return ""
+	}
+	name := inv.Command.builtinFlagName(id)
+	if name == "" {
+		return ""
+	}
+	if f := inv.Flags.Lookup(name); f != nil {
+		switch v := f.Value.(type) {
+		case *String:
+			return string(*v)
+		case *Enum:
+			return *v.Value
+		}
+	}
+	return ""
+}
+
+// builtinStrings returns the values of the built-in string array global flag id.
+func (inv *Invocation) builtinStrings(id string) []string {
+	if inv.Flags == nil {
+		return nil
+	}
+	name := inv.Command.builtinFlagName(id)
+	if name == "" {
+		return nil
+	}
+	if f := inv.Flags.Lookup(name); f != nil {
+		if v, ok := f.Value.(*StringArray); ok {
+			return *v
+		}
+	}
+	return nil
+}
+
+func (inv *Invocation) ParsedFlags() *FlagSet {
 	if inv.Flags == nil {
 		panic("flags not parsed, has Run() been called?")
 	}
@@ -404,18 +1193,9 @@ type runState struct {
 	commandDepth int
 
 	flagParseErr error
-}
 
-func copyFlagSetWithout(fs *pflag.FlagSet, without string) *pflag.FlagSet {
-	fs2 := pflag.NewFlagSet("", pflag.ContinueOnError)
-	fs2.Usage = func() {}
-	fs.VisitAll(func(f *pflag.Flag) {
-		if f.Name == without {
-			return
-		}
-		fs2.AddFlag(f)
-	})
-	return fs2
+	// deprecationChecked records commands whose deprecation was reported.
+	deprecationChecked map[*Command]bool
 }
 
 func (inv *Invocation) CurWords() (prev, cur string) {
@@ -454,32 +1234,6 @@ func getExecCommand(parentCmd *Command, commands map[string]*Command, args []str
 		return parentCmd, 0
 	}
 
-	// Try to find command by colon-separated path
-	if strings.Contains(args[0], ":") {
-		if cmd, exists := commands[args[0]]; exists {
-			return cmd, 1
-		}
-		// If not found, try to find parent command and process subcommands
-		parts := strings.Split(args[0], ":")
-		if len(parts) > 0 {
-			cmd := parentCmd
-			for _, part := range parts {
-				found := false
-				for _, child := range cmd.Children {
-					if child.Name() == part {
-						cmd = child
-						found = true
-						break
-					}
-				}
-				if !found {
-					return parentCmd, 0 // Return parent if path not found
-				}
-			}
-			return cmd, 1
-		}
-	}
-
 	currentCmd := parentCmd
 	consumedArgs := 0
 
@@ -489,21 +1243,15 @@ func getExecCommand(parentCmd *Command, commands map[string]*Command, args []str
 		consumedArgs = 1
 	}
 
-	// Walk down the command tree using remaining args.
+	// Walk down the command tree using remaining args, each of which may be
+	// a colon-separated path such as "repo:commit".
 	for i := consumedArgs; i < len(args); i++ {
-		arg := args[i]
-		found := false
-		for _, child := range currentCmd.Children {
-			if child.Name() == arg {
-				currentCmd = child
-				consumedArgs = i + 1
-				found = true
-				break
-			}
-		}
-		if !found {
+		child := currentCmd.lookupColonPath(args[i])
+		if child == nil {
 			break // Stop if command not found
 		}
+		currentCmd = child
+		consumedArgs = i + 1
 	}
 
 	return currentCmd, consumedArgs
@@ -523,33 +1271,40 @@ func normalizeArgv0(arg0 string) string {
 	return base
 }
 
-func addCommandMapping(commandMap map[string]*Command, key string, cmd *Command) {
+func addCommandMapping(commandMap map[string]*Command, key string, cmd *Command) error {
 	if key == "" {
-		return
+		return nil
 	}
 	if existing := commandMap[key]; existing != nil && existing != cmd {
-		log.Panicf("duplicate command name: %s", key)
+		return fmt.Errorf("duplicate command name %q: used by %q and %q", key, existing.FullName(), cmd.FullName())
 	}
 	commandMap[key] = cmd
+	return nil
 }
 
-func getCommands(cmd *Command, parentName string) map[string]*Command {
+func getCommands(cmd *Command, parentName string) (map[string]*Command, error) {
 	if cmd == nil {
-		return nil
+		return nil, nil
 	}
 
 	commandMap := make(map[string]*Command)
+	var firstErr error
+	add := func(key string, cmd *Command) {
+		if err := addCommandMapping(commandMap, key, cmd); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 
 	name := parentName + ":" + cmd.Name()
 	if parentName == "" {
 		name = cmd.Name()
 	}
 
-	addCommandMapping(commandMap, name, cmd)
+	add(name, cmd)
 
 	// Allow busybox-style short lookups for root-level children (one hop away from root).
 	if cmd.parent != nil && cmd.parent.parent == nil {
-		addCommandMapping(commandMap, cmd.Name(), cmd)
+		add(cmd.Name(), cmd)
 	}
 
 	for _, alias := range cmd.Aliases {
@@ -563,19 +1318,23 @@ func getCommands(cmd *Command, parentName string) map[string]*Command {
 			aliasName = alias
 		}
 
-		addCommandMapping(commandMap, aliasName, cmd)
+		add(aliasName, cmd)
 
 		if cmd.parent != nil && cmd.parent.parent == nil {
-			addCommandMapping(commandMap, alias, cmd)
+			add(alias, cmd)
 		}
 	}
 	for _, child := range cmd.Children {
-		for n, command := range getCommands(child, name) {
-			addCommandMapping(commandMap, n, command)
+		childCommands, err := getCommands(child, name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		for n, command := range childCommands {
+			add(n, command)
 		}
 	}
 
-	return commandMap
+	return commandMap, firstErr
 }
 
 func resolveArgv0Command(arg0 string, commands map[string]*Command) *Command {
@@ -591,11 +1350,17 @@ func resolveArgv0Command(arg0 string, commands map[string]*Command) *Command {
 	return cmd
 }
 
-func (inv *Invocation) setParentCommand(parent *Command, children []*Command) {
-	for _, child := range children {
-		child.parent = parent
-		inv.setParentCommand(child, child.Children)
+// resolveExecCommand returns the command selected by args like
+// getExecCommand, falling back to the command named by arg0 when args
+// name no subcommand.
+func resolveExecCommand(root *Command, commands map[string]*Command, args []string, arg0 string) (*Command, int) {
+	cmd, consumed := getExecCommand(root, commands, args)
+	if consumed == 0 {
+		if argv0Cmd := resolveArgv0Command(arg0, commands); argv0Cmd != nil {
+			cmd = argv0Cmd
+		}
 	}
+	return cmd, consumed
 }
 
 // run recursively executes the command and its children.
@@ -603,44 +1368,36 @@ func (inv *Invocation) setParentCommand(parent *Command, children []*Command) {
 // anywhere in the command invocation.
 func (inv *Invocation) run(state *runState) error {
 	parent := inv.Command
-	inv.setParentCommand(inv.Command, inv.Command.Children)
-
-	if inv.Command.Deprecated != "" {
-		if _, err := fmt.Fprintf(inv.Stderr, "%s %q is deprecated!. %s\n",
-			prettyHeader("warning"),
-			inv.Command.FullName(),
-			inv.Command.Deprecated,
-		); err != nil {
-			return fmt.Errorf("write deprecated warning: %w", err)
-		}
-	}
 
 	// Organize command tree
-	commands := getCommands(parent, "")
+	commands, err := getCommands(parent, "")
+	if err != nil {
+		return err
+	}
 
 	// Use the command returned by getExecCommand
 	var consumed int
-	inv.Command, consumed = getExecCommand(parent, commands, state.allArgs)
-	if consumed == 0 {
-		if cmd := resolveArgv0Command(inv.Arg0, commands); cmd != nil {
-			inv.Command = cmd
-		}
-	}
+	inv.Command, consumed = resolveExecCommand(parent, commands, state.allArgs, inv.Arg0)
 	if consumed > 0 && consumed <= len(state.allArgs) {
 		state.allArgs = state.allArgs[consumed:]
 	}
+	if err := inv.followRedirect(state); err != nil {
+		return err
+	}
 
 	// Check for global flags before proceeding
 	if inv.Flags == nil {
-		inv.Flags = pflag.NewFlagSet(inv.Command.Name(), pflag.ContinueOnError)
-		// We handle Usage ourselves.
-		inv.Flags.Usage = func() {}
+		inv.Flags = NewFlagSet(inv.Command.Name())
+		inv.Flags.SetOutput(inv.Stderr)
+	}
+	if normalize := inv.Command.flagNormalizer(); normalize != nil {
+		inv.Flags.SetNormalizeFunc(normalize)
 	}
 
 	// Add global flags to the flag set
 	globalFlags := inv.Command.GetGlobalFlags()
-	globalFlagSet := globalFlags.FlagSet(inv.Command.Name())
-	globalFlagSet.VisitAll(func(f *pflag.Flag) {
+	globalFlagSet := globalFlags.flagSet(inv.Command.Name(), inv.LookupEnv)
+	globalFlagSet.VisitAll(func(f *Flag) {
 		if inv.Flags.Lookup(f.Name) == nil {
 			inv.Flags.AddFlag(f)
 		}
@@ -649,20 +1406,29 @@ func (inv *Invocation) run(state *runState) error {
 	// Add flags from all parent commands to support flag inheritance
 	// This allows child commands to use flags defined in parent commands
 	for p := inv.Command.parent; p != nil; p = p.parent {
-		p.Options.FlagSet(p.Name()).VisitAll(func(f *pflag.Flag) {
+		localOpts := p.localOptions()
+		localOpts.flagSet(p.Name(), inv.LookupEnv).VisitAll(func(f *Flag) {
 			if inv.Flags.Lookup(f.Name) == nil {
 				inv.Flags.AddFlag(f)
 			}
 		})
 	}
 
-	// If we find a duplicate flag, we want the deeper command's flag to override
-	// the shallow one. Unfortunately, pflag has no way to remove a flag, so we
-	// have to create a copy of the flagset without a value.
-	inv.Command.Options.FlagSet(inv.Command.Name()).VisitAll(func(f *pflag.Flag) {
-		if inv.Flags.Lookup(f.Name) != nil {
-			inv.Flags = copyFlagSetWithout(inv.Flags, f.Name)
+	// Commands passing arbitrary flags through drop the built-in globals
+	// before their own flags are added.
+	if inv.Command.DisableGlobalFlags {
+		for _, opt := range inv.Command.root().Options {
+			if opt.builtin != "" && opt.Flag != "" {
+				inv.Flags.remove(opt.Flag)
+			}
 		}
+	}
+
+	// If we find a duplicate flag, we want the deeper command's flag to override
+	// the shallow one.
+	localOpts := inv.Command.localOptions()
+	localOpts.flagSet(inv.Command.Name(), inv.LookupEnv).VisitAll(func(f *Flag) {
+		inv.Flags.remove(f.Name)
 		inv.Flags.AddFlag(f)
 	})
 
@@ -672,21 +1438,28 @@ func (inv *Invocation) run(state *runState) error {
 	if !inv.Command.RawArgs {
 		// Flag parsing will fail on intermediate commands in the command tree,
 		// so we check the error after looking for a child command.
-		state.flagParseErr = inv.Flags.Parse(state.allArgs)
-		parsedArgs = inv.Flags.Args()
+		if inv.Command.PassthroughArgs {
+			flagArgs, rest := splitPassthroughArgs(inv.Flags, state.allArgs)
+			state.flagParseErr = inv.parseFlags(flagArgs)
+			parsedArgs = rest
+		} else {
+			args, restore := inv.protectNegativeNumbers(state.allArgs)
+			state.flagParseErr = inv.parseFlags(args)
+			parsedArgs = restore(inv.Flags.Args())
+		}
 	}
 
 	// Handle global flags
 	if inv.Flags != nil {
 		// Check for --list-commands flag
-		if listCommands, err := inv.Flags.GetBool("list-commands"); err == nil && listCommands {
-			PrintCommands(parent) // Use parent to show full tree
+		if list, tags := inv.listCommands(); list {
+			printCommands(inv, parent, inv.builtinBool(builtinShowHidden), tags) // Use parent to show full tree
 			return nil
 		}
 
 		// Check for --list-flags flag
-		if listFlags, err := inv.Flags.GetBool("list-flags"); err == nil && listFlags {
-			PrintFlags(parent)
+		if inv.builtinBool(builtinListFlags) {
+			printFlags(inv, parent, inv.builtinBool(builtinShowHidden))
 			return nil
 		}
 	}
@@ -696,14 +1469,13 @@ func (inv *Invocation) run(state *runState) error {
 	// values for subcommand names.
 	if len(parsedArgs) > state.commandDepth {
 		nextArg := parsedArgs[state.commandDepth]
-		if child, ok := inv.Command.children()[nextArg]; ok {
-			child.parent = inv.Command
+		if child := inv.Command.lookupColonPath(nextArg); child != nil {
 			inv.Command = child
 			state.commandDepth++
 			// Clear the Deprecated field on already-parsed flags to avoid
 			// printing duplicate deprecated warnings when re-parsing in child commands.
 			// The flags are shared between parent and child FlagSets.
-			inv.Flags.VisitAll(func(f *pflag.Flag) {
+			inv.Flags.VisitAll(func(f *Flag) {
 				if f.Changed {
 					f.Deprecated = ""
 				}
@@ -712,83 +1484,154 @@ func (inv *Invocation) run(state *runState) error {
 		}
 	}
 
+	// Run the default child when neither a subcommand nor args were given;
+	// --help still describes the command itself.
+	if len(parsedArgs) <= state.commandDepth && inv.Command.DefaultChild != "" && !inv.builtinBool(builtinHelp) {
+		child := inv.Command.children()[inv.Command.DefaultChild]
+		inv.Command = child
+		inv.Flags.VisitAll(func(f *Flag) {
+			if f.Changed {
+				f.Deprecated = ""
+			}
+		})
+		return inv.run(state)
+	}
+
 	// At this point, we have the final command, so collect remaining args
 	// (non-flag arguments) for the handler
 	// Note: flags have already been parsed above, so parsedArgs contains
 	// the remaining positional arguments
 
+	if inv.Command.StdinArgs && !inv.Command.RawArgs && !inv.builtinBool(builtinHelp) {
+		var err error
+		parsedArgs, err = inv.appendStdinArgs(state, parsedArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Positional args as far as known, for middleware of rejected runs.
+	if !inv.Command.RawArgs && len(parsedArgs) >= state.commandDepth {
+		inv.Args = parsedArgs[state.commandDepth:]
+	}
+
 	ignoreFlagParseErrors := inv.Command.RawArgs
 
 	// Flag parse errors are irrelevant for raw args commands.
-	if !ignoreFlagParseErrors && state.flagParseErr != nil && !errors.Is(state.flagParseErr, pflag.ErrHelp) {
-		return fmt.Errorf(
-			"parsing flags (%v) for %q: %w",
+	if !ignoreFlagParseErrors && state.flagParseErr != nil && !errors.Is(state.flagParseErr, ErrHelp) {
+		return inv.usageError(fmt.Errorf(
+			inv.localize("parsing flags (%v) for %q: %w"),
 			state.allArgs,
 			inv.Command.FullName(), state.flagParseErr,
-		)
+		))
+	}
+
+	if inv.Command.OptionsFromArgs && !inv.Command.RawArgs {
+		rest, err := inv.bindOptionArgs(parsedArgs[state.commandDepth:])
+		if err != nil {
+			return inv.usageError(err)
+		}
+		parsedArgs = append(parsedArgs[:state.commandDepth:state.commandDepth], rest...)
 	}
 
 	// Check for help flag before validating required options
-	isHelpRequested := false
-	if inv.Flags != nil {
-		if help, err := inv.Flags.GetBool("help"); err == nil && help {
-			isHelpRequested = true
-		} else if h, err := inv.Flags.GetBool("h"); err == nil && h {
-			isHelpRequested = true
+	isHelpRequested := inv.builtinBool(builtinHelp)
+
+	if !isHelpRequested {
+		if err := inv.checkPathDeprecation(state); err != nil {
+			return err
+		}
+		if err := inv.checkRemovedOptions(); err != nil {
+			return inv.usageError(err)
+		}
+	}
+
+	// Populate env-only options, which have no flag to carry their value.
+	var envOnlySet map[string]bool
+	if !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) {
+		var err error
+		envOnlySet, err = inv.applyEnvOnlyOptions()
+		if err != nil {
+			return err
 		}
 	}
 
+	// --explain describes the resolved invocation instead of running it,
+	// before validation so that missing values are reported as unset.
+	if !isHelpRequested && inv.builtinBool(builtinExplain) {
+		if !inv.Command.RawArgs {
+			inv.Args = parsedArgs[state.commandDepth:]
+		}
+		_, err := io.WriteString(inv.Stdout, inv.Explain().String())
+		return err
+	}
+
 	// All options should be set. Check all required options have sources,
 	// meaning they were set by the user in some way (env, flag, etc).
 	// Don't validate required flags if help was requested or if there's a help error.
-	if !isHelpRequested && !errors.Is(state.flagParseErr, pflag.ErrHelp) {
-		var missing []string
-		for _, opt := range inv.Command.Options {
+	if !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) {
+		var (
+			missing     []string
+			missingOpts []Option
+		)
+		requiredOpts := slices.Concat(inv.Command.localOptions(), inv.Command.inheritedPersistentOptions())
+		seenRequired := make(map[string]bool)
+		for _, opt := range requiredOpts {
+			if opt.Flag != "" {
+				if seenRequired[opt.Flag] {
+					continue
+				}
+				seenRequired[opt.Flag] = true
+			}
 			if opt.Required {
-				// Required means the flag must have a value, not that the flag must be present.
-				// A flag has a value if:
-				// 1. User explicitly set it (flag.Changed)
+				// Required means the option must have a value, not that the
+				// flag must be present. It has one if:
+				// 1. It was set by flag or by one of its env vars (flag.Changed)
 				// 2. It has a default value (opt.Default != "")
-				// 3. It can be set via environment variable (opt.Envs)
+				// 3. For env-only options, one of its env vars was set
 				hasValue := false
 
 				if inv.Flags != nil && opt.Flag != "" {
 					if flag := inv.Flags.Lookup(opt.Flag); flag != nil {
-						// Flag was explicitly set by user
 						hasValue = flag.Changed
 					}
 				}
 
-				// If not set by user, check if there's a default value
 				if !hasValue && opt.Default != "" {
 					hasValue = true
 				}
 
-				// If still no value, check if environment variable is available
-				// (we can't check if env var is actually set here, but if it's configured,
-				// we assume it might be set)
-				if !hasValue && len(opt.Envs) > 0 {
-					hasValue = true
+				if !hasValue && opt.Flag == "" {
+					hasValue = envOnlySet[opt.name()]
 				}
 
 				if !hasValue {
-					name := opt.Flag
-					// use env as a fallback if flag is empty
-					if name == "" && len(opt.Envs) > 0 {
-						name = opt.Envs[0]
-					}
-					missing = append(missing, name)
+					missing = append(missing, fmt.Sprintf("%s (checked %s)", opt.name(), strings.Join(opt.sources(), ", ")))
+					missingOpts = append(missingOpts, opt)
 				}
 			}
 		}
+		if len(missing) > 0 && inv.promptsMissing() {
+			if err := inv.promptMissingOptions(missingOpts); err != nil {
+				return err
+			}
+			missing = nil
+		}
 		if len(missing) > 0 {
-			return fmt.Errorf("missing values for the required flags: %s", strings.Join(missing, ", "))
+			return inv.usageError(fmt.Errorf(inv.localize("missing values for the required flags: %s"), strings.Join(missing, ", ")))
+		}
+	}
+
+	// Check numeric bounds of options that have a value
+	if !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) {
+		if err := inv.checkOptionRanges(); err != nil {
+			return inv.usageError(err)
 		}
 	}
 
 	// Execute Action callbacks for options that were set
 	// Don't execute actions if help was requested
-	if !isHelpRequested && !errors.Is(state.flagParseErr, pflag.ErrHelp) && inv.Flags != nil {
+	if !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) && inv.Flags != nil {
 		// Use a map to track which flags we've already processed
 		// This prevents executing Action multiple times for the same flag
 		processedFlags := make(map[string]bool)
@@ -797,7 +1640,7 @@ func (inv *Invocation) run(state *runState) error {
 		// This ensures that if a flag is defined in multiple commands (e.g., overridden),
 		// the action of the most specific command (the current one) is executed.
 		for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
-			for _, opt := range cmd.Options {
+			for _, opt := range cmd.localOptions() {
 				if opt.Action != nil && opt.Flag != "" && !processedFlags[opt.Flag] {
 					if ff := inv.Flags.Lookup(opt.Flag); ff != nil && ff.Changed {
 						if err := opt.Action(ff.Value); err != nil {
@@ -828,8 +1671,8 @@ func (inv *Invocation) run(state *runState) error {
 		inv.Args = parsedArgs[state.commandDepth:]
 	}
 
-	if inv.Flags != nil {
-		if internalArgsFlag := inv.Flags.Lookup(internalArgsOverrideFlag); internalArgsFlag != nil && internalArgsFlag.Changed {
+	if name := inv.Command.builtinFlagName(builtinArgs); name != "" && inv.Flags != nil {
+		if internalArgsFlag := inv.Flags.Lookup(name); internalArgsFlag != nil && internalArgsFlag.Changed {
 			var overriddenArgs []string
 			switch v := internalArgsFlag.Value.(type) {
 			case *StringArray:
@@ -845,17 +1688,37 @@ func (inv *Invocation) run(state *runState) error {
 		}
 	}
 
+	// Unmatched subcommand tokens go to the FallbackHandler, which takes
+	// them as they are instead of the command's own Args.
+	useFallback := inv.Command.FallbackHandler != nil && len(inv.Command.Children) > 0 && len(inv.Args) > 0
+
+	if inv.Command.ArgsPolicy != nil && !useFallback && !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) {
+		if err := inv.Command.ArgsPolicy(inv); err != nil {
+			return inv.usageError(err)
+		}
+	}
+
 	// Parse args and set values to Arg.Value if Args are defined
 	// Skip args parsing and validation if help was requested
-	if len(inv.Command.Args) > 0 && !isHelpRequested && !errors.Is(state.flagParseErr, pflag.ErrHelp) {
-		if err := parseAndSetArgs(inv.Command.Args, inv.Args); err != nil {
-			return fmt.Errorf("parsing args: %w", err)
+	inv.BoundArgs = inv.Command.Args
+	if len(inv.Command.Args) > 0 && !useFallback && !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) {
+		inv.argSources = make(map[string]ArgSource)
+		if err := inv.warnDeprecatedArgKeys(); err != nil {
+			return err
 		}
-	} else {
+		if err := parseAndSetArgs(inv.Command.Args, inv.Args, inv.argSources, inv.Command.StrictArgs); err != nil {
+			return inv.usageError(fmt.Errorf("parsing args: %w", err))
+		}
+		if err := inv.runArgActions(); err != nil {
+			return err
+		}
+	} else if len(inv.Command.Args) == 0 && !inv.Command.DisableAutoArgs {
 		// If Command doesn't define Args, auto-create them from parsed args
-		// Name will be arg1, arg2, arg3, etc.
+		// Name will be arg1, arg2, arg3, etc. They belong to the invocation
+		// so that a shared command tree is left untouched.
 		if len(inv.Args) > 0 {
 			autoArgs := make(ArgSet, len(inv.Args))
+			inv.argSources = make(map[string]ArgSource, len(inv.Args))
 			for i, argStr := range inv.Args {
 				autoArgs[i] = Arg{
 					Name: fmt.Sprintf("arg%d", i+1),
@@ -867,32 +1730,27 @@ func (inv *Invocation) run(state *runState) error {
 				if err := strVal.Set(argStr); err != nil {
 					return fmt.Errorf("setting arg%d value: %w", i+1, err)
 				}
+				inv.argSources[autoArgs[i].Name] = ArgSourcePositional
 			}
-			inv.Command.Args = autoArgs
+			inv.BoundArgs = autoArgs
 		}
 	}
 
-	// Collect all middlewares from root to current command
-	// We collect from current (child) to root (parent), then reverse
-	// to get [root, parent, ..., child] order. Chain() will reverse again
-	// to ensure execution order is root -> parent -> ... -> child -> handler
-	var middlewareChain []MiddlewareFunc
-	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
-		if cmd.Middleware != nil {
-			middlewareChain = append(middlewareChain, cmd.Middleware)
+	// Enable feature toggles, then run cross-flag validation once
+	// everything is parsed
+	if !isHelpRequested && !errors.Is(state.flagParseErr, ErrHelp) {
+		inv.applyFeatureOptions()
+		if err := inv.validateOptions(); err != nil {
+			return inv.usageError(err)
 		}
 	}
-	// Reverse to get order from root (parent) to current (child)
-	// This ensures Chain() will execute them in the correct order: root -> parent -> child -> handler
-	for i, j := 0, len(middlewareChain)-1; i < j; i, j = i+1, j-1 {
-		middlewareChain[i], middlewareChain[j] = middlewareChain[j], middlewareChain[i]
-	}
 
-	var mw MiddlewareFunc
-	if len(middlewareChain) > 0 {
-		mw = Chain(middlewareChain...)
-	} else {
-		mw = Chain()
+	inv.mergeAnnotations()
+
+	mw := inv.middleware()
+	// Profiling wraps all middleware so that it covers the whole run.
+	if profile := inv.profileMiddleware(); profile != nil {
+		mw = Chain(profile, mw)
 	}
 
 	ctx := inv.ctx
@@ -905,22 +1763,56 @@ func (inv *Invocation) run(state *runState) error {
 	inv.ctx = ctx
 
 	// Check for help flag
-	if inv.Flags != nil {
-		if help, err := inv.Flags.GetBool("help"); err == nil && help {
-			return DefaultHelpFn()(ctx, inv)
-		}
+	if isHelpRequested {
+		return DefaultHelpFn()(ctx, inv)
 	}
 
 	handler, resolveErr := inv.Command.resolveConfiguredHandler()
 	if resolveErr != nil {
 		return &RunCommandError{Cmd: inv.Command, Err: resolveErr}
 	}
+	if useFallback {
+		handler = inv.Command.FallbackHandler
+	}
 
-	if handler == nil || errors.Is(state.flagParseErr, pflag.ErrHelp) {
-		return DefaultHelpFn()(ctx, inv)
+	if handler == nil || errors.Is(state.flagParseErr, ErrHelp) {
+		err := DefaultHelpFn()(ctx, inv)
+		if ClassifyError(err) == ErrorClassUsage {
+			return inv.rejectRun(err)
+		}
+		return err
+	}
+
+	if err := inv.checkCooldown(); err != nil {
+		return err
+	}
+
+	// Usage tracking is best-effort and never fails the command.
+	_ = inv.recordUsage()
+
+	restoreLimits, err := inv.applyLimits()
+	if err != nil {
+		return err
 	}
+	defer func() { _ = restoreLimits() }()
+
+	ctx = inv.setupLogger(ctx)
+	if ctx, err = inv.provide(ctx); err != nil {
+		if cleanupErr := inv.runCleanups(); cleanupErr != nil {
+			err = errors.Join(err, cleanupErr)
+		}
+		return err
+	}
+	ctx, stopSignals, receivedSignal := inv.notifyOnSignals(ctx)
+	defer stopSignals()
+	inv.ctx = ctx
 
-	err := mw(handler)(ctx, inv)
+	err = inv.reportRun(ctx, func() error {
+		err := inv.callWithCleanups(func() error {
+			return mw(inv.Command.withRunHooks(handler))(ctx, inv)
+		})
+		return withSignal(receivedSignal(), err)
+	})
 	if err != nil {
 		return &RunCommandError{
 			Cmd: inv.Command,
@@ -930,6 +1822,30 @@ func (inv *Invocation) run(state *runState) error {
 	return nil
 }
 
+// middleware returns the middleware of the invoked command, chained
+// from the root down, stopping at a command that skips its parents'
+// middleware.
+func (inv *Invocation) middleware() MiddlewareFunc {
+	// We collect from current (child) to root (parent), then reverse
+	// to get [root, parent, ..., child] order. Chain() will reverse again
+	// to ensure execution order is root -> parent -> ... -> child -> handler
+	var middlewareChain []MiddlewareFunc
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		if cmd.Middleware != nil {
+			middlewareChain = append(middlewareChain, cmd.Middleware)
+		}
+		if cmd.SkipParentMiddleware {
+			break
+		}
+	}
+	// Reverse to get order from root (parent) to current (child)
+	// This ensures Chain() will execute them in the correct order: root -> parent -> child -> handler
+	for i, j := 0, len(middlewareChain)-1; i < j; i, j = i+1, j-1 {
+		middlewareChain[i], middlewareChain[j] = middlewareChain[j], middlewareChain[i]
+	}
+	return Chain(middlewareChain...)
+}
+
 type RunCommandError struct {
 	Cmd *Command
 	Err error
@@ -945,7 +1861,7 @@ func (e *RunCommandError) Error() string {
 
 // findArg returns the index of the first occurrence of arg in args, skipping
 // over all flags.
-func findArg(want string, args []string, fs *pflag.FlagSet) (int, error) {
+func findArg(want string, args []string, fs *FlagSet) (int, error) {
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		if !strings.HasPrefix(arg, "-") {
@@ -984,10 +1900,60 @@ func findArg(want string, args []string, fs *pflag.FlagSet) (int, error) {
 
 // parseAndSetArgs parses args and sets values to Arg.Value
 // It handles different arg formats: positional, query string, form data, and JSON
-func parseAndSetArgs(argsDef ArgSet, args []string) error {
+// The source of each arg that received a value is recorded in sources.
+// In strict mode keyed tokens only bind to declared names and every
+// required arg must end up with a value.
+func parseAndSetArgs(argsDef ArgSet, args []string, sources map[string]ArgSource, strict bool) error {
+	if err := bindArgs(argsDef, args, sources, strict); err != nil {
+		return err
+	}
+	if !strict {
+		return nil
+	}
+	for i, argDef := range argsDef {
+		name := argDef.displayName(i)
+		if argDef.Required && argDef.Default == "" && sources[name] == ArgSourceNone {
+			return fmt.Errorf("required argument %q is missing", name)
+		}
+	}
+	return nil
+}
+
+// unknownArgKeys returns the keys of values not naming an arg of argsDef.
+func unknownArgKeys(argsDef ArgSet, values map[string][]string) []string {
+	var unknown []string
+	for key := range values {
+		if key == "" {
+			continue
+		}
+		if argByKey(argsDef, key) < 0 {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+func unknownArgKeysError(argsDef ArgSet, unknown []string) error {
+	var names []string
+	for i, a := range argsDef {
+		names = append(names, a.displayName(i))
+	}
+	return fmt.Errorf("unknown argument key(s) %s, expected one of: %s",
+		strings.Join(unknown, ", "), strings.Join(names, ", "))
+}
+
+// bindArgs implements parseAndSetArgs, binding args in order.
+func bindArgs(argsDef ArgSet, args []string, sources map[string]ArgSource, strict bool) error {
 	if len(args) == 0 {
 		// Check for required args and set defaults
 		for i, argDef := range argsDef {
+			if argDef.Variadic {
+				if err := argDef.setVariadic(i, nil, sources); err != nil {
+					return err
+				}
+				continue
+			}
 			if argDef.Required && argDef.Default == "" {
 				name := argDef.Name
 				if name == "" {
@@ -1004,6 +1970,7 @@ func parseAndSetArgs(argsDef ArgSet, args []string) error {
 					}
 					return fmt.Errorf("setting default value for %q: %w", name, err)
 				}
+				sources[argDef.displayName(i)] = ArgSourceDefault
 			}
 		}
 		return nil
@@ -1011,8 +1978,15 @@ func parseAndSetArgs(argsDef ArgSet, args []string) error {
 
 	argIndex := 0
 	for i, argDef := range argsDef {
+		if argDef.Variadic {
+			return argDef.setVariadic(i, args[argIndex:], sources)
+		}
 		if argIndex >= len(args) {
-			// No more args provided
+			// No more args provided; args set by name from an earlier
+			// query, form or JSON token keep their value.
+			if sources[argDef.displayName(i)] != ArgSourceNone {
+				continue
+			}
 			if argDef.Required && argDef.Default == "" {
 				name := argDef.Name
 				if name == "" {
@@ -1029,74 +2003,35 @@ func parseAndSetArgs(argsDef ArgSet, args []string) error {
 					}
 					return fmt.Errorf("setting default value for %q: %w", name, err)
 				}
+				sources[argDef.displayName(i)] = ArgSourceDefault
 			}
 			continue
 		}
 
 		argStr := args[argIndex]
-		trimmedArg := strings.TrimSpace(argStr)
 
 		// Check if it's a query string, form data, or JSON format
-		if strings.Contains(argStr, "=") && !strings.HasPrefix(argStr, "-") {
-			// Query string or form data format
-			var values map[string][]string
-			var err error
-
-			if strings.Contains(argStr, "&") || !strings.Contains(argStr, " ") {
-				// Query string format
-				values, err = ParseQueryArgs(argStr)
-			} else {
-				// Form data format
-				values, err = ParseFormArgs(argStr)
+		if values, source, ok := parseKeyedArg(argStr); ok {
+			if unknown := unknownArgKeys(argsDef, values); strict && len(unknown) > 0 {
+				return unknownArgKeysError(argsDef, unknown)
 			}
-
-			if err == nil && len(values) > 0 {
-				// Try to find matching arg by name
-				found := false
-				for key, valueList := range values {
-					if len(valueList) > 0 {
-						// Find arg by name
-						for j := range argsDef {
-							if argsDef[j].Name == key && argsDef[j].Value != nil {
-								if err := argsDef[j].Value.Set(valueList[0]); err != nil {
-									return fmt.Errorf("setting value for arg %q: %w", key, err)
-								}
-								found = true
-								break
-							}
+			// Try to find matching arg by name
+			found := false
+			for key, valueList := range values {
+				if len(valueList) > 0 && key != "" {
+					// Find arg by name or alias
+					if j := argByKey(argsDef, key); j >= 0 && argsDef[j].Value != nil {
+						if err := argsDef[j].Value.Set(valueList[0]); err != nil {
+							return fmt.Errorf("setting value for arg %q: %w", argsDef[j].Name, err)
 						}
+						sources[argsDef[j].Name] = source
+						found = true
 					}
 				}
-				if found {
-					argIndex++
-					continue
-				}
 			}
-		} else if (strings.HasPrefix(trimmedArg, "{") && strings.HasSuffix(trimmedArg, "}")) ||
-			(strings.HasPrefix(trimmedArg, "[") && strings.HasSuffix(trimmedArg, "]")) {
-			// JSON format
-			values, err := ParseJSONArgs(trimmedArg)
-			if err == nil && len(values) > 0 {
-				// Try to find matching arg by name
-				found := false
-				for key, valueList := range values {
-					if len(valueList) > 0 && key != "" {
-						// Find arg by name
-						for j := range argsDef {
-							if argsDef[j].Name == key && argsDef[j].Value != nil {
-								if err := argsDef[j].Value.Set(valueList[0]); err != nil {
-									return fmt.Errorf("setting value for arg %q: %w", key, err)
-								}
-								found = true
-								break
-							}
-						}
-					}
-				}
-				if found {
-					argIndex++
-					continue
-				}
+			if found || strict && len(values[""]) == 0 {
+				argIndex++
+				continue
 			}
 		}
 
@@ -1110,12 +2045,20 @@ func parseAndSetArgs(argsDef ArgSet, args []string) error {
 				return fmt.Errorf("setting value for arg %q: %w", name, err)
 			}
 		}
+		sources[argDef.displayName(i)] = ArgSourcePositional
 		argIndex++
 	}
 
 	return nil
 }
 
+// initMu serializes the initialization of command trees. Apart from it,
+// running an invocation only reads the Command tree: parsed flags, bound
+// args and other run state live on the Invocation, so a tree may be run
+// repeatedly and from concurrent goroutines. Values bound by Options and
+// Args are the application's and are shared by those invocations.
+var initMu sync.Mutex
+
 // Run executes the command.
 // If two command share a flag name, the first command wins.
 //
@@ -1124,7 +2067,41 @@ func (inv *Invocation) Run() (err error) {
 	defer inv.closeResponseStream()
 	inv.clearResponse()
 
-	restoreEnv, preloadErr := preloadEnvFromArgs(inv.Args)
+	initMu.Lock()
+	err = inv.Command.init()
+	initMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("initializing command: %w", err)
+	}
+
+	if hook := inv.Command.ArgvHook; hook != nil {
+		inv.Args, err = hook(slices.Clone(inv.Args))
+		if err != nil {
+			return fmt.Errorf("rewriting args: %w", err)
+		}
+	}
+
+	if inv.Command.ResponseFiles {
+		inv.Args, err = expandResponseFiles(inv.Args, inv.Dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	if inv.Command.SlashFlags && slashFlagsSupported {
+		inv.Args = inv.Command.translateSlashFlags(inv.Args)
+	}
+	inv.rewriteHelpCommand()
+
+	envNames := inv.Command.envFlagNames()
+	commands, err := getCommands(inv.Command, "")
+	if err != nil {
+		return err
+	}
+	if target, _ := resolveExecCommand(inv.Command, commands, inv.Args, inv.Arg0); target.DisableGlobalFlags {
+		envNames = envFlagNames{}
+	}
+	restoreEnv, preloadErr := preloadEnvFromArgs(inv.Args, envNames, inv.env())
 	if preloadErr != nil {
 		return fmt.Errorf("preloading environment variables: %w", preloadErr)
 	}
@@ -1134,15 +2111,6 @@ func (inv *Invocation) Run() (err error) {
 		}
 	}()
 
-	for _, child := range inv.Command.Children {
-		child.parent = inv.Command
-	}
-
-	err = inv.Command.init()
-	if err != nil {
-		return fmt.Errorf("initializing command: %w", err)
-	}
-
 	defer func() {
 		// Pflag is panicky, so additional context is helpful in tests.
 		if flag.Lookup("test.v") == nil {
@@ -1154,6 +2122,16 @@ func (inv *Invocation) Run() (err error) {
 		}
 	}()
 
+	defer func() {
+		if !inv.Command.root().CrashReports {
+			return
+		}
+		if r := recover(); r != nil {
+			inv.reportPanic(r)
+			panic(r)
+		}
+	}()
+
 	// We close Stdin to prevent deadlocks, e.g. when the command
 	// has ended but an io.Copy is still reading from Stdin.
 	defer func() {
@@ -1224,54 +2202,70 @@ func RequireRangeArgs(start, end int) MiddlewareFunc {
 	if start < 0 {
 		panic("start must be >= 0")
 	}
+	if end != -1 && start > end {
+		panic("start must be <= end")
+	}
 	return func(next HandlerFunc) HandlerFunc {
 		return func(ctx context.Context, i *Invocation) error {
-			got := len(i.Args)
-			switch {
-			case start == end && got != start:
-				switch start {
-				case 0:
-					if len(i.Command.Children) > 0 {
-						return fmt.Errorf("unrecognized subcommand %q", i.Args[0])
-					}
-					return fmt.Errorf("wanted no args but got %v %v", got, i.Args)
-				default:
-					return fmt.Errorf(
-						"wanted %v args but got %v %v",
-						start,
-						got,
-						i.Args,
-					)
-				}
-			case start > 0 && end == -1:
-				switch {
-				case got < start:
-					return fmt.Errorf(
-						"wanted at least %v args but got %v",
-						start,
-						got,
-					)
-				default:
-					return next(ctx, i)
-				}
-			case start > end:
-				panic("start must be <= end")
-			case got < start || got > end:
-				return fmt.Errorf(
-					"wanted between %v and %v args but got %v",
-					start, end,
-					got,
-				)
-			default:
-				return next(ctx, i)
+			if err := checkArgsRange(i, start, end); err != nil {
+				return err
 			}
+			return next(ctx, i)
 		}
 	}
 }
 
-// children returns a map of child command names to their respective commands.
+// checkArgsRange checks that the invocation has between start and end
+// positional args, end being -1 for no upper bound.
+func checkArgsRange(i *Invocation, start, end int) error {
+	got := len(i.Args)
+	switch {
+	case start == end && got != start:
+		switch start {
+		case 0:
+			if len(i.Command.Children) > 0 {
+				return fmt.Errorf("unrecognized subcommand %q%s", i.Args[0], suggestionText(i.Command.SuggestionsFor(i.Args[0])))
+			}
+			return fmt.Errorf("wanted no args but got %v %v", got, i.Args)
+		default:
+			return fmt.Errorf(
+				"wanted %v args but got %v %v",
+				start,
+				got,
+				i.Args,
+			)
+		}
+	case end == -1:
+		if got < start {
+			return fmt.Errorf(
+				"wanted at least %v args but got %v",
+				start,
+				got,
+			)
+		}
+		return nil
+	case got < start || got > end:
+		return fmt.Errorf(
+			"wanted between %v and %v args but got %v",
+			start, end,
+			got,
+		)
+	default:
+		return nil
+	}
+}
+
+// children maps the names and aliases of the direct children of c to them.
+// Names win over aliases of other children.
 func (c *Command) children() map[string]*Command {
 	childrenMap := make(map[string]*Command)
+	for _, child := range c.Children {
+		for _, alias := range child.Aliases {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				childrenMap[alias] = child
+			}
+		}
+	}
 	for _, child := range c.Children {
 		childrenMap[child.Name()] = child
 	}
@@ -1,6 +1,13 @@
 package redant
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestCommandInitIsIdempotentForGlobalFlags(t *testing.T) {
 	root := &Command{Use: "app"}
@@ -52,3 +59,165 @@ func TestCommandInitDoesNotOverrideExistingRootGlobalFlag(t *testing.T) {
 		t.Fatalf("expected env flag exactly once, got %d", envCount)
 	}
 }
+
+func TestCommandDisableDefaultGlobals(t *testing.T) {
+	root := &Command{Use: "app", DisableDefaultGlobals: true}
+
+	if err := root.init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	if len(root.Options) != 0 {
+		t.Fatalf("expected no global flags, got %d", len(root.Options))
+	}
+
+	err := root.Invoke("--list-commands").Run()
+	if err == nil || !strings.Contains(err.Error(), "unknown flag") {
+		t.Fatalf("expected unknown flag error when built-ins are disabled, got %v", err)
+	}
+}
+
+func TestCommandSetGlobalFlagsRenameKeepsBehavior(t *testing.T) {
+	const key = "REDANT_RENAMED_ENV_FLAG"
+	t.Setenv(key, "orig")
+
+	var got string
+	root := &Command{
+		Use: "app",
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			got = os.Getenv(key)
+			return nil
+		},
+	}
+	root.SetGlobalFlags(func(defaults OptionSet) OptionSet {
+		for i := range defaults {
+			switch defaults[i].Flag {
+			case "help":
+				defaults[i].Flag = "usage"
+				defaults[i].Shorthand = "?"
+			case "env":
+				defaults[i].Flag = "set-env"
+				defaults[i].Shorthand = "E"
+			}
+		}
+		return defaults
+	})
+
+	if err := root.Invoke("-E", key+"=renamed").Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got != "renamed" {
+		t.Fatalf("renamed env flag value = %q, want %q", got, "renamed")
+	}
+
+	var out bytes.Buffer
+	inv := root.Invoke("--usage")
+	inv.Stdout = &out
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "USAGE:") {
+		t.Fatalf("renamed help flag should render help, got %q", out.String())
+	}
+}
+
+func TestCommandGlobalFlagsDoNotCollideWithAppFlags(t *testing.T) {
+	var host, env string
+	var executed bool
+	root := &Command{
+		Use: "app",
+		Options: OptionSet{
+			{Flag: "host", Shorthand: "h", Value: StringOf(&host)},
+			{Flag: "env", Value: StringOf(&env)},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			executed = true
+			return nil
+		},
+	}
+
+	if err := root.Invoke("-h", "example.com", "--env", "prod").Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !executed {
+		t.Fatalf("handler was not executed")
+	}
+	if host != "example.com" || env != "prod" {
+		t.Fatalf("got host=%q env=%q, want example.com/prod", host, env)
+	}
+
+	for _, opt := range root.Options {
+		if opt.Flag == "help" && opt.Shorthand != "" {
+			t.Fatalf("built-in help should drop colliding shorthand, got %q", opt.Shorthand)
+		}
+	}
+}
+
+func TestRepeatedRunsKeepCommandTreeStable(t *testing.T) {
+	optionFlags := func(opts OptionSet) string {
+		var flags []string
+		for _, opt := range opts {
+			flags = append(flags, opt.Flag+"/"+strings.Join(opt.Envs, ","))
+		}
+		return strings.Join(flags, " ")
+	}
+
+	var runs int
+	root := &Command{
+		Use:     "app",
+		Bundles: []string{"repeat-test"},
+		Options: OptionSet{{Flag: "verbose", Description: "verbose output", Value: BoolOf(new(bool))}},
+		Children: []*Command{{
+			Use:     "run",
+			Handler: func(ctx context.Context, inv *Invocation) error { runs++; return nil },
+		}},
+	}
+	RegisterBundle("repeat-test", func() OptionSet {
+		return OptionSet{{Flag: "timeout", Value: DurationOf(new(time.Duration))}}
+	})
+	root.SetGlobalFlags(func(defaults OptionSet) OptionSet {
+		return append(defaults, Option{Envs: []string{"APP_TOKEN"}, Value: StringOf(new(string))})
+	})
+
+	if err := root.Invoke("run").Run(); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	first := optionFlags(root.Options)
+	for range 3 {
+		if err := root.Invoke("run", "--verbose").Run(); err != nil {
+			t.Fatalf("repeated run: %v", err)
+		}
+	}
+	if got := optionFlags(root.Options); got != first {
+		t.Fatalf("root options changed across runs:\nfirst: %s\nlater: %s", first, got)
+	}
+	if runs != 4 {
+		t.Fatalf("expected 4 runs, got %d", runs)
+	}
+
+	// Configuration changes between runs are picked up.
+	root.DisableDefaultGlobals = true
+	if err := root.Invoke("run").Run(); err != nil {
+		t.Fatalf("run without globals: %v", err)
+	}
+	if got := optionFlags(root.Options); strings.Contains(got, "help") {
+		t.Fatalf("expected built-ins to be dropped, got %s", got)
+	}
+	root.DisableDefaultGlobals = false
+	if err := root.Invoke("run").Run(); err != nil {
+		t.Fatalf("run with globals again: %v", err)
+	}
+	if got := optionFlags(root.Options); got != first {
+		t.Fatalf("expected built-ins to be restored:\nwant: %s\ngot:  %s", first, got)
+	}
+
+	// An initialized root attached under another command sheds its built-ins.
+	umbrella := &Command{Use: "suite", Children: []*Command{root}}
+	if err := umbrella.Invoke("app", "run").Run(); err != nil {
+		t.Fatalf("run under umbrella: %v", err)
+	}
+	for _, opt := range root.Options {
+		if opt.builtin != "" {
+			t.Fatalf("attached root still declares built-in %q", opt.Flag)
+		}
+	}
+}
@@ -3,10 +3,18 @@ package redant
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestCommandBasic(t *testing.T) {
@@ -269,6 +277,87 @@ func TestFlagInheritance(t *testing.T) {
 	}
 }
 
+func TestPersistentOptions(t *testing.T) {
+	newTree := func(namespace *string) *Command {
+		noop := func(ctx context.Context, inv *Invocation) error { return nil }
+		return &Command{
+			Use: "app",
+			Children: []*Command{
+				{
+					Use: "server",
+					PersistentOptions: OptionSet{
+						{Flag: "namespace", Required: true, Value: StringOf(namespace)},
+					},
+					Children: []*Command{
+						{Use: "start", Handler: noop},
+					},
+				},
+				{Use: "client", Handler: noop},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "descendant parses persistent flag", args: []string{"server", "start", "--namespace", "prod"}, want: "prod"},
+		{name: "colon path parses persistent flag", args: []string{"server:start", "--namespace=dev"}, want: "dev"},
+		{name: "descendant validates required persistent flag", args: []string{"server", "start"}, wantErr: "namespace"},
+		{name: "sibling subtree does not see persistent flag", args: []string{"client", "--namespace", "prod"}, wantErr: "unknown flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var namespace string
+			err := newTree(&namespace).Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want contains %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if namespace != tt.want {
+				t.Fatalf("namespace = %q, want %q", namespace, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistentOptionsAreNotGlobalFlags(t *testing.T) {
+	root := &Command{
+		Use: "app",
+		Children: []*Command{
+			{Use: "server", PersistentOptions: OptionSet{{Flag: "namespace", Value: StringOf(new(string))}}},
+		},
+	}
+	if err := root.init(); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	for _, opt := range root.Children[0].GetGlobalFlags() {
+		if opt.Flag == "namespace" {
+			t.Fatalf("persistent option must not be reported as a global flag")
+		}
+	}
+}
+
+func TestPersistentOptionsConflictWithOptions(t *testing.T) {
+	root := &Command{
+		Use:               "app",
+		Options:           OptionSet{{Flag: "namespace", Value: StringOf(new(string))}},
+		PersistentOptions: OptionSet{{Flag: "namespace", Value: StringOf(new(string))}},
+	}
+	err := root.init()
+	if err == nil || !strings.Contains(err.Error(), "both Options and PersistentOptions") {
+		t.Fatalf("err = %v, want duplicate declaration error", err)
+	}
+}
+
 func TestMiddleware(t *testing.T) {
 	var order []string
 
@@ -321,6 +410,56 @@ func TestMiddleware(t *testing.T) {
 	}
 }
 
+func TestSkipParentMiddleware(t *testing.T) {
+	var order []string
+	record := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) error {
+				order = append(order, name)
+				return next(ctx, inv)
+			}
+		}
+	}
+	handler := func(ctx context.Context, inv *Invocation) error { return nil }
+
+	root := &Command{
+		Use:        "app",
+		Middleware: record("root"),
+		Children: []*Command{
+			{Use: "serve", Middleware: record("serve"), Handler: handler},
+			{Use: "version", SkipParentMiddleware: true, Handler: handler},
+			{
+				Use:                  "completion",
+				SkipParentMiddleware: true,
+				Middleware:           record("completion"),
+				Children: []*Command{
+					{Use: "bash", Middleware: record("bash"), Handler: handler},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"serve"}, want: []string{"root", "serve"}},
+		{args: []string{"version"}},
+		{args: []string{"completion", "bash"}, want: []string{"completion", "bash"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			order = nil
+			if err := root.Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if !slices.Equal(order, tt.want) {
+				t.Fatalf("middleware order %q, want %q", order, tt.want)
+			}
+		})
+	}
+}
+
 func TestHelpFlag(t *testing.T) {
 	cmd := &Command{
 		Use:   "test",
@@ -351,6 +490,70 @@ func TestHelpFlag(t *testing.T) {
 	}
 }
 
+func TestOptionMinMax(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		wantErr string
+	}{
+		{name: "within range", args: []string{"--port", "8080"}},
+		{name: "below min", args: []string{"--port", "0"}, wantErr: "less than min 1"},
+		{name: "above max", args: []string{"--port", "70000"}, wantErr: "greater than max 65535"},
+		{name: "env value is checked", env: "99999", wantErr: "greater than max 65535"},
+		{name: "duration bound", args: []string{"--timeout", "2h"}, wantErr: "greater than max 1h"},
+		{name: "unset option without default is skipped", args: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REDANT_TEST_PORT", tt.env)
+			var port int64
+			var timeout time.Duration
+			cmd := &Command{
+				Use: "serve",
+				Options: OptionSet{
+					{Flag: "port", Envs: []string{"REDANT_TEST_PORT"}, Min: "1", Max: "65535", Value: Int64Of(&port)},
+					{Flag: "timeout", Max: "1h", Value: DurationOf(&timeout)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+
+			err := cmd.Invoke(tt.args...).Run()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want contains %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptionMinMaxInitValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr string
+	}{
+		{name: "non numeric value", opt: Option{Flag: "name", Min: "1", Value: StringOf(new(string))}, wantErr: "numeric"},
+		{name: "invalid bound", opt: Option{Flag: "n", Max: "ten", Value: Int64Of(new(int64))}, wantErr: "invalid bound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{Use: "app", Options: OptionSet{tt.opt}}
+			err := cmd.init()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want contains %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestRequiredFlag(t *testing.T) {
 	var required string
 
@@ -693,7 +896,8 @@ func TestDeprecatedFlag(t *testing.T) {
 
 	inv := cmd.Invoke("--old", "value")
 	inv.Stdout = &bytes.Buffer{}
-	inv.Stderr = &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	inv.Stderr = stderr
 
 	err := inv.Run()
 	if err != nil {
@@ -705,8 +909,9 @@ func TestDeprecatedFlag(t *testing.T) {
 		t.Errorf("deprecated flag value = %q, want %q", deprecated, "value")
 	}
 
-	// Note: pflag prints deprecation warnings to os.Stderr directly,
-	// not to the provided inv.Stderr. This is expected pflag behavior.
+	if want := "Flag --old has been deprecated, use --new instead\n"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr = %q, want the deprecation warning", stderr.String())
+	}
 }
 
 func TestBusyboxArgv0Dispatch(t *testing.T) {
@@ -795,6 +1000,88 @@ func TestBusyboxArgv0DoesNotOverrideExplicitArgs(t *testing.T) {
 	}
 }
 
+func TestBusyboxArgv0DisableGlobalFlags(t *testing.T) {
+	var gotArgs []string
+	root := &Command{Use: "app"}
+	root.Children = append(root.Children, &Command{
+		Use:                "exec",
+		Aliases:            []string{"x"},
+		DisableGlobalFlags: true,
+		PassthroughArgs:    true,
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			gotArgs = append([]string(nil), inv.Args...)
+			return nil
+		},
+	})
+
+	inv := root.Invoke("--env", "A=1", "ls")
+	inv.Stdout = &bytes.Buffer{}
+	inv.Stderr = &bytes.Buffer{}
+	inv = inv.WithArgv0("/usr/local/bin/x.exe")
+
+	if err := inv.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(gotArgs, " ") != "--env A=1 ls" {
+		t.Fatalf("unexpected args: %#v", gotArgs)
+	}
+}
+
+func TestInvocationBuilders(t *testing.T) {
+	var gotArgs []string
+	root := &Command{
+		Use: "app",
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			gotArgs = inv.Args
+			line, err := io.ReadAll(inv.Stdin)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "out:%s", line)
+			_, _ = fmt.Fprint(inv.Stderr, "err")
+			return nil
+		},
+	}
+
+	var stdout, stderr bytes.Buffer
+	inv := root.Invoke().
+		WithArgs("a", "b").
+		WithStdin(strings.NewReader("in")).
+		WithStdout(&stdout).
+		WithStderr(&stderr).
+		WithArgv0("app").
+		WithOS()
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Join(gotArgs, " ") != "a b" || stdout.String() != "out:in" || stderr.String() != "err" || inv.Arg0 != "app" {
+		t.Fatalf("args %q, stdout %q, stderr %q, arg0 %q", gotArgs, stdout.String(), stderr.String(), inv.Arg0)
+	}
+
+	// Fields not set by a builder still get the OS defaults.
+	inv = root.Invoke().WithStdout(&stdout).WithOS()
+	if inv.Stdin != os.Stdin || inv.Stderr != os.Stderr || inv.Stdout != &stdout {
+		t.Fatal("WithOS did not fill in the unset fields only")
+	}
+}
+
+func TestMultiCallNames(t *testing.T) {
+	root := &Command{
+		Use: "app",
+		Children: []*Command{
+			{Use: "serve", Aliases: []string{"svc", " "}},
+			{Use: "secret", Hidden: true},
+			{Use: "echo <text>", Aliases: []string{"svc"}},
+			{Use: "app"},
+		},
+	}
+
+	got := strings.Join(root.MultiCallNames(), ",")
+	if got != "serve,svc,echo" {
+		t.Fatalf("unexpected names: %s", got)
+	}
+}
+
 func TestInternalArgsFlagOverridesParsedArgs(t *testing.T) {
 	var gotFirst string
 	var gotSecond string
@@ -877,3 +1164,827 @@ func TestCommandInitHandlerValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "within limit", args: []string{"--replicas", "3", "--max-replicas", "5"}},
+		{name: "defaults", args: nil},
+		{name: "exceeds limit", args: []string{"--replicas", "6"}, wantErr: "--replicas (6) must not exceed --max-replicas (5)"},
+		{name: "help skips validation", args: []string{"--replicas", "6", "--help"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replicas, maxReplicas int64
+			var ran bool
+			cmd := &Command{
+				Use: "scale",
+				Options: OptionSet{
+					{
+						Flag:  "replicas",
+						Value: Int64Of(&replicas),
+						Validate: func(inv *Invocation, val Value) error {
+							limit, err := inv.Flags.GetInt64("max-replicas")
+							if err != nil {
+								return err
+							}
+							if replicas > limit {
+								return fmt.Errorf("--replicas (%s) must not exceed --max-replicas (%d)", val, limit)
+							}
+							return nil
+						},
+					},
+					{Flag: "max-replicas", Value: Int64Of(&maxReplicas), Default: "5"},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					ran = true
+					return nil
+				},
+			}
+
+			inv := cmd.Invoke(tt.args...)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if ran {
+					t.Fatal("handler must not run when validation fails")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOptionNoSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		noSplit bool
+		args    []string
+		want    []string
+	}{
+		{name: "csv split", args: []string{"--header", "a, b", "--header", "c"}, want: []string{"a", " b", "c"}},
+		{name: "literal", noSplit: true, args: []string{"--header", "a, b", "--header", "c"}, want: []string{"a, b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			cmd := &Command{
+				Use: "req",
+				Options: OptionSet{
+					{Flag: "header", Value: StringArrayOf(&headers), NoSplit: tt.noSplit},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+
+			inv := cmd.Invoke(tt.args...)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(headers, "|") != strings.Join(tt.want, "|") {
+				t.Fatalf("headers = %q, want %q", headers, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionNoSplitRequiresArray(t *testing.T) {
+	var s string
+	cmd := &Command{
+		Use:     "req",
+		Options: OptionSet{{Flag: "name", Value: StringOf(&s), NoSplit: true}},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	err := cmd.Invoke().Run()
+	if err == nil || !strings.Contains(err.Error(), "NoSplit requires an array value") {
+		t.Fatalf("expected NoSplit init error, got %v", err)
+	}
+}
+
+func TestNormalizeFlagName(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantPort string
+		wantErr  bool
+	}{
+		{name: "lower", args: []string{"serve", "--port", "1"}, wantPort: "1"},
+		{name: "title", args: []string{"serve", "--Port", "2"}, wantPort: "2"},
+		{name: "upper", args: []string{"serve", "--PORT=3"}, wantPort: "3"},
+		{name: "inherited parent flag", args: []string{"serve", "--Verbose", "--port", "4"}, wantPort: "4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var port string
+			var verbose bool
+			root := &Command{
+				Use:               "app",
+				NormalizeFlagName: FoldFlagCase,
+				Options:           OptionSet{{Flag: "verbose", Value: BoolOf(&verbose)}},
+			}
+			root.Children = append(root.Children, &Command{
+				Use:     "serve",
+				Options: OptionSet{{Flag: "port", Value: StringOf(&port)}},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			})
+
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if port != tt.wantPort {
+				t.Fatalf("port = %q, want %q", port, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestNormalizeFlagNameCollision(t *testing.T) {
+	var a, b string
+	cmd := &Command{
+		Use:               "app",
+		NormalizeFlagName: FoldFlagCase,
+		Options: OptionSet{
+			{Flag: "name", Value: StringOf(&a)},
+			{Flag: "Name", Value: StringOf(&b)},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	err := cmd.Invoke().Run()
+	if err == nil || !strings.Contains(err.Error(), "collide after name normalization") {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+}
+
+func TestOptionEnvSemantics(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		separator string
+		wantTags  []string
+		wantDebug bool
+		debugEnv  string
+	}{
+		{name: "comma list", env: "a, b,c", wantTags: []string{"a", "b", "c"}},
+		{name: "custom separator", env: "a:b", separator: ":", wantTags: []string{"a", "b"}},
+		{name: "space separator", env: "a  b", separator: " ", wantTags: []string{"a", "b"}},
+		{name: "yes bool", debugEnv: "yes", wantDebug: true},
+		{name: "on bool", debugEnv: "ON", wantDebug: true},
+		{name: "off bool", debugEnv: "off", wantDebug: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tags []string
+			var debug bool
+			cmd := &Command{
+				Use: "app",
+				Options: OptionSet{
+					{Flag: "tags", Envs: []string{"APP_TAGS"}, EnvSeparator: tt.separator, Value: StringArrayOf(&tags), Default: "x"},
+					{Flag: "debug", Envs: []string{"APP_DEBUG"}, Value: BoolOf(&debug)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+
+			inv := cmd.Invoke().WithEnviron([]string{"APP_TAGS=" + tt.env, "APP_DEBUG=" + tt.debugEnv})
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantTags != nil && strings.Join(tags, "|") != strings.Join(tt.wantTags, "|") {
+				t.Fatalf("tags = %q, want %q", tags, tt.wantTags)
+			}
+			if debug != tt.wantDebug {
+				t.Fatalf("debug = %v, want %v", debug, tt.wantDebug)
+			}
+		})
+	}
+}
+
+func TestEnvOnlyOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		environ   []string
+		wantToken string
+		wantLevel int64
+		wantErr   string
+	}{
+		{name: "from env", environ: []string{"APP_TOKEN=abc", "APP_LEVEL=3"}, wantToken: "abc", wantLevel: 3},
+		{name: "default", environ: []string{"APP_TOKEN=abc"}, wantToken: "abc", wantLevel: 1},
+		{name: "required missing", environ: nil, wantErr: "APP_TOKEN"},
+		{name: "invalid value", environ: []string{"APP_TOKEN=abc", "APP_LEVEL=x"}, wantErr: "parsing $APP_LEVEL"},
+		{name: "out of range", environ: []string{"APP_TOKEN=abc", "APP_LEVEL=9"}, wantErr: "max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			var level int64
+			root := &Command{
+				Use: "app",
+				Options: OptionSet{
+					{Envs: []string{"APP_LEVEL"}, Value: Int64Of(&level), Default: "1", Max: "5"},
+				},
+			}
+			root.Children = append(root.Children, &Command{
+				Use: "run",
+				Options: OptionSet{
+					{Envs: []string{"APP_TOKEN"}, Value: StringOf(&token), Required: true},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			})
+
+			inv := root.Invoke("run").WithEnviron(tt.environ)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token != tt.wantToken || level != tt.wantLevel {
+				t.Fatalf("token=%q level=%d, want %q %d", token, level, tt.wantToken, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestOptionExpandEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		expand    bool
+		args      []string
+		environ   []string
+		wantPath  string
+		wantPaths []string
+	}{
+		{name: "disabled", args: []string{"--path", "$DATA/x"}, wantPath: "$DATA/x"},
+		{name: "env var", expand: true, args: []string{"--path", "$DATA/x"}, wantPath: "/srv/data/x"},
+		{name: "braces", expand: true, args: []string{"--path", "${DATA}/y"}, wantPath: "/srv/data/y"},
+		{name: "tilde", expand: true, args: []string{"--path", "~/data"}, wantPath: "/home/u/data"},
+		{name: "default", expand: true, wantPath: "/home/u/default"},
+		{name: "array", expand: true, args: []string{"--paths", "$DATA/a,~/b"}, wantPath: "/home/u/default", wantPaths: []string{"/srv/data/a", "/home/u/b"}},
+		{name: "repeated array", expand: true, args: []string{"--paths", "~/a", "--paths", "~/b"}, wantPath: "/home/u/default", wantPaths: []string{"/home/u/a", "/home/u/b"}},
+		{name: "env", expand: true, environ: []string{"APP_PATHS=~/a,$DATA/b"}, wantPath: "/home/u/default", wantPaths: []string{"/home/u/a", "/srv/data/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			var paths []string
+			cmd := &Command{
+				Use: "app",
+				Options: OptionSet{
+					{Flag: "path", Value: StringOf(&path), ExpandEnv: tt.expand, Default: defaultIf(tt.expand, "~/default")},
+					{Flag: "paths", Envs: []string{"APP_PATHS"}, Value: StringArrayOf(&paths), ExpandEnv: tt.expand},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					// Expansion keeps the declared value types.
+					if _, ok := inv.Flags.Lookup("path").Value.(*String); !ok {
+						return fmt.Errorf("path value is %T", inv.Flags.Lookup("path").Value)
+					}
+					if _, ok := inv.Flags.Lookup("paths").Value.(*StringArray); !ok {
+						return fmt.Errorf("paths value is %T", inv.Flags.Lookup("paths").Value)
+					}
+					return nil
+				},
+			}
+			inv := cmd.Invoke(tt.args...).WithEnviron(append([]string{"HOME=/home/u", "DATA=/srv/data"}, tt.environ...))
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if path != tt.wantPath {
+				t.Fatalf("path = %q, want %q", path, tt.wantPath)
+			}
+			if strings.Join(paths, "|") != strings.Join(tt.wantPaths, "|") {
+				t.Fatalf("paths = %q, want %q", paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func defaultIf(ok bool, v string) string {
+	if ok {
+		return v
+	}
+	return ""
+}
+
+func TestRequiredFlagChecksEnvPresence(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		environ []string
+		wantErr string
+	}{
+		{name: "env unset", wantErr: "missing values for the required flags: token (checked --token, $APP_TOKEN, $TOKEN)"},
+		{name: "empty env", environ: []string{"APP_TOKEN="}, wantErr: "token (checked"},
+		{name: "second env set", environ: []string{"TOKEN=abc"}},
+		{name: "flag set", args: []string{"--token", "abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			cmd := &Command{
+				Use: "app",
+				Options: OptionSet{
+					{Flag: "token", Envs: []string{"APP_TOKEN", "TOKEN"}, Value: StringOf(&token), Required: true},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			inv := cmd.Invoke(tt.args...).WithEnviron(tt.environ)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCommandAliasesDispatch(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "name", args: []string{"repo", "commit"}, want: "commit"},
+		{name: "child alias", args: []string{"repo", "ci"}, want: "commit"},
+		{name: "parent alias", args: []string{"r", "ci", "x"}, want: "commit x"},
+		{name: "colon alias", args: []string{"r:ci"}, want: "commit"},
+		{name: "flag before child alias", args: []string{"repo", "--all", "ci"}, want: "commit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var all bool
+			commit := &Command{
+				Use:     "commit",
+				Aliases: []string{"ci"},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					got = strings.TrimSpace(inv.Command.Name() + " " + strings.Join(inv.Args, " "))
+					return nil
+				},
+			}
+			repo := &Command{
+				Use:      "repo",
+				Aliases:  []string{"r"},
+				Options:  OptionSet{{Flag: "all", Value: BoolOf(&all)}},
+				Children: []*Command{commit},
+			}
+			root := &Command{Use: "app", Children: []*Command{repo}}
+			if err := root.Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentRuns(t *testing.T) {
+	var ran atomic.Int64
+	root := &Command{
+		Use: "app",
+		Children: []*Command{{
+			Use: "repo",
+			Children: []*Command{{
+				Use: "commit",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					ran.Add(1)
+					if got := inv.ArgString("arg1"); got != inv.Annotations["want"] {
+						return fmt.Errorf("got arg %q, want %q", got, inv.Annotations["want"])
+					}
+					return nil
+				},
+			}},
+		}},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			want := strconv.Itoa(i)
+			args := []string{"repo", "commit", want}
+			if i%2 == 0 {
+				args = []string{"repo", "commit", "--help"}
+			}
+			inv := root.Invoke(args...)
+			inv.Annotations = map[string]any{"want": want}
+			errs <- inv.Run()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ran.Load() != 20 {
+		t.Fatalf("expected 20 handler runs, got %d", ran.Load())
+	}
+	if n := len(root.Options); n != len(GlobalFlags()) {
+		t.Fatalf("expected %d root options after repeated runs, got %d", len(GlobalFlags()), n)
+	}
+}
+
+func TestDuplicateCommandNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    *Command
+		wantErr string
+	}{
+		{
+			name:    "sibling names",
+			root:    &Command{Use: "app", Children: []*Command{{Use: "get"}, {Use: "get"}}},
+			wantErr: `duplicate command name "get": name of Children[0] "app get" and name of Children[1] "app get"`,
+		},
+		{
+			name:    "alias of a sibling",
+			root:    &Command{Use: "app", Children: []*Command{{Use: "list", Aliases: []string{"ls"}}, {Use: "ls"}}},
+			wantErr: `duplicate command name "ls": alias of Children[0] "app list" and name of Children[1] "app ls"`,
+		},
+		{
+			name: "nested alias",
+			root: &Command{Use: "app", Children: []*Command{{Use: "repo", Children: []*Command{
+				{Use: "commit", Aliases: []string{"c"}},
+				{Use: "clone", Aliases: []string{"c"}},
+			}}}},
+			wantErr: `command repo: duplicate command name "c": alias of Children[0] "app repo commit" and alias of Children[1] "app repo clone"`,
+		},
+		{
+			name:    "child named like the root",
+			root:    &Command{Use: "app", Children: []*Command{{Use: "app"}}},
+			wantErr: `duplicate command name "app": used by "app" and "app app"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.root.Invoke().Run()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestColonPaths(t *testing.T) {
+	newRoot := func(got *string) *Command {
+		handler := func(ctx context.Context, inv *Invocation) error {
+			*got = strings.TrimSpace(inv.Command.FullName() + " " + strings.Join(inv.Args, " "))
+			return nil
+		}
+		var verbose bool
+		return &Command{
+			Use:     "app",
+			Options: OptionSet{{Flag: "verbose", Value: BoolOf(&verbose)}},
+			Children: []*Command{{
+				Use:     "repo",
+				Aliases: []string{"r"},
+				Children: []*Command{
+					{Use: "remote", Children: []*Command{{Use: "add", Aliases: []string{"a"}, Handler: handler}}},
+					{Use: "secret", Hidden: true, Handler: handler},
+				},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "full colon path", args: []string{"repo:remote:add", "x"}, want: "app repo remote add x"},
+		{name: "colon path with aliases", args: []string{"r:remote:a"}, want: "app repo remote add"},
+		{name: "colon path then name", args: []string{"repo:remote", "add", "x"}, want: "app repo remote add x"},
+		{name: "name then colon path", args: []string{"repo", "remote:add"}, want: "app repo remote add"},
+		{name: "flag before colon path", args: []string{"--verbose", "r:remote:add", "x"}, want: "app repo remote add x"},
+		{name: "unknown segment is an arg", args: []string{"repo:remote:add", "host:port"}, want: "app repo remote add host:port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := newRoot(&got).Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		var got string
+		root := newRoot(&got)
+		tests := []struct {
+			words []string
+			want  string
+		}{
+			{words: []string{"repo:"}, want: "repo:remote,repo:remote:add"},
+			{words: []string{"repo:remote:"}, want: "repo:remote:add"},
+			{words: []string{"repo", "remote:"}, want: "remote:add"},
+		}
+		for _, tt := range tests {
+			if out := strings.Join(root.CompleteArgs(context.Background(), tt.words), ","); out != tt.want {
+				t.Fatalf("complete %q: got %q, want %q", tt.words, out, tt.want)
+			}
+		}
+	})
+
+	t.Run("colon path", func(t *testing.T) {
+		var got string
+		add := newRoot(&got).lookupColonPath("r:remote:a")
+		if add == nil || add.ColonPath() != "repo:remote:add" {
+			t.Fatalf("unexpected command %v", add)
+		}
+	})
+}
+
+func TestHelpListsSubcommandAliases(t *testing.T) {
+	root := &Command{Use: "app"}
+	root.Children = append(root.Children,
+		&Command{Use: "deploy", Aliases: []string{"dep", "d"}, Short: "Deploy the app."},
+		&Command{Use: "status", Short: "Show status."},
+	)
+	var stdout bytes.Buffer
+	inv := root.Invoke("--help")
+	inv.Stdout = &stdout
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	for _, want := range []string{"deploy, dep, d    Deploy the app.", "status            Show status."} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("help missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestDefaultChild(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantRan  string
+		wantPort int64
+		wantHelp bool
+	}{
+		{name: "no args", wantRan: "serve"},
+		{name: "child flag", args: []string{"--port", "90"}, wantRan: "serve", wantPort: 90},
+		{name: "explicit child", args: []string{"status"}, wantRan: "status"},
+		{name: "help", args: []string{"--help"}, wantHelp: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			var port int64
+			root := &Command{Use: "app", DefaultChild: "serve"}
+			root.Children = append(root.Children,
+				&Command{
+					Use:     "serve",
+					Short:   "Start the daemon.",
+					Options: OptionSet{{Flag: "port", Value: Int64Of(&port)}},
+					Handler: func(ctx context.Context, inv *Invocation) error {
+						ran = inv.Command.Name()
+						if len(inv.Args) != 0 {
+							t.Errorf("unexpected args %q", inv.Args)
+						}
+						return nil
+					},
+				},
+				&Command{
+					Use: "status",
+					Handler: func(ctx context.Context, inv *Invocation) error {
+						ran = inv.Command.Name()
+						return nil
+					},
+				},
+			)
+
+			var stdout bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &stdout
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if ran != tt.wantRan || port != tt.wantPort {
+				t.Fatalf("ran %q with port %d", ran, port)
+			}
+			if tt.wantHelp && !strings.Contains(stdout.String(), "Start the daemon. (default)") {
+				t.Fatalf("help does not mark the default child:\n%s", stdout.String())
+			}
+		})
+	}
+}
+
+func TestDefaultChildMustExist(t *testing.T) {
+	root := &Command{Use: "app", DefaultChild: "serve"}
+	root.Children = append(root.Children, &Command{Use: "status"})
+	err := root.Invoke().Run()
+	if err == nil || !strings.Contains(err.Error(), `default child "serve" is not a subcommand of "app"`) {
+		t.Fatalf("expected default child error, got %v", err)
+	}
+}
+
+func TestFallbackHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantRan  string
+		wantArgs []string
+	}{
+		{name: "child", args: []string{"get", "pods"}, wantRan: "pods"},
+		{name: "unmatched", args: []string{"get", "mypod", "-o", "yaml"}, wantRan: "fallback", wantArgs: []string{"mypod"}},
+		{name: "no args", args: []string{"get"}, wantRan: "get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			var gotArgs []string
+			var output string
+			var middleware int
+			get := &Command{
+				Use:        "get",
+				Options:    OptionSet{{Flag: "output", Shorthand: "o", Value: StringOf(&output)}},
+				Args:       ArgSet{{Name: "kind", Required: true}},
+				ArgsPolicy: NoArgs,
+				Middleware: func(next HandlerFunc) HandlerFunc {
+					return func(ctx context.Context, inv *Invocation) error {
+						middleware++
+						return next(ctx, inv)
+					}
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					ran = "get"
+					return nil
+				},
+				FallbackHandler: func(ctx context.Context, inv *Invocation) error {
+					ran = "fallback"
+					gotArgs = inv.Args
+					return nil
+				},
+				Children: []*Command{{
+					Use: "pods",
+					Handler: func(ctx context.Context, inv *Invocation) error {
+						ran = "pods"
+						return nil
+					},
+				}},
+			}
+			if tt.name == "no args" {
+				get.Args = nil
+			}
+			root := &Command{Use: "app", Children: []*Command{get}}
+			if err := root.Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if ran != tt.wantRan || strings.Join(gotArgs, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Fatalf("ran %q with args %q", ran, gotArgs)
+			}
+			if tt.wantRan != "pods" && middleware != 1 {
+				t.Fatalf("middleware ran %d times", middleware)
+			}
+		})
+	}
+}
+
+func TestDisableGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		passthrough bool
+		args        []string
+		wantArgs    string
+		wantVerbose bool
+	}{
+		{name: "raw args", args: []string{"exec", "--help", "-e", "X=1", "--list-commands"}, wantArgs: "--help -e X=1 --list-commands"},
+		{name: "passthrough", passthrough: true, args: []string{"exec", "--verbose", "ls", "--help"}, wantArgs: "ls --help", wantVerbose: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verbose bool
+			var gotArgs []string
+			root := &Command{
+				Use:     "app",
+				Options: OptionSet{{Flag: "verbose", Value: BoolOf(&verbose)}},
+			}
+			root.Children = append(root.Children, &Command{
+				Use:                "exec",
+				RawArgs:            !tt.passthrough,
+				PassthroughArgs:    tt.passthrough,
+				DisableGlobalFlags: true,
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					gotArgs = inv.Args
+					if _, ok := inv.LookupEnv("X"); ok {
+						t.Error("-e was applied as the global env flag")
+					}
+					return nil
+				},
+			})
+
+			var stdout bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &stdout
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if strings.Join(gotArgs, " ") != tt.wantArgs || verbose != tt.wantVerbose {
+				t.Fatalf("args = %q verbose = %v, stdout: %s", gotArgs, verbose, stdout.String())
+			}
+		})
+	}
+}
+
+func TestArgvHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantArgs []string
+		wantName string
+		wantErr  string
+	}{
+		{name: "unchanged", args: []string{"greet", "--name", "bob"}, wantName: "bob"},
+		{name: "alias", args: []string{"hi", "x"}, wantArgs: []string{"x"}, wantName: "world"},
+		{name: "legacy flag", args: []string{"greet", "-name=amy"}, wantName: "amy"},
+		{name: "rejected", args: []string{"greet", "-old"}, wantErr: "rewriting args: -old is no longer supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				name    string
+				gotArgs []string
+			)
+			root := &Command{
+				Use: "app",
+				ArgvHook: func(args []string) ([]string, error) {
+					for i, arg := range args {
+						switch {
+						case arg == "hi":
+							args[i] = "greet"
+						case arg == "-old":
+							return nil, errors.New("-old is no longer supported")
+						case strings.HasPrefix(arg, "-name="):
+							args[i] = "-" + arg
+						}
+					}
+					return args, nil
+				},
+				Children: []*Command{{
+					Use:     "greet",
+					Options: OptionSet{{Flag: "name", Default: "world", Value: StringOf(&name)}},
+					Handler: func(ctx context.Context, inv *Invocation) error {
+						gotArgs = inv.Args
+						return nil
+					},
+				}},
+			}
+			err := root.Invoke(tt.args...).Run()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if name != tt.wantName || !slices.Equal(gotArgs, tt.wantArgs) {
+				t.Fatalf("got name %q args %q, want %q %q", name, gotArgs, tt.wantName, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package redant
+
+import (
+	"context"
+	"strings"
+)
+
+// CompleteArgs returns completion candidates for the last of words, the
+// command line typed after the root command name ("deploy", "pr"). It
+// resolves the subcommand, finds the positional arg being completed and
+// returns the suggestions of its CompletionHandler, or its enum choices,
+// starting with the last word. A last word containing ":" completes to the
+// colon paths of subcommands instead, e.g. "repo:" to "repo:commit".
+// Shell completion scripts call it through the completion command.
+func (c *Command) CompleteArgs(ctx context.Context, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	typed, cur := words[:len(words)-1], words[len(words)-1]
+	if strings.HasPrefix(cur, "-") {
+		return nil
+	}
+
+	cmd := c
+	var positionals []string
+	for i := 0; i < len(typed); i++ {
+		word := typed[i]
+		if word == "--" {
+			positionals = append(positionals, typed[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(word, "-") && len(word) > 1 {
+			if !strings.Contains(word, "=") && flagTakesValue(cmd, word) {
+				i++
+			}
+			continue
+		}
+		if len(positionals) == 0 {
+			if child := cmd.lookupColonPath(word); child != nil && !child.Hidden {
+				cmd = child
+				continue
+			}
+		}
+		positionals = append(positionals, word)
+	}
+
+	if len(positionals) == 0 && strings.Contains(cur, ":") {
+		return cmd.completeColonPath(cur)
+	}
+
+	arg, ok := cmd.argAt(len(positionals))
+	if !ok {
+		return nil
+	}
+	var candidates []string
+	if arg.CompletionHandler != nil {
+		candidates = arg.CompletionHandler(cmd.Invoke(append(positionals, cur)...).WithContext(ctx))
+	} else {
+		// Enum args complete to their choices.
+		candidates = enumChoices(arg.Value)
+	}
+
+	var out []string
+	for _, s := range candidates {
+		if strings.HasPrefix(s, cur) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// argAt returns the arg receiving the positional at index i, where a
+// variadic last arg receives all remaining positionals.
+func (c *Command) argAt(i int) (Arg, bool) {
+	if n := len(c.Args); n > 0 && i >= n-1 && c.Args[n-1].Variadic {
+		return c.Args[n-1], true
+	}
+	if i < len(c.Args) {
+		return c.Args[i], true
+	}
+	return Arg{}, false
+}
+
+// flagTakesValue reports whether the flag word ("--name" or "-n") of cmd
+// consumes the next word as its value.
+func flagTakesValue(cmd *Command, word string) bool {
+	name := strings.TrimLeft(word, "-")
+	long := strings.HasPrefix(word, "--")
+	for _, opt := range cmd.FullOptions() {
+		if long && opt.Flag != name || !long && opt.Shorthand != name {
+			continue
+		}
+		if opt.Value == nil {
+			return false
+		}
+		no, ok := opt.Value.(NoOptDefValuer)
+		return !ok || no.NoOptDefValue() == ""
+	}
+	return false
+}
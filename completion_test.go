@@ -0,0 +1,54 @@
+package redant
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCommandCompleteArgs(t *testing.T) {
+	var gotArgs []string
+	deploy := &Command{
+		Use: "deploy",
+		Options: OptionSet{
+			{Flag: "region", Value: StringOf(new(string))},
+			{Flag: "force", Value: BoolOf(new(bool))},
+		},
+		Args: ArgSet{
+			{Name: "env", CompletionHandler: func(inv *Invocation) []string {
+				gotArgs = inv.Args
+				return []string{"dev", "prod", "staging"}
+			}},
+			{Name: "services", Variadic: true, CompletionHandler: func(inv *Invocation) []string {
+				_, cur := inv.CurWords()
+				return []string{"api", "web", cur + "-worker"}
+			}},
+		},
+	}
+	root := &Command{Use: "app", Children: []*Command{deploy}}
+
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{name: "first arg", words: []string{"deploy", ""}, want: []string{"dev", "prod", "staging"}},
+		{name: "prefix", words: []string{"deploy", "--force", "--region", "eu", "p"}, want: []string{"prod"}},
+		{name: "variadic", words: []string{"deploy", "dev", "api", "w"}, want: []string{"web", "w-worker"}},
+		{name: "flag word", words: []string{"deploy", "--re"}},
+		{name: "unknown command", words: []string{"nope", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := root.CompleteArgs(context.Background(), tt.words)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("CompleteArgs(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+
+	root.CompleteArgs(context.Background(), []string{"deploy", "--region", "eu", "st"})
+	if !slices.Equal(gotArgs, []string{"st"}) {
+		t.Fatalf("handler inv.Args = %q, want [st]", gotArgs)
+	}
+}
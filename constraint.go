@@ -0,0 +1,70 @@
+package redant
+
+import "strings"
+
+// ConstraintKind identifies a kind of option constraint.
+type ConstraintKind string
+
+const (
+	// ConstraintRequired means the option must be set by flag, env or default.
+	ConstraintRequired ConstraintKind = "required"
+	// ConstraintMin is the inclusive lower bound of a numeric option.
+	ConstraintMin ConstraintKind = "min"
+	// ConstraintMax is the inclusive upper bound of a numeric option.
+	ConstraintMax ConstraintKind = "max"
+	// ConstraintEnum restricts the option to a fixed set of choices.
+	ConstraintEnum ConstraintKind = "enum"
+)
+
+// Constraint is a single enforced rule on an option. It is the one model
+// rendered by help, --list-flags, the manifest and generated docs, the WebUI
+// metadata and the MCP JSON schema, so documentation is derived from the
+// same data used for validation.
+type Constraint struct {
+	Kind ConstraintKind `json:"kind" yaml:"kind"`
+	// Value holds the bound for min/max constraints.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Values holds the choices for enum constraints.
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// String returns the human readable form used in help output.
+func (c Constraint) String() string {
+	switch c.Kind {
+	case ConstraintMin, ConstraintMax:
+		return string(c.Kind) + ": " + c.Value
+	case ConstraintEnum:
+		return "one of: " + strings.Join(c.Values, ", ")
+	default:
+		return string(c.Kind)
+	}
+}
+
+// Constraints returns the constraints enforced on the option, in a stable order.
+func (o Option) Constraints() []Constraint {
+	var cs []Constraint
+	if o.Required {
+		cs = append(cs, Constraint{Kind: ConstraintRequired})
+	}
+	if o.Min != "" {
+		cs = append(cs, Constraint{Kind: ConstraintMin, Value: o.Min})
+	}
+	if o.Max != "" {
+		cs = append(cs, Constraint{Kind: ConstraintMax, Value: o.Max})
+	}
+	if choices := enumChoices(o.Value); len(choices) > 0 {
+		cs = append(cs, Constraint{Kind: ConstraintEnum, Values: choices})
+	}
+	return cs
+}
+
+func enumChoices(v any) []string {
+	switch v := v.(type) {
+	case *Enum:
+		return append([]string(nil), v.AllowedChoices()...)
+	case *EnumArray:
+		return append([]string(nil), v.AllowedChoices()...)
+	default:
+		return nil
+	}
+}
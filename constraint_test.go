@@ -0,0 +1,36 @@
+package redant
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionConstraints(t *testing.T) {
+	var (
+		n    int64
+		mode string
+		s    string
+	)
+	tests := []struct {
+		name string
+		opt  Option
+		want []string
+	}{
+		{name: "none", opt: Option{Flag: "s", Value: StringOf(&s)}, want: nil},
+		{name: "required", opt: Option{Flag: "s", Value: StringOf(&s), Required: true}, want: []string{"required"}},
+		{name: "range", opt: Option{Flag: "n", Value: Int64Of(&n), Min: "1", Max: "9"}, want: []string{"min: 1", "max: 9"}},
+		{name: "enum", opt: Option{Flag: "mode", Value: EnumOf(&mode, "a", "b")}, want: []string{"one of: a, b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range tt.opt.Constraints() {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Constraints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package redant
+
+import (
+	"fmt"
+	"time"
+)
+
+const cooldownsFileName = "cooldowns.json"
+
+// CooldownError is returned when a command runs again before its Cooldown
+// has elapsed.
+type CooldownError struct {
+	Command string
+	LastRun time.Duration
+	RetryIn time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("%s: last run %s ago, retry in %s",
+		e.Command, e.LastRun.Truncate(time.Second), e.RetryIn.Round(time.Second))
+}
+
+// checkCooldown enforces the resolved command's Cooldown and records the
+// current run in the state store.
+func (inv *Invocation) checkCooldown() error {
+	cmd := inv.Command
+	if cmd.Cooldown <= 0 {
+		return nil
+	}
+	stateDir := cmd.stateDir()
+
+	lastRuns := map[string]time.Time{}
+	if err := readStateFile(stateDir, cooldownsFileName, &lastRuns); err != nil {
+		return fmt.Errorf("reading cooldown state: %w", err)
+	}
+
+	name := cmd.FullName()
+	now := inv.Now()
+	if last, ok := lastRuns[name]; ok {
+		if elapsed := now.Sub(last); elapsed >= 0 && elapsed < cmd.Cooldown {
+			return &CooldownError{Command: name, LastRun: elapsed, RetryIn: cmd.Cooldown - elapsed}
+		}
+	}
+
+	lastRuns[name] = now
+	if err := writeStateFile(stateDir, cooldownsFileName, lastRuns); err != nil {
+		return fmt.Errorf("writing cooldown state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time                         { return c.now }
+func (c *stepClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func TestCommandCooldown(t *testing.T) {
+	clock := &stepClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	runs := 0
+	root := &Command{Use: "app", StateDir: t.TempDir()}
+	root.Children = append(root.Children, &Command{
+		Use:      "sync",
+		Cooldown: time.Minute,
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			runs++
+			return nil
+		},
+	})
+
+	run := func() error {
+		inv := root.Invoke("sync").WithClock(clock)
+		inv.Stdout = &bytes.Buffer{}
+		inv.Stderr = &bytes.Buffer{}
+		return inv.Run()
+	}
+
+	steps := []struct {
+		advance time.Duration
+		wantErr string
+	}{
+		{advance: 0},
+		{advance: 12 * time.Second, wantErr: "app sync: last run 12s ago, retry in 48s"},
+		{advance: 48 * time.Second},
+	}
+	for i, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		err := run()
+		if step.wantErr == "" {
+			if err != nil {
+				t.Fatalf("step %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		var cooldownErr *CooldownError
+		if !errors.As(err, &cooldownErr) || err.Error() != step.wantErr {
+			t.Fatalf("step %d: got %v, want %q", i, err, step.wantErr)
+		}
+	}
+	if runs != 2 {
+		t.Fatalf("handler ran %d times, want 2", runs)
+	}
+}
+
+func TestCommandCooldownRequiresStateDir(t *testing.T) {
+	cmd := &Command{
+		Use:      "sync",
+		Cooldown: time.Minute,
+		Handler:  func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	if err := cmd.Invoke().Run(); err == nil {
+		t.Fatal("expected init error without StateDir")
+	}
+}
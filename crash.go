@@ -0,0 +1,96 @@
+package redant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+const crashReportDirName = "crash-reports"
+
+// CrashReport is the content of a report file written on crashes.
+type CrashReport struct {
+	Time      string            `json:"time"`
+	Version   string            `json:"version,omitempty"`
+	GoVersion string            `json:"goVersion"`
+	OS        string            `json:"os"`
+	Arch      string            `json:"arch"`
+	Command   string            `json:"command"`
+	Flags     map[string]string `json:"flags,omitempty"`
+	Args      int               `json:"args"`
+	Reason    string            `json:"reason"`
+	Stack     string            `json:"stack,omitempty"`
+}
+
+// sensitiveWords mark flags and env vars whose values are never shown.
+var sensitiveWords = []string{"token", "secret", "password", "passwd", "key", "credential", "auth"}
+
+// IsSensitiveName reports whether a flag or env var name looks like it
+// holds a secret, so that its value must be redacted when shown: one of
+// its "-" or "_" separated words, ignoring case and a plural "s", is a
+// sensitive word. "api-token", "DB_PASSWORD" and "api-keys" match while
+// "author", "keyboard-layout" and "GIT_AUTHOR_NAME" do not.
+func IsSensitiveName(name string) bool {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	for _, word := range words {
+		if slices.Contains(sensitiveWords, word) || slices.Contains(sensitiveWords, strings.TrimSuffix(word, "s")) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCrashReport writes a report for reason (a recovered panic value or
+// any failure the application deems a crash) under the root StateDir and prints its path to
+// Stderr. Positional args are only counted and values of secret options
+// and sensitive-looking flags are redacted. It returns the report path.
+func (inv *Invocation) WriteCrashReport(reason any, stack []byte) (string, error) {
+	root := inv.Command.root()
+	if root.StateDir == "" {
+		return "", fmt.Errorf("crash reports require StateDir on the root command")
+	}
+
+	now := inv.Now()
+	report := CrashReport{
+		Time:      now.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		Version:   root.Version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Command:   inv.Command.FullName(),
+		Args:      len(inv.Args),
+		Reason:    fmt.Sprint(reason),
+		Stack:     string(stack),
+	}
+	report.Flags = inv.redactedFlags()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root.StateDir, crashReportDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", root.Name(), now.UTC().Format("20060102T150405.000000000")))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	if inv.Stderr != nil {
+		_, _ = fmt.Fprintf(inv.Stderr, "%s crashed; a report was written to %s\nplease attach it when filing a bug report\n", root.Name(), path)
+	}
+	return path, nil
+}
+
+// reportPanic writes a crash report for a recovered panic. Failures to
+// write are ignored so the original panic is never masked.
+func (inv *Invocation) reportPanic(r any) {
+	_, _ = inv.WriteCrashReport(r, debug.Stack())
+}
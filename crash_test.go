@@ -0,0 +1,138 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCrashReportOnPanic(t *testing.T) {
+	dir := t.TempDir()
+	var token, region string
+	root := &Command{Use: "app", Version: "1.2.3", StateDir: dir, CrashReports: true}
+	root.Children = append(root.Children, &Command{
+		Use: "boom",
+		Options: OptionSet{
+			{Flag: "api-token", Value: StringOf(&token)},
+			{Flag: "region", Value: StringOf(&region)},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			panic("kaboom")
+		},
+	})
+
+	stderr := &bytes.Buffer{}
+	inv := root.Invoke("boom", "--api-token", "s3cr3t", "--region", "eu", "extra")
+	inv.Stdout = &bytes.Buffer{}
+	inv.Stderr = stderr
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic to propagate")
+			}
+		}()
+		_ = inv.Run()
+	}()
+
+	files, err := filepath.Glob(filepath.Join(dir, crashReportDirName, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one crash report, got %v (err=%v)", files, err)
+	}
+	if !strings.Contains(stderr.String(), files[0]) {
+		t.Fatalf("expected report path in stderr, got %q", stderr.String())
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report CrashReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Command != "app boom" || report.Version != "1.2.3" || report.Reason != "kaboom" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Flags["api-token"] != "<redacted>" || report.Flags["region"] != "eu" {
+		t.Fatalf("unexpected flags: %v", report.Flags)
+	}
+	if strings.Contains(string(data), "s3cr3t") || strings.Contains(string(data), "extra") {
+		t.Fatalf("report leaks sensitive values: %s", data)
+	}
+	if report.Stack == "" {
+		t.Fatal("expected stack in report")
+	}
+}
+
+func TestCrashReportSkipsHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "plain", err: errors.New("nil map")},
+		{name: "user error", err: &Error{Msg: "no project"}},
+		{name: "exit code", err: &Error{Code: 3, Msg: "no changes"}},
+		{name: "canceled", err: fmt.Errorf("fetch: %w", context.Canceled)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			root := &Command{
+				Use:          "app",
+				StateDir:     dir,
+				CrashReports: true,
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					return tt.err
+				},
+			}
+			stderr := &bytes.Buffer{}
+			inv := root.Invoke()
+			inv.Stderr = stderr
+			if err := inv.Run(); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, crashReportDirName, "*.json"))
+			if len(files) != 0 {
+				t.Fatalf("reports = %v, want none", files)
+			}
+			if strings.Contains(stderr.String(), "crashed") {
+				t.Fatalf("stderr = %q, want no crash notice", stderr.String())
+			}
+		})
+	}
+}
+
+func TestIsSensitiveName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "token", want: true},
+		{name: "api-key", want: true},
+		{name: "api-keys", want: true},
+		{name: "DB_PASSWORD", want: true},
+		{name: "GITHUB_TOKEN", want: true},
+		{name: "client-secret", want: true},
+		{name: "basic-auth", want: true},
+		{name: "aws_credentials", want: true},
+		{name: "author", want: false},
+		{name: "authority-url", want: false},
+		{name: "keyboard-layout", want: false},
+		{name: "monkey", want: false},
+		{name: "GIT_AUTHOR_NAME", want: false},
+		{name: "KEYBOARD", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsSensitiveName(tt.name); got != tt.want {
+			t.Errorf("IsSensitiveName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
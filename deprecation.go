@@ -0,0 +1,157 @@
+package redant
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two dotted versions such as "v1.2.3" numerically.
+// Pre-release and build suffixes are ignored. ok is false if either version
+// cannot be parsed.
+func compareVersions(a, b string) (cmp int, ok bool) {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return ascendingSortFn(x, y), true
+		}
+	}
+	return 0, true
+}
+
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// removalReached reports whether the application version has reached the
+// removedIn version of a deprecated command or option.
+func (c *Command) removalReached(removedIn string) bool {
+	if removedIn == "" {
+		return false
+	}
+	cmp, ok := compareVersions(c.root().Version, removedIn)
+	return ok && cmp >= 0
+}
+
+// deprecationSchedule formats a deprecation message with its removal version.
+func deprecationSchedule(msg, removedIn string) string {
+	if removedIn == "" {
+		return msg
+	}
+	return fmt.Sprintf("%s (removed in %s)", msg, removedIn)
+}
+
+// checkCommandDeprecation warns about a deprecated command once per run, or
+// fails once the application version passed the command's RemovedIn.
+func (inv *Invocation) checkCommandDeprecation(state *runState, cmd *Command) error {
+	if cmd.Deprecated == "" || state.deprecationChecked[cmd] {
+		return nil
+	}
+	if state.deprecationChecked == nil {
+		state.deprecationChecked = make(map[*Command]bool)
+	}
+	state.deprecationChecked[cmd] = true
+
+	if cmd.removalReached(cmd.RemovedIn) {
+		return fmt.Errorf("command %q was removed in %s: %s", cmd.FullName(), cmd.RemovedIn, cmd.Deprecated)
+	}
+
+	if _, err := fmt.Fprintf(inv.Stderr, "%s "+inv.localize("%q is deprecated!. %s")+"\n",
+		inv.palette(inv.Stderr).header(inv.localize("warning")),
+		cmd.FullName(),
+		deprecationSchedule(cmd.Deprecated, cmd.RemovedIn),
+	); err != nil {
+		return fmt.Errorf("write deprecated warning: %w", err)
+	}
+	return nil
+}
+
+// checkPathDeprecation runs checkCommandDeprecation on inv.Command and its
+// ancestors, outermost first. It is skipped when help is requested so the
+// help of a removed command stays readable.
+func (inv *Invocation) checkPathDeprecation(state *runState) error {
+	var path []*Command
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		path = append(path, cmd)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		if err := inv.checkCommandDeprecation(state, path[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRemovedOptions fails if a flag whose removal version has been reached
+// was used.
+func (inv *Invocation) checkRemovedOptions() error {
+	if inv.Flags == nil {
+		return nil
+	}
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		for _, opt := range cmd.localOptions() {
+			if opt.Flag == "" || opt.Deprecated == "" || !cmd.removalReached(opt.RemovedIn) {
+				continue
+			}
+			if f := inv.Flags.Lookup(opt.Flag); f != nil && f.Changed {
+				return fmt.Errorf("flag --%s was removed in %s: %s", opt.Flag, opt.RemovedIn, opt.Deprecated)
+			}
+		}
+	}
+	return nil
+}
+
+// warnDeprecatedArgKeys warns about query, form and JSON args using a
+// DeprecatedAliases key of an arg.
+func (inv *Invocation) warnDeprecatedArgKeys() error {
+	warned := make(map[string]bool)
+	for _, arg := range inv.Args {
+		values, _, ok := parseKeyedArg(arg)
+		if !ok {
+			continue
+		}
+		keys := slices.Sorted(maps.Keys(values))
+		for _, key := range keys {
+			i := argByKey(inv.Command.Args, key)
+			if i < 0 || warned[key] || !slices.Contains(inv.Command.Args[i].DeprecatedAliases, key) {
+				continue
+			}
+			warned[key] = true
+			if _, err := fmt.Fprintf(inv.Stderr, "%s "+inv.localize("argument key %q is deprecated, use %q instead")+"\n",
+				inv.palette(inv.Stderr).header(inv.localize("warning")), key, inv.Command.Args[i].Name,
+			); err != nil {
+				return fmt.Errorf("write deprecated warning: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{a: "v1.2.3", b: "1.2.3", want: 0, wantOK: true},
+		{a: "v1.10.0", b: "v1.9.9", want: 1, wantOK: true},
+		{a: "v1.2", b: "v1.2.1", want: -1, wantOK: true},
+		{a: "v2.0.0-rc.1", b: "v2.0.0", want: 0, wantOK: true},
+		{a: "", b: "v1.0.0", wantOK: false},
+		{a: "dev", b: "v1.0.0", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			got, ok := compareVersions(tt.a, tt.b)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Fatalf("compareVersions(%q, %q) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDeprecationRemovalSchedule(t *testing.T) {
+	newTree := func(version string) *Command {
+		noop := func(ctx context.Context, inv *Invocation) error { return nil }
+		return &Command{
+			Use:     "app",
+			Version: version,
+			Children: []*Command{
+				{Use: "old", Deprecated: "use new", RemovedIn: "v2.0.0", Handler: noop},
+				{
+					Use:        "legacy",
+					Deprecated: "use new",
+					RemovedIn:  "v2.0.0",
+					Children:   []*Command{{Use: "sub", Handler: noop}},
+				},
+				{
+					Use: "run",
+					Options: OptionSet{
+						{Flag: "legacy", Deprecated: "use --modern", RemovedIn: "v2.0.0", Value: StringOf(new(string))},
+					},
+					Handler: noop,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		version    string
+		args       []string
+		wantErr    string
+		wantStderr string
+	}{
+		{name: "command warns before removal", version: "v1.9.0", args: []string{"old"}, wantStderr: "use new (removed in v2.0.0)"},
+		{name: "command fails at removal version", version: "v2.0.0", args: []string{"old"}, wantErr: `command "app old" was removed in v2.0.0`},
+		{name: "removed command shows help", version: "v2.0.0", args: []string{"old", "--help"}},
+		{name: "removed parent shows help", version: "v2.0.0", args: []string{"legacy", "sub", "-h"}},
+		{name: "removed parent fails", version: "v2.0.0", args: []string{"legacy", "sub"}, wantErr: `command "app legacy" was removed in v2.0.0`},
+		{name: "unknown version never escalates", version: "", args: []string{"old"}, wantStderr: "deprecated"},
+		{name: "flag works before removal", version: "v1.0.0", args: []string{"run", "--legacy", "x"}},
+		{name: "flag fails after removal", version: "v2.1.0", args: []string{"run", "--legacy", "x"}, wantErr: "flag --legacy was removed in v2.0.0"},
+		{name: "unused removed flag is fine", version: "v3.0.0", args: []string{"run"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			inv := newTree(tt.version).Invoke(tt.args...)
+			inv.Stderr = &stderr
+
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want contains %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Fatalf("stderr = %q, want contains %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.wantStderr != "" && strings.Count(stderr.String(), "deprecated") != 1 {
+				t.Fatalf("expected a single deprecation warning, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRemovedRootShowsHelp(t *testing.T) {
+	root := &Command{
+		Use:        "app",
+		Version:    "v2.0.0",
+		Deprecated: "use app2",
+		RemovedIn:  "v2.0.0",
+		Handler:    func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	if out := renderHelp(t, root); !strings.Contains(out, "USAGE:") {
+		t.Fatalf("help not shown:\n%s", out)
+	}
+	inv := root.Invoke()
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err == nil || !strings.Contains(err.Error(), `command "app" was removed in v2.0.0`) {
+		t.Fatalf("err = %v, want the removal error", err)
+	}
+}
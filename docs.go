@@ -0,0 +1,263 @@
+package redant
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// docsWidth is the width examples are aligned within in generated docs.
+const docsWidth = 100
+
+// WriteMarkdown writes the reference of the command tree rooted at root to
+// w as markdown, for docs sites: a section per visible command, headed by
+// its full name and nested by depth, with its usage, its Long description
+// passed through verbatim, its args, options, examples aligned as in help
+// and subcommands. Hidden commands and options are left out.
+func WriteMarkdown(w io.Writer, root *Command) error {
+	m, err := CommandTree(root)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	writeMarkdownCommand(&sb, *m, 1)
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// writeMarkdownCommand writes the section of m and its visible subcommands.
+func writeMarkdownCommand(sb *strings.Builder, m CommandManifest, depth int) {
+	_, _ = fmt.Fprintf(sb, "%s %s\n\n", strings.Repeat("#", min(depth, 6)), m.Path)
+	if m.Short != "" {
+		_, _ = fmt.Fprintf(sb, "%s\n\n", m.Short)
+	}
+	_, _ = fmt.Fprintf(sb, "```\n%s\n```\n\n", m.Usage)
+	if m.Deprecated != "" {
+		_, _ = fmt.Fprintf(sb, "**Deprecated:** %s\n\n", deprecationSchedule(m.Deprecated, m.RemovedIn))
+	}
+	if long := strings.TrimSpace(m.Long); long != "" {
+		_, _ = fmt.Fprintf(sb, "%s\n\n", long)
+	}
+	if len(m.Aliases) > 0 {
+		_, _ = fmt.Fprintf(sb, "**Aliases:** `%s`\n\n", strings.Join(m.Aliases, "`, `"))
+	}
+	if len(m.Args) > 0 {
+		_, _ = sb.WriteString("**Arguments:**\n\n")
+		for _, arg := range m.Args {
+			_, _ = fmt.Fprintf(sb, "- `%s` (%s)", arg.Name, strings.Join(arg.notes(), ", "))
+			if arg.Description != "" {
+				_, _ = fmt.Fprintf(sb, ": %s", arg.Description)
+			}
+			_, _ = sb.WriteString("\n")
+		}
+		_, _ = sb.WriteString("\n")
+	}
+	if flags := visibleFlags(m.Flags); len(flags) > 0 {
+		_, _ = sb.WriteString("**Options:**\n\n")
+		for _, f := range flags {
+			_, _ = fmt.Fprintf(sb, "- `%s` (%s)", strings.Join(f.names(), "`, `"), strings.Join(f.notes(), ", "))
+			if f.Description != "" {
+				_, _ = fmt.Fprintf(sb, ": %s", f.Description)
+			}
+			_, _ = sb.WriteString("\n")
+		}
+		_, _ = sb.WriteString("\n")
+	}
+	if len(m.Examples) > 0 {
+		_, _ = fmt.Fprintf(sb, "**Examples:**\n\n```\n%s```\n\n", docsExamples(m.Examples))
+	}
+	children := visibleCommands(m.Commands)
+	if len(children) > 0 {
+		_, _ = sb.WriteString("**Commands:**\n\n")
+		for _, child := range children {
+			_, _ = fmt.Fprintf(sb, "- `%s`", child.Path)
+			if child.Short != "" {
+				_, _ = fmt.Fprintf(sb, ": %s", child.Short)
+			}
+			_, _ = sb.WriteString("\n")
+		}
+		_, _ = sb.WriteString("\n")
+	}
+	for _, child := range children {
+		writeMarkdownCommand(sb, child, depth+1)
+	}
+}
+
+// WriteManPage writes a man page in section 1 for the command tree rooted
+// at root to w, in roff: the root command followed by a COMMANDS section
+// describing each visible subcommand. Long descriptions and examples are
+// laid out as in help, without colors. Hidden commands and options are left
+// out. Install it as e.g. /usr/share/man/man1/app.1.
+func WriteManPage(w io.Writer, root *Command) error {
+	m, err := CommandTree(root)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	source := m.Name
+	if m.Version != "" {
+		source += " " + m.Version
+	}
+	_, _ = fmt.Fprintf(&sb, ".TH %q 1 \"\" %q\n", strings.ToUpper(m.Name), source)
+	_, _ = sb.WriteString(".SH NAME\n")
+	if m.Short != "" {
+		_, _ = fmt.Fprintf(&sb, "%s \\- %s\n", roffEscape(m.Name), roffEscape(m.Short))
+	} else {
+		_, _ = fmt.Fprintf(&sb, "%s\n", roffEscape(m.Name))
+	}
+	_, _ = fmt.Fprintf(&sb, ".SH SYNOPSIS\n.nf\n%s\n.fi\n", roffEscape(m.Usage))
+	if long := strings.TrimSpace(m.Long); long != "" {
+		_, _ = fmt.Fprintf(&sb, ".SH DESCRIPTION\n.nf\n%s.fi\n", manLong(long))
+	}
+	if flags := visibleFlags(m.Flags); len(flags) > 0 {
+		_, _ = sb.WriteString(".SH OPTIONS\n")
+		writeManFlags(&sb, flags)
+	}
+	if len(m.Examples) > 0 {
+		_, _ = fmt.Fprintf(&sb, ".SH EXAMPLES\n.nf\n%s.fi\n", roffEscape(docsExamples(m.Examples)))
+	}
+	if children := visibleCommands(m.Commands); len(children) > 0 {
+		_, _ = sb.WriteString(".SH COMMANDS\n")
+		for _, child := range children {
+			writeManCommand(&sb, child)
+		}
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// writeManCommand writes the COMMANDS entry of m and of its visible
+// subcommands.
+func writeManCommand(sb *strings.Builder, m CommandManifest) {
+	_, _ = fmt.Fprintf(sb, ".SS %q\n", m.Path)
+	if m.Short != "" {
+		_, _ = fmt.Fprintf(sb, "%s\n.PP\n", roffEscape(m.Short))
+	}
+	_, _ = fmt.Fprintf(sb, ".nf\n%s\n.fi\n", roffEscape(m.Usage))
+	if m.Deprecated != "" {
+		_, _ = fmt.Fprintf(sb, ".PP\nDeprecated: %s\n", roffEscape(deprecationSchedule(m.Deprecated, m.RemovedIn)))
+	}
+	if long := strings.TrimSpace(m.Long); long != "" {
+		_, _ = fmt.Fprintf(sb, ".PP\n.nf\n%s.fi\n", manLong(long))
+	}
+	writeManFlags(sb, visibleFlags(m.Flags))
+	if len(m.Examples) > 0 {
+		_, _ = fmt.Fprintf(sb, ".PP\nExamples:\n.PP\n.nf\n%s.fi\n", roffEscape(docsExamples(m.Examples)))
+	}
+	for _, child := range visibleCommands(m.Commands) {
+		writeManCommand(sb, child)
+	}
+}
+
+// writeManFlags writes a tagged paragraph per flag.
+func writeManFlags(sb *strings.Builder, flags []FlagManifest) {
+	for _, f := range flags {
+		names := f.names()
+		for i, name := range names {
+			names[i] = `\fB` + roffEscape(name) + `\fR`
+		}
+		_, _ = fmt.Fprintf(sb, ".TP\n%s (%s)\n", strings.Join(names, ", "), roffEscape(strings.Join(f.notes(), ", ")))
+		if f.Description != "" {
+			_, _ = fmt.Fprintf(sb, "%s\n", roffEscape(f.Description))
+		}
+	}
+}
+
+// manLong renders the lightweight markdown of a Long description as help
+// does without colors, for a no-fill block of a man page.
+func manLong(long string) string {
+	return roffEscape(formatLong(long, 0, 80, palette{profile: termenv.Ascii}))
+}
+
+// docsExamples formats examples as help does, without its indentation.
+func docsExamples(examples []Example) string {
+	lines := strings.SplitAfter(formatExamples(examples, docsWidth), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "    ")
+	}
+	return strings.Join(lines, "")
+}
+
+// roffEscape escapes s for roff text: backslashes and dashes, and control
+// characters starting a line.
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// visibleCommands returns the commands that are not hidden.
+func visibleCommands(commands []CommandManifest) []CommandManifest {
+	var visible []CommandManifest
+	for _, c := range commands {
+		if !c.Hidden {
+			visible = append(visible, c)
+		}
+	}
+	return visible
+}
+
+// visibleFlags returns the flags that are neither hidden nor env-only.
+func visibleFlags(flags []FlagManifest) []FlagManifest {
+	var visible []FlagManifest
+	for _, f := range flags {
+		if !f.Hidden && f.Name != "" {
+			visible = append(visible, f)
+		}
+	}
+	return visible
+}
+
+// names returns the flag and its shorthand as typed on the command line.
+func (f FlagManifest) names() []string {
+	names := []string{"--" + f.Name}
+	if f.Shorthand != "" {
+		names = append(names, "-"+f.Shorthand)
+	}
+	return names
+}
+
+// notes returns the type of the flag followed by its constraints, default,
+// env vars and status, as listed in generated docs.
+func (f FlagManifest) notes() []string {
+	notes := []string{f.Type}
+	for _, c := range f.Constraints {
+		notes = append(notes, c.String())
+	}
+	if f.Default != "" {
+		notes = append(notes, "default: "+f.Default)
+	}
+	for _, env := range f.Env {
+		notes = append(notes, "env: $"+env)
+	}
+	if f.Deprecated != "" {
+		notes = append(notes, "deprecated: "+deprecationSchedule(f.Deprecated, f.RemovedIn))
+	}
+	return notes
+}
+
+// notes returns the type of the arg followed by its default, choices and
+// status, as listed in generated docs.
+func (a ArgManifest) notes() []string {
+	notes := []string{a.Type}
+	if len(a.Choices) > 0 {
+		notes = append(notes, "one of: "+strings.Join(a.Choices, ", "))
+	}
+	if a.Default != "" {
+		notes = append(notes, "default: "+a.Default)
+	}
+	if a.Required {
+		notes = append(notes, "required")
+	}
+	if a.Variadic {
+		notes = append(notes, "variadic")
+	}
+	return notes
+}
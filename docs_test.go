@@ -0,0 +1,93 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func docsTestTree() *Command {
+	return &Command{
+		Use:     "app",
+		Short:   "Ship things.",
+		Version: "1.2.3",
+		Children: []*Command{
+			{
+				Use:   "deploy",
+				Short: "Deploy a release.",
+				Long:  "Deploys the **current** release:\n- web\n\n```\nmake -x\n```\n.env is read first.",
+				Options: OptionSet{
+					{Flag: "stage", Shorthand: "s", Description: "Target stage.", Default: "dev", Envs: []string{"APP_STAGE"}, Value: StringOf(new(string))},
+					{Flag: "replicas", Min: "1", Max: "9", Value: Int64Of(new(int64))},
+					{Flag: "debug-dump", Hidden: true, Value: BoolOf(new(bool))},
+					{Flag: "zone", Deprecated: "use --stage", RemovedIn: "2.0", Value: StringOf(new(string))},
+				},
+				Args: ArgSet{{Name: "target", Required: true, Value: StringOf(new(string))}},
+				Examples: []Example{
+					{Command: "app deploy prod", Description: "Deploy to production."},
+					{Command: "app deploy -s qa web"},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			},
+			{Use: "legacy", Deprecated: "use deploy", RemovedIn: "2.0", Handler: func(ctx context.Context, inv *Invocation) error { return nil }},
+			{Use: "internal", Hidden: true, Handler: func(ctx context.Context, inv *Invocation) error { return nil }},
+		},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, docsTestTree()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# app\n\nShip things.\n",
+		"- `app deploy`: Deploy a release.\n",
+		"## app deploy\n\nDeploy a release.\n\n```\napp deploy [--replicas <replicas>] [-s <stage>] <target>\n```\n",
+		"Deploys the **current** release:\n- web\n\n```\nmake -x\n```\n.env is read first.\n",
+		"- `target` (string, required)\n",
+		"- `--stage`, `-s` (string, default: dev, env: $APP_STAGE): ",
+		"- `--replicas` (int64, min: 1, max: 9)\n",
+		"- `--zone` (string, deprecated: use --stage (removed in 2.0))\n",
+		"## app legacy\n\n```\napp legacy\n```\n\n**Deprecated:** use deploy (removed in 2.0)\n",
+		"```\n$ app deploy prod         # Deploy to production.\n$ app deploy -s qa web\n```\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, got)
+		}
+	}
+	for _, hidden := range []string{"internal", "debug-dump"} {
+		if strings.Contains(got, hidden) {
+			t.Errorf("markdown documents hidden %q", hidden)
+		}
+	}
+}
+
+func TestWriteManPage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteManPage(&buf, docsTestTree()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		".TH \"APP\" 1 \"\" \"app 1.2.3\"\n.SH NAME\napp \\- Ship things.\n",
+		".SH COMMANDS\n.SS \"app deploy\"\nDeploy a release.\n",
+		".nf\nDeploys the current release:\n\\- web\n\n  make \\-x\n\\&.env is read first.\n.fi\n",
+		".TP\n\\fB\\-\\-stage\\fR, \\fB\\-s\\fR (string, default: dev, env: $APP_STAGE)\n",
+		".TP\n\\fB\\-\\-replicas\\fR (int64, min: 1, max: 9)\n",
+		".TP\n\\fB\\-\\-zone\\fR (string, deprecated: use \\-\\-stage (removed in 2.0))\n",
+		".PP\nDeprecated: use deploy (removed in 2.0)\n",
+		".nf\n$ app deploy prod         # Deploy to production.\n$ app deploy \\-s qa web\n.fi\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("man page lacks %q:\n%s", want, got)
+		}
+	}
+	for _, hidden := range []string{"internal", `debug\-dump`} {
+		if strings.Contains(got, hidden) {
+			t.Errorf("man page documents hidden %q", hidden)
+		}
+	}
+}
@@ -13,15 +13,29 @@ type envSnapshot struct {
 	existed bool
 }
 
+// envFlagNames holds the effective names of the built-in env flags.
+// An empty name means the flag is disabled and is not preloaded.
+type envFlagNames struct {
+	env      string
+	envShort string
+	envFile  string
+}
+
+var defaultEnvFlagNames = envFlagNames{env: "env", envShort: "e", envFile: "env-file"}
+
 // preloadEnvFromArgs scans global env-related flags from raw args, applies them
-// to the process environment before normal flag parsing, and returns a restore
-// function to avoid leaking state between invocations.
-func preloadEnvFromArgs(args []string) (restore func() error, err error) {
+// to env before normal flag parsing, and returns a restore function to avoid
+// leaking state between invocations.
+func preloadEnvFromArgs(args []string, names envFlagNames, env envStore) (restore func() error, err error) {
+	if names == (envFlagNames{}) {
+		return nil, nil
+	}
+
 	snapshots := make(map[string]envSnapshot)
 
 	defer func() {
 		if err != nil && len(snapshots) > 0 {
-			_ = restoreEnvSnapshots(snapshots)
+			_ = restoreEnvSnapshots(env, snapshots)
 		}
 	}()
 
@@ -31,10 +45,10 @@ func preloadEnvFromArgs(args []string) (restore func() error, err error) {
 			return fmt.Errorf("environment variable name cannot be empty")
 		}
 		if _, ok := snapshots[key]; !ok {
-			prev, existed := os.LookupEnv(key)
+			prev, existed := env.LookupEnv(key)
 			snapshots[key] = envSnapshot{value: prev, existed: existed}
 		}
-		return os.Setenv(key, value)
+		return env.Setenv(key, value)
 	}
 
 	for i := 0; i < len(args); i++ {
@@ -43,22 +57,22 @@ func preloadEnvFromArgs(args []string) (restore func() error, err error) {
 			break
 		}
 
-		if flagName, value, ok, parseErr := parseEnvFlagFromArgs(args, i); parseErr != nil {
+		if flagName, value, ok, parseErr := parseEnvFlagFromArgs(args, i, names); parseErr != nil {
 			return nil, parseErr
 		} else if ok {
-			if consumesNextArg(arg, flagName) {
+			if consumesNextArg(arg, flagName, names) {
 				i++
 			}
 
 			switch flagName {
-			case "env":
+			case builtinEnv:
 				if err := applyEnvAssignmentsCSV(value, setEnv); err != nil {
-					return nil, fmt.Errorf("invalid --env value %q: %w", value, err)
+					return nil, fmt.Errorf("invalid --%s value %q: %w", names.env, value, err)
 				}
-			case "env-file":
+			case builtinEnvFile:
 				paths, err := readAsCSV(value)
 				if err != nil {
-					return nil, fmt.Errorf("parsing --env-file value %q: %w", value, err)
+					return nil, fmt.Errorf("parsing --%s value %q: %w", names.envFile, value, err)
 				}
 				for _, path := range paths {
 					path = strings.TrimSpace(path)
@@ -66,7 +80,7 @@ func preloadEnvFromArgs(args []string) (restore func() error, err error) {
 						continue
 					}
 					if err := loadEnvFile(path, setEnv); err != nil {
-						return nil, fmt.Errorf("loading --env-file entry %q: %w", path, err)
+						return nil, fmt.Errorf("loading --%s entry %q: %w", names.envFile, path, err)
 					}
 				}
 			}
@@ -78,20 +92,20 @@ func preloadEnvFromArgs(args []string) (restore func() error, err error) {
 	}
 
 	restore = func() error {
-		return restoreEnvSnapshots(snapshots)
+		return restoreEnvSnapshots(env, snapshots)
 	}
 
 	return restore, nil
 }
 
-func restoreEnvSnapshots(snapshots map[string]envSnapshot) error {
+func restoreEnvSnapshots(env envStore, snapshots map[string]envSnapshot) error {
 	var merr error
 	for key, snap := range snapshots {
 		var err error
 		if snap.existed {
-			err = os.Setenv(key, snap.value)
+			err = env.Setenv(key, snap.value)
 		} else {
-			err = os.Unsetenv(key)
+			err = env.Unsetenv(key)
 		}
 		merr = errors.Join(merr, err)
 	}
@@ -115,56 +129,69 @@ func parseLongFlag(arg string) (name, value string, hasInlineValue, ok bool) {
 	return name, value, hasInlineValue, true
 }
 
-func parseShortEFlag(arg string) (value string, hasInlineValue, ok bool) {
-	if strings.HasPrefix(arg, "--") || !strings.HasPrefix(arg, "-e") {
+func parseShortFlag(arg, shorthand string) (value string, hasInlineValue, ok bool) {
+	prefix := "-" + shorthand
+	if shorthand == "" || strings.HasPrefix(arg, "--") || !strings.HasPrefix(arg, prefix) {
 		return "", false, false
 	}
-	if arg == "-e" {
+	if arg == prefix {
 		return "", false, true
 	}
-	if strings.HasPrefix(arg, "-e=") {
-		return strings.TrimPrefix(arg, "-e="), true, true
+	if strings.HasPrefix(arg, prefix+"=") {
+		return strings.TrimPrefix(arg, prefix+"="), true, true
 	}
-	return strings.TrimPrefix(arg, "-e"), true, true
+	return strings.TrimPrefix(arg, prefix), true, true
 }
 
-func parseEnvFlagFromArgs(args []string, i int) (name, value string, ok bool, err error) {
+// parseEnvFlagFromArgs detects a built-in env flag at args[i]. The returned
+// name is the built-in identifier (env or env-file), not the effective name.
+func parseEnvFlagFromArgs(args []string, i int, names envFlagNames) (name, value string, ok bool, err error) {
 	arg := strings.TrimSpace(args[i])
 
 	if flagName, flagValue, hasInlineValue, parsed := parseLongFlag(arg); parsed {
-		switch flagName {
-		case "env", "env-file":
-			if !hasInlineValue {
-				if i+1 >= len(args) {
-					return "", "", false, fmt.Errorf("flag --%s requires a value", flagName)
-				}
-				flagValue = args[i+1]
-			}
-			return flagName, flagValue, true, nil
+		var id string
+		switch {
+		case names.env != "" && flagName == names.env:
+			id = builtinEnv
+		case names.envFile != "" && flagName == names.envFile:
+			id = builtinEnvFile
 		default:
 			return "", "", false, nil
 		}
+		if !hasInlineValue {
+			if i+1 >= len(args) {
+				return "", "", false, fmt.Errorf("flag --%s requires a value", flagName)
+			}
+			flagValue = args[i+1]
+		}
+		return id, flagValue, true, nil
 	}
 
-	if flagValue, hasInlineValue, parsed := parseShortEFlag(arg); parsed {
+	if flagValue, hasInlineValue, parsed := parseShortFlag(arg, names.envShort); parsed {
 		if !hasInlineValue {
 			if i+1 >= len(args) {
-				return "", "", false, fmt.Errorf("flag -e requires a value")
+				return "", "", false, fmt.Errorf("flag -%s requires a value", names.envShort)
 			}
 			flagValue = args[i+1]
 		}
-		return "env", flagValue, true, nil
+		return builtinEnv, flagValue, true, nil
 	}
 
 	return "", "", false, nil
 }
 
-func consumesNextArg(currentArg, flagName string) bool {
+func consumesNextArg(currentArg, flagName string, names envFlagNames) bool {
 	if strings.HasPrefix(currentArg, "--") {
-		return currentArg == "--"+flagName
+		switch flagName {
+		case builtinEnv:
+			return currentArg == "--"+names.env
+		case builtinEnvFile:
+			return currentArg == "--"+names.envFile
+		}
+		return false
 	}
-	if flagName == "env" {
-		return currentArg == "-e"
+	if flagName == builtinEnv && names.envShort != "" {
+		return currentArg == "-"+names.envShort
 	}
 	return false
 }
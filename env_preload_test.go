@@ -7,7 +7,7 @@ import (
 	"testing"
 )
 
-func TestParseShortEFlag(t *testing.T) {
+func TestParseShortFlag(t *testing.T) {
 	tests := []struct {
 		name           string
 		arg            string
@@ -24,7 +24,7 @@ func TestParseShortEFlag(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			value, inline, parsed := parseShortEFlag(tt.arg)
+			value, inline, parsed := parseShortFlag(tt.arg, "e")
 			if value != tt.wantValue || inline != tt.wantInline || parsed != tt.wantParsedFlag {
 				t.Fatalf("got value=%q inline=%v parsed=%v, want value=%q inline=%v parsed=%v",
 					value, inline, parsed,
@@ -87,7 +87,7 @@ func TestParseEnvFlagFromArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			name, value, ok, err := parseEnvFlagFromArgs(tt.args, tt.index)
+			name, value, ok, err := parseEnvFlagFromArgs(tt.args, tt.index, defaultEnvFlagNames)
 			if tt.wantErr != "" {
 				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 					t.Fatalf("err=%v, want contains %q", err, tt.wantErr)
@@ -115,7 +115,7 @@ func TestPreloadEnvFromArgs_AppliesAndRestores(t *testing.T) {
 	t.Setenv(existing, "orig")
 	_ = os.Unsetenv(created)
 
-	restore, err := preloadEnvFromArgs([]string{"-e", existing + "=override", "--env", created + "=1"})
+	restore, err := preloadEnvFromArgs([]string{"-e", existing + "=override", "--env", created + "=1"}, defaultEnvFlagNames, osEnv{})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -146,7 +146,7 @@ func TestPreloadEnvFromArgs_ShortInlineEquals(t *testing.T) {
 	const key = "REDANT_PRELOAD_SHORT_INLINE_EQUALS"
 	_ = os.Unsetenv(key)
 
-	restore, err := preloadEnvFromArgs([]string{"-e=" + key + "=ok"})
+	restore, err := preloadEnvFromArgs([]string{"-e=" + key + "=ok"}, defaultEnvFlagNames, osEnv{})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -184,7 +184,7 @@ func TestPreloadEnvFromArgs_EnvFileRepeatAndCSV(t *testing.T) {
 		t.Fatalf("write file2: %v", err)
 	}
 
-	restore, err := preloadEnvFromArgs([]string{"--env-file", file1, "--env-file", file2 + "," + file1})
+	restore, err := preloadEnvFromArgs([]string{"--env-file", file1, "--env-file", file2 + "," + file1}, defaultEnvFlagNames, osEnv{})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -221,7 +221,7 @@ func TestPreloadEnvFromArgs_StopAtDoubleDash(t *testing.T) {
 	const key = "REDANT_PRELOAD_STOP_AT_DASH"
 	_ = os.Unsetenv(key)
 
-	restore, err := preloadEnvFromArgs([]string{"--", "-e", key + "=1"})
+	restore, err := preloadEnvFromArgs([]string{"--", "-e", key + "=1"}, defaultEnvFlagNames, osEnv{})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -237,7 +237,7 @@ func TestPreloadEnvFromArgs_RollbackOnError(t *testing.T) {
 	const key = "REDANT_PRELOAD_ROLLBACK"
 	_ = os.Unsetenv(key)
 
-	restore, err := preloadEnvFromArgs([]string{"-e", key + "=1", "-e", "INVALID"})
+	restore, err := preloadEnvFromArgs([]string{"-e", key + "=1", "-e", "INVALID"}, defaultEnvFlagNames, osEnv{})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -253,7 +253,7 @@ func TestPreloadEnvFromArgs_RollbackOnParseErrorAfterMutation(t *testing.T) {
 	const key = "REDANT_PRELOAD_ROLLBACK_PARSE"
 	_ = os.Unsetenv(key)
 
-	restore, err := preloadEnvFromArgs([]string{"-e", key + "=1", "--env"})
+	restore, err := preloadEnvFromArgs([]string{"-e", key + "=1", "--env"}, defaultEnvFlagNames, osEnv{})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -355,7 +355,7 @@ func TestConsumesNextArg(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := consumesNextArg(tt.current, tt.flagName); got != tt.want {
+			if got := consumesNextArg(tt.current, tt.flagName, defaultEnvFlagNames); got != tt.want {
 				t.Fatalf("got %v, want %v", got, tt.want)
 			}
 		})
@@ -367,7 +367,7 @@ func TestPreloadEnvFromArgs_RollbackOnEnvFileLoadError(t *testing.T) {
 	_ = os.Unsetenv(key)
 
 	missing := filepath.Join(t.TempDir(), "not-exists.env")
-	restore, err := preloadEnvFromArgs([]string{"-e", key + "=1", "--env-file", missing})
+	restore, err := preloadEnvFromArgs([]string{"-e", key + "=1", "--env-file", missing}, defaultEnvFlagNames, osEnv{})
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -383,7 +383,7 @@ func TestPreloadEnvFromArgs_NoEnvFlagsNoChange(t *testing.T) {
 	const key = "REDANT_PRELOAD_NO_CHANGE"
 	t.Setenv(key, "orig")
 
-	restore, err := preloadEnvFromArgs([]string{"--name", "demo", "subcmd"})
+	restore, err := preloadEnvFromArgs([]string{"--name", "demo", "subcmd"}, defaultEnvFlagNames, osEnv{})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
@@ -436,7 +436,7 @@ func TestRestoreEnvSnapshots(t *testing.T) {
 		created:  {value: "", existed: false},
 	}
 
-	if err := restoreEnvSnapshots(snapshots); err != nil {
+	if err := restoreEnvSnapshots(osEnv{}, snapshots); err != nil {
 		t.Fatalf("restoreEnvSnapshots err: %v", err)
 	}
 
@@ -0,0 +1,125 @@
+package redant
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// envStore is the environment an invocation reads from and writes to.
+type envStore interface {
+	LookupEnv(key string) (string, bool)
+	Setenv(key, value string) error
+	Unsetenv(key string) error
+}
+
+// osEnv is the process environment.
+type osEnv struct{}
+
+func (osEnv) LookupEnv(key string) (string, bool) { return os.LookupEnv(key) }
+func (osEnv) Setenv(key, value string) error      { return os.Setenv(key, value) }
+func (osEnv) Unsetenv(key string) error           { return os.Unsetenv(key) }
+
+// mapEnv is a synthetic environment isolated from the process.
+type mapEnv map[string]string
+
+func (m mapEnv) LookupEnv(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m mapEnv) Setenv(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "=\x00") {
+		return errors.New("invalid environment variable name")
+	}
+	m[key] = value
+	return nil
+}
+
+func (m mapEnv) Unsetenv(key string) error {
+	delete(m, key)
+	return nil
+}
+
+// WithEnviron returns a copy of the Invocation that uses env ("KEY=VALUE"
+// entries) instead of the process environment. Option env resolution,
+// --env/--env-file preloading and Exec all use the synthetic environment,
+// so embedding servers can run commands with per-request environments.
+func (inv *Invocation) WithEnviron(env []string) *Invocation {
+	m := make(mapEnv, len(env))
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		if k == "" {
+			continue
+		}
+		m[k] = v
+	}
+	return inv.with(func(i *Invocation) {
+		i.environ = m
+	})
+}
+
+// env returns the environment backing the invocation.
+func (inv *Invocation) env() envStore {
+	if inv.environ == nil {
+		return osEnv{}
+	}
+	return inv.environ
+}
+
+// Environ returns the invocation's environment in "KEY=VALUE" form.
+// It is os.Environ() unless the invocation was isolated with WithEnviron.
+func (inv *Invocation) Environ() []string {
+	if inv.environ == nil {
+		return os.Environ()
+	}
+	out := make([]string, 0, len(inv.environ))
+	for k, v := range inv.environ {
+		out = append(out, k+"="+v)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// LookupEnv retrieves the value of the environment variable key from the
+// invocation's environment.
+func (inv *Invocation) LookupEnv(key string) (string, bool) {
+	return inv.env().LookupEnv(key)
+}
+
+// Getenv returns the value of the environment variable key from the
+// invocation's environment, or "" if it is unset.
+func (inv *Invocation) Getenv(key string) string {
+	v, _ := inv.LookupEnv(key)
+	return v
+}
+
+// Setenv sets an environment variable in the invocation's environment.
+// Without WithEnviron this is the process environment.
+func (inv *Invocation) Setenv(key, value string) error {
+	return inv.env().Setenv(key, value)
+}
+
+// Exec runs an external program bound to the invocation's context, stdio,
+// environment and Dir.
+func (inv *Invocation) Exec(name string, args ...string) error {
+	cmd := exec.CommandContext(inv.Context(), name, args...)
+	cmd.Dir = inv.Dir
+	cmd.Env = inv.Environ()
+	cmd.Stdin = inv.Stdin
+	cmd.Stdout = inv.Stdout
+	cmd.Stderr = inv.Stderr
+	return cmd.Run()
+}
+
+// Path resolves a relative path against Dir, e.g. a file named in args.
+// Absolute paths, and all paths when Dir is empty, are returned as is.
+func (inv *Invocation) Path(name string) string {
+	if inv.Dir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(inv.Dir, name)
+}
@@ -0,0 +1,97 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestInvocationWithEnvironIsolatesOptionEnv(t *testing.T) {
+	const key = "REDANT_ENVIRON_ISOLATED"
+	t.Setenv(key, "process")
+
+	tests := []struct {
+		name    string
+		environ []string
+		args    []string
+		want    string
+	}{
+		{name: "process env by default", want: "process"},
+		{name: "synthetic env overrides process", environ: []string{key + "=synthetic"}, want: "synthetic"},
+		{name: "synthetic env without key ignores process", environ: []string{"OTHER=1"}, want: ""},
+		{name: "env flag writes synthetic env", environ: []string{}, args: []string{"-e", key + "=flag"}, want: "flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, fromInv string
+			cmd := &Command{
+				Use: "app",
+				Options: OptionSet{
+					{Flag: "value", Envs: []string{key}, Value: StringOf(&got)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					fromInv = inv.Getenv(key)
+					return nil
+				},
+			}
+
+			inv := cmd.Invoke(tt.args...)
+			if tt.environ != nil {
+				inv = inv.WithEnviron(tt.environ)
+			}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("option value = %q, want %q", got, tt.want)
+			}
+			if tt.environ != nil && fromInv != tt.want {
+				t.Fatalf("inv.Getenv = %q, want %q", fromInv, tt.want)
+			}
+			if v := os.Getenv(key); v != "process" {
+				t.Fatalf("process env leaked: %q", v)
+			}
+		})
+	}
+}
+
+func TestInvocationSetenvAndEnviron(t *testing.T) {
+	inv := (&Command{Use: "app"}).Invoke().WithEnviron([]string{"B=2", "A=1", "=skip"})
+
+	if err := inv.Setenv("C", "3"); err != nil {
+		t.Fatalf("Setenv failed: %v", err)
+	}
+	if err := inv.Setenv("", "x"); err == nil {
+		t.Fatalf("expected error for empty key")
+	}
+
+	got := strings.Join(inv.Environ(), ",")
+	if got != "A=1,B=2,C=3" {
+		t.Fatalf("Environ() = %q, want %q", got, "A=1,B=2,C=3")
+	}
+	if _, ok := os.LookupEnv("C"); ok && os.Getenv("C") == "3" {
+		t.Fatalf("Setenv on isolated invocation leaked into process env")
+	}
+}
+
+func TestInvocationExecUsesEnviron(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	var out bytes.Buffer
+	inv := (&Command{Use: "app"}).Invoke().WithEnviron([]string{"REDANT_EXEC_VALUE=isolated"})
+	inv.Stdout = &out
+
+	if err := inv.Exec(sh, "-c", "printf %s \"$REDANT_EXEC_VALUE\""); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if out.String() != "isolated" {
+		t.Fatalf("Exec output = %q, want %q", out.String(), "isolated")
+	}
+}
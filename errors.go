@@ -0,0 +1,143 @@
+package redant
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/muesli/termenv"
+
+	"github.com/pubgo/redant/internal/pretty"
+)
+
+// Error is an error meant for the user of the application: Msg says what
+// went wrong, Hint how to fix it, and Code is the exit status Main uses.
+// Err, when set, is the underlying cause.
+//
+//	return &redant.Error{
+//		Msg:  "no project found",
+//		Hint: "run 'app init' first",
+//		Code: 3,
+//	}
+type Error struct {
+	Msg  string
+	Hint string
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
+	if e.Msg == "" {
+		return e.Err.Error()
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns Code, or else the code of an ExitCoder wrapped by the
+// cause. Otherwise it is 0, leaving the status to the other rules of
+// ExitCode, e.g. ExitUsage within a UsageError or ExitTimeout for a
+// cause wrapping context.DeadlineExceeded.
+func (e *Error) ExitCode() int {
+	if e.Code != 0 {
+		return e.Code
+	}
+	var exitCoder ExitCoder
+	if errors.As(e.Err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+	return 0
+}
+
+// ErrorRenderer prints an error returned by Run for the user. See
+// Command.RenderError.
+type ErrorRenderer func(inv *Invocation, err error)
+
+// DefaultErrorRenderer prints err to Stderr as an "error:" line, without
+// the wrapping added by Run, followed by the hint of an Error in its chain
+// or, for usage errors, a pointer to --help, and the first docs link in
+// the metadata of the command or its ancestors. Under --verbose the full
+// chain of wrapped errors is listed too.
+func DefaultErrorRenderer(inv *Invocation, err error) {
+	msg := err.Error()
+	var (
+		userErr  *Error
+		runErr   *RunCommandError
+		usageErr *UsageError
+		hint     string
+	)
+	switch {
+	case errors.As(err, &userErr):
+		msg, hint = userErr.Error(), userErr.Hint
+	case errors.As(err, &runErr) && runErr.Err != nil:
+		msg = runErr.Err.Error()
+	}
+	if hint == "" && errors.As(err, &usageErr) && usageErr.Cmd != nil {
+		hint = inv.Translate("run '%s --help' for usage", usageErr.Cmd.FullName())
+	}
+	if msg == "" {
+		return
+	}
+
+	inv.Errorf("%s", msg)
+	label := pretty.Style{pretty.FgColor(termenv.ANSICyan)}
+	if hint != "" {
+		inv.printLine(inv.Stderr, label, inv.localize("hint")+": ", "%s", []any{hint})
+	}
+	if link := inv.Command.docsLink(); link != "" {
+		inv.printLine(inv.Stderr, label, inv.localize("docs")+": ", "%s", []any{link})
+	}
+	if inv.Verbosity() >= VerbosityVerbose {
+		for _, layer := range errorChain(err) {
+			inv.printLine(inv.Stderr, label, "  "+inv.localize("caused by")+": ", "%s", []any{layer})
+		}
+	}
+}
+
+// renderError prints err with the RenderError of the root command, or
+// DefaultErrorRenderer.
+func (inv *Invocation) renderError(err error) {
+	if render := inv.Command.root().RenderError; render != nil {
+		render(inv, err)
+		return
+	}
+	DefaultErrorRenderer(inv, err)
+}
+
+// docsLink returns the first "docs" metadata link of c or its ancestors.
+func (c *Command) docsLink() string {
+	for ; c != nil; c = c.parent {
+		for _, link := range strings.Split(c.Meta("docs"), ",") {
+			if link = strings.TrimSpace(link); link != "" {
+				return link
+			}
+		}
+	}
+	return ""
+}
+
+// errorChain describes each error wrapped by err, from the outermost, by
+// its own part of the message and its type, e.g. `comparing (*fmt.wrapError)`.
+func errorChain(err error) []string {
+	var chain []string
+	for err != nil {
+		next := errors.Unwrap(err)
+		msg := err.Error()
+		if next != nil {
+			msg = strings.TrimSuffix(strings.TrimSuffix(msg, next.Error()), ": ")
+		}
+		if msg == "" {
+			chain = append(chain, fmt.Sprintf("%T", err))
+		} else {
+			chain = append(chain, fmt.Sprintf("%s (%T)", msg, err))
+		}
+		err = next
+	}
+	return chain
+}
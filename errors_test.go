@@ -0,0 +1,89 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDefaultErrorRenderer(t *testing.T) {
+	errNotFound := errors.New("not found")
+	root := &Command{
+		Use:      "app",
+		Metadata: map[string]string{"docs": "https://example.com/app, https://example.com/more"},
+		Children: []*Command{
+			{
+				Use: "deploy",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					return fmt.Errorf("loading: %w", &Error{Msg: "no project", Hint: "run 'app init' first", Code: 3, Err: errNotFound})
+				},
+			},
+			{
+				Use: "sync",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					return fmt.Errorf("fetching: %w", errNotFound)
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			args:       []string{"deploy"},
+			wantCode:   3,
+			wantStderr: "error: no project: not found\nhint: run 'app init' first\ndocs: https://example.com/app\n",
+		},
+		{
+			args:       []string{"sync"},
+			wantCode:   ExitFailure,
+			wantStderr: "error: fetching: not found\ndocs: https://example.com/app\n",
+		},
+		{
+			args:     []string{"sync", "--verbose"},
+			wantCode: ExitFailure,
+			wantStderr: "error: fetching: not found\ndocs: https://example.com/app\n" +
+				"  caused by: running command \"app sync\" (*redant.RunCommandError)\n" +
+				"  caused by: fetching (*fmt.wrapError)\n" +
+				"  caused by: not found (*errors.errorString)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			var stderr bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stderr = &stderr
+			if code := inv.main(); code != tt.wantCode {
+				t.Fatalf("exit code %d, want %d", code, tt.wantCode)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Fatalf("stderr %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRenderErrorOverride(t *testing.T) {
+	var rendered error
+	root := &Command{
+		Use:         "app",
+		RenderError: func(inv *Invocation, err error) { rendered = err },
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			return errors.New("boom")
+		},
+	}
+	var stderr bytes.Buffer
+	inv := root.Invoke()
+	inv.Stderr = &stderr
+	if code := inv.main(); code != ExitFailure {
+		t.Fatalf("exit code %d", code)
+	}
+	if rendered == nil || stderr.Len() != 0 {
+		t.Fatalf("rendered %v, stderr %q", rendered, stderr.String())
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/pubgo/redant"
@@ -248,9 +247,5 @@ func main() {
 	)
 
 	// Run command
-	err := rootCmd.Invoke().WithOS().Run()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	redant.Main(rootCmd)
 }
@@ -3,9 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
-
-	"github.com/spf13/pflag"
 
 	"github.com/pubgo/redant"
 )
@@ -83,7 +80,7 @@ func main() {
 
 			// Get flag values
 			if inv.Flags != nil {
-				inv.Flags.VisitAll(func(f *pflag.Flag) {
+				inv.Flags.VisitAll(func(f *redant.Flag) {
 					fmt.Printf("Flag: %s, Value: %v, Type: %s\n", f.Name, f.Value.String(), f.Value.Type())
 				})
 			}
@@ -120,9 +117,5 @@ func main() {
 	rootCmd.Children = append(rootCmd.Children, serverCmd, configCmd)
 
 	// Run command
-	err := rootCmd.Invoke().WithOS().Run()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	redant.Main(rootCmd)
 }
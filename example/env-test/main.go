@@ -83,9 +83,5 @@ func main() {
 	rootCmd.Children = append(rootCmd.Children, testCmd)
 
 	// Run command
-	err := rootCmd.Invoke().WithOS().Run()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	redant.Main(rootCmd)
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
 	"time"
 
 	"github.com/pubgo/redant"
@@ -397,9 +396,5 @@ func main() {
 		webttycmd.New(),
 	)
 
-	err := rootCmd.Invoke().WithOS().Run()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	redant.Main(rootCmd)
 }
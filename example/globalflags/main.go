@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/pubgo/redant"
 )
@@ -66,9 +65,5 @@ func main() {
 	rootCmd.Children = append(rootCmd.Children, testCmd)
 
 	// Run command
-	err := rootCmd.Invoke().WithOS().Run()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	redant.Main(rootCmd)
 }
@@ -0,0 +1,125 @@
+package redant
+
+import (
+	"context"
+	"errors"
+)
+
+// Exit codes used by ExitCode for errors that carry none. ExitSignal is
+// the status shells report for a process interrupted by SIGINT; commands
+// canceled by another notify signal exit with 128 plus its number.
+const (
+	ExitFailure = 1
+	ExitUsage   = 2
+	ExitTimeout = 124
+	ExitSignal  = 130
+)
+
+// ExitCoder is implemented by errors that choose the process exit status,
+// e.g. to report "no changes" as 3 from a diff command. See ExitCode.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// UsageError reports a command line rejected before the handler ran: an
+// unknown or malformed flag, a missing required flag, invalid args or a
+// failed option validation.
+type UsageError struct {
+	// Cmd is the command the command line resolved to.
+	Cmd *Command
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// usageError wraps err as a UsageError of the invoked command, passed
+// through the nearest OnUsageError hook, and rejects the run with it.
+func (inv *Invocation) usageError(err error) error {
+	usageErr := &UsageError{Cmd: inv.Command, Err: err}
+	for c := inv.Command; c != nil; c = c.parent {
+		if c.OnUsageError == nil {
+			continue
+		}
+		custom := c.OnUsageError(inv, usageErr)
+		if custom == nil {
+			break
+		}
+		if target := (*UsageError)(nil); !errors.As(custom, &target) {
+			custom = &UsageError{Cmd: inv.Command, Err: custom}
+		}
+		return inv.rejectRun(custom)
+	}
+	return inv.rejectRun(usageErr)
+}
+
+// rejectRun passes a run rejected with err before its handler through the
+// middleware of the command, in place of the handler, so that middleware
+// such as Audit and the Reporter see every run.
+func (inv *Invocation) rejectRun(err error) error {
+	ctx := inv.setupLogger(inv.Context())
+	return inv.reportRun(ctx, func() error {
+		return inv.middleware()(func(context.Context, *Invocation) error {
+			return err
+		})(ctx, inv)
+	})
+}
+
+// ExitCode maps an error returned by Run to a process exit status:
+//
+//   - 0 for nil;
+//   - the code of the first ExitCoder in the error chain, unless it is 0;
+//   - ExitUsage (2) for usage errors and unknown subcommands;
+//   - ExitTimeout (124) when a deadline was exceeded;
+//   - 128 plus the signal number when a notify signal canceled the
+//     command, e.g. 143 for SIGTERM (see Command.NotifySignals);
+//   - ExitSignal (130) when the context was canceled otherwise;
+//   - ExitFailure (1) otherwise.
+func ExitCode(err error) int {
+	var (
+		exitCoder  ExitCoder
+		usageErr   *UsageError
+		unknownErr *UnknownSubcommandError
+	)
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitCoder) && exitCoder.ExitCode() != 0:
+		return exitCoder.ExitCode()
+	case errors.As(err, &usageErr), errors.As(err, &unknownErr):
+		return ExitUsage
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitSignal
+	default:
+		return ExitFailure
+	}
+}
+
+// Main runs cmd with the process's args and stdio, prints a returned error
+// with the root's RenderError or DefaultErrorRenderer, and exits with the
+// status ExitCode maps the error to. It is meant as the whole body of main:
+//
+//	func main() {
+//		redant.Main(rootCmd)
+//	}
+func Main(cmd *Command) {
+	inv := cmd.Invoke().WithOS()
+	inv.exit(inv.main())
+}
+
+// main runs the invocation and reports its error, returning the exit code.
+func (inv *Invocation) main() int {
+	err := inv.Run()
+	if err == nil {
+		return 0
+	}
+	inv.renderError(err)
+	return ExitCode(err)
+}
@@ -0,0 +1,149 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type exitStatusError int
+
+func (e exitStatusError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitStatusError) ExitCode() int { return int(e) }
+
+func TestExitCode(t *testing.T) {
+	failWith := func(err error) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) error { return err }
+	}
+	root := &Command{
+		Use: "app",
+		Children: []*Command{
+			{Use: "ok", Handler: failWith(nil)},
+			{Use: "fail", Handler: failWith(errors.New("boom"))},
+			{Use: "oops", Handler: failWith(&Error{Msg: "oops"})},
+			{Use: "diff", Handler: failWith(fmt.Errorf("comparing: %w", exitStatusError(3)))},
+			{Use: "slow", Handler: failWith(context.DeadlineExceeded)},
+			{Use: "stopped", Handler: failWith(context.Canceled)},
+			{Use: "init", Handler: failWith(&Error{Msg: "no project", Hint: "run init"})},
+			{Use: "coded", Handler: failWith(&Error{Msg: "conflict", Code: 4})},
+			{
+				Use:     "get",
+				Options: OptionSet{{Flag: "name", Value: StringOf(new(string)), Required: true}},
+				Handler: failWith(nil),
+			},
+			{Use: "repo", Children: []*Command{{Use: "commit", Handler: failWith(nil)}}},
+		},
+	}
+
+	tests := []struct {
+		args       []string
+		want       int
+		wantStderr string
+	}{
+		{args: []string{"ok"}, want: 0},
+		{args: []string{"fail"}, want: ExitFailure, wantStderr: "error: boom\n"},
+		{args: []string{"oops"}, want: ExitFailure, wantStderr: "error: oops\n"},
+		{args: []string{"diff"}, want: 3, wantStderr: "error: comparing: exit status 3\n"},
+		{args: []string{"slow"}, want: ExitTimeout},
+		{args: []string{"stopped"}, want: ExitSignal},
+		{args: []string{"init"}, want: ExitFailure, wantStderr: "error: no project\nhint: run init\n"},
+		{args: []string{"coded"}, want: 4, wantStderr: "error: conflict\n"},
+		{args: []string{"ok", "--bogus"}, want: ExitUsage},
+		{args: []string{"get"}, want: ExitUsage, wantStderr: "error: missing values for the required flags: name (checked --name)\nhint: run 'app get --help' for usage\n"},
+		{args: []string{"repo", "comit"}, want: ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			var stderr bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &stderr
+			if got := inv.main(); got != tt.want {
+				t.Fatalf("exit code %d, want %d (stderr %q)", got, tt.want, stderr.String())
+			}
+			if tt.wantStderr != "" && stderr.String() != tt.wantStderr {
+				t.Fatalf("stderr %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestOnUsageError(t *testing.T) {
+	tests := []struct {
+		name       string
+		hook       func(inv *Invocation, err error) error
+		childHook  bool
+		args       []string
+		want       int
+		wantStderr string
+	}{
+		{
+			name: "custom message",
+			hook: func(inv *Invocation, err error) error {
+				return &Error{Msg: "bad command line", Hint: "see https://example.com/docs", Err: errors.Unwrap(err)}
+			},
+			args:       []string{"get", "--bogus"},
+			want:       ExitUsage,
+			wantStderr: "error: bad command line: parsing flags ([--bogus]) for \"app get\": unknown flag: --bogus\nhint: see https://example.com/docs\n",
+		},
+		{
+			name: "arg validation",
+			hook: func(inv *Invocation, err error) error {
+				return fmt.Errorf("usage: %s NAME", inv.Command.FullName())
+			},
+			args:       []string{"get", "a", "b"},
+			want:       ExitUsage,
+			wantStderr: "error: usage: app get NAME\nhint: run 'app get --help' for usage\n",
+		},
+		{
+			name:       "keep original",
+			hook:       func(inv *Invocation, err error) error { return nil },
+			args:       []string{"get"},
+			want:       ExitUsage,
+			wantStderr: "error: wanted 1 args but got 0 []\nhint: run 'app get --help' for usage\n",
+		},
+		{
+			name: "nearest command",
+			hook: func(inv *Invocation, err error) error {
+				return errors.New("from child")
+			},
+			childHook:  true,
+			args:       []string{"get", "--bogus"},
+			want:       ExitUsage,
+			wantStderr: "error: from child\nhint: run 'app get --help' for usage\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := &Command{
+				Use:        "get",
+				ArgsPolicy: ExactArgs(1),
+				Handler:    func(ctx context.Context, inv *Invocation) error { return nil },
+			}
+			root := &Command{
+				Use: "app",
+				OnUsageError: func(inv *Invocation, err error) error {
+					return errors.New("from root")
+				},
+				Children: []*Command{get},
+			}
+			if tt.childHook {
+				get.OnUsageError = tt.hook
+			} else {
+				root.OnUsageError = tt.hook
+			}
+			var stderr bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &stderr
+			if got := inv.main(); got != tt.want {
+				t.Fatalf("exit code %d, want %d (stderr %q)", got, tt.want, stderr.String())
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Fatalf("stderr %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
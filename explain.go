@@ -0,0 +1,151 @@
+package redant
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Explanation describes what an invocation would do, as printed by
+// --explain instead of running the handler.
+type Explanation struct {
+	// Command is the resolved command path, e.g. "app deploy".
+	Command string `json:"command"`
+	// Args are the positional args left after flag parsing.
+	Args []string `json:"args,omitempty"`
+	// Options are the effective values of the command's options.
+	Options []ExplainedOption `json:"options,omitempty"`
+	// Effects are the side effects declared in Command.Effects.
+	Effects []string `json:"effects,omitempty"`
+	// Docs are the "docs" metadata links of the command and its parents,
+	// most specific first.
+	Docs []string `json:"docs,omitempty"`
+}
+
+// ExplainedOption is the effective value of an option and its source.
+type ExplainedOption struct {
+	// Name is "--flag", or "$ENV" for env-only options.
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Source is "flag", "env $NAME", "default" or "unset".
+	Source string `json:"source"`
+}
+
+// Explain reports the resolved command, the effective option values and
+// their sources, the declared effects and docs links of the invocation.
+// Values of sensitive-looking options are redacted. It is meaningful once
+// flags are parsed, i.e. from middleware and handlers or with --explain.
+func (inv *Invocation) Explain() *Explanation {
+	showHidden := inv.builtinBool(builtinShowHidden)
+	exp := &Explanation{
+		Command: inv.Command.FullName(),
+		Args:    inv.Args,
+		Effects: inv.Command.Effects,
+	}
+
+	var cliSet map[string]bool
+	if inv.Flags != nil {
+		cliSet = make(map[string]bool)
+		// Visit only reports flags set on the command line; env values
+		// are applied to the flag directly and only mark it Changed.
+		inv.Flags.Visit(func(f *Flag) { cliSet[f.Name] = true })
+	}
+
+	seen := make(map[string]bool)
+	for _, opt := range inv.Command.FullOptions() {
+		if opt.builtin != "" || opt.Value == nil || opt.Hidden && !showHidden {
+			continue
+		}
+		name := opt.name()
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		entry := ExplainedOption{Name: "$" + name, Value: opt.Value.String()}
+		var flag *Flag
+		if opt.Flag != "" {
+			entry.Name = "--" + opt.Flag
+			if inv.Flags != nil {
+				flag = inv.Flags.Lookup(opt.Flag)
+			}
+			if flag != nil {
+				entry.Value = flag.Value.String()
+			}
+		}
+
+		switch {
+		case flag != nil && cliSet[flag.Name]:
+			entry.Source = "flag"
+		case flag != nil && flag.Changed, opt.Flag == "":
+			entry.Source = inv.envSource(opt)
+		}
+		if entry.Source == "" {
+			if opt.Default != "" {
+				entry.Source = "default"
+			} else {
+				entry.Source = "unset"
+			}
+		}
+		if opt.IsSecret() && entry.Source != "unset" {
+			entry.Value = "<redacted>"
+		}
+		exp.Options = append(exp.Options, entry)
+	}
+
+	for c := inv.Command; c != nil; c = c.parent {
+		for _, link := range strings.Split(c.Meta("docs"), ",") {
+			if link = strings.TrimSpace(link); link != "" {
+				exp.Docs = append(exp.Docs, link)
+			}
+		}
+	}
+	return exp
+}
+
+// envSource names the first env var of opt holding a value, or returns ""
+// when none does.
+func (inv *Invocation) envSource(opt Option) string {
+	for _, env := range opt.Envs {
+		if v, _ := inv.LookupEnv(env); v != "" {
+			return "env $" + env
+		}
+	}
+	return ""
+}
+
+// String renders the explanation as printed by --explain.
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Command: %s\n", e.Command)
+	if len(e.Args) > 0 {
+		fmt.Fprintf(&sb, "Args: %s\n", strings.Join(e.Args, " "))
+	}
+
+	sb.WriteString("\nOptions:\n")
+	if len(e.Options) == 0 {
+		sb.WriteString("  (none)\n")
+	} else {
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, opt := range e.Options {
+			fmt.Fprintf(tw, "  %s\t%s\t(%s)\n", opt.Name, opt.Value, opt.Source)
+		}
+		_ = tw.Flush()
+	}
+
+	sb.WriteString("\nEffects:\n")
+	if len(e.Effects) == 0 {
+		sb.WriteString("  (none declared)\n")
+	}
+	for _, effect := range e.Effects {
+		fmt.Fprintf(&sb, "  - %s\n", effect)
+	}
+
+	if len(e.Docs) > 0 {
+		sb.WriteString("\nDocs:\n")
+		for _, link := range e.Docs {
+			fmt.Fprintf(&sb, "  %s\n", link)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,62 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var (
+		region, token string
+		replicas      int64
+		ran           bool
+	)
+	deploy := &Command{
+		Use:      "deploy",
+		Effects:  []string{"creates a deployment in the target region"},
+		Metadata: map[string]string{"docs": "https://example.com/deploy"},
+		Options: OptionSet{
+			{Flag: "region", Value: StringOf(&region)},
+			{Flag: "replicas", Default: "2", Value: Int64Of(&replicas)},
+			{Flag: "api-token", Envs: []string{"APP_TOKEN"}, Required: true, Value: StringOf(&token)},
+			{Flag: "color", Value: StringOf(new(string))},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			ran = true
+			return nil
+		},
+	}
+	root := &Command{Use: "app", Children: []*Command{deploy}}
+
+	var stdout bytes.Buffer
+	inv := root.Invoke("deploy", "--region", "eu-west-1", "--explain", "api").
+		WithEnviron([]string{"APP_TOKEN=s3cr3t"})
+	inv.Stdout = &stdout
+	if err := inv.Run(); err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if ran {
+		t.Fatal("handler must not run with --explain")
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"Command: app deploy",
+		"Args: api",
+		"--api-token  <redacted>  (env $APP_TOKEN)",
+		"--color                  (unset)",
+		"--region     eu-west-1   (flag)",
+		"--replicas   2           (default)",
+		"- creates a deployment in the target region",
+		"https://example.com/deploy",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("explain output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("explain output leaks a sensitive value:\n%s", out)
+	}
+}
@@ -0,0 +1,75 @@
+package redant
+
+import "slices"
+
+// Feature names an invocation-scoped toggle queried by middleware and
+// handlers, e.g. "trace".
+type Feature string
+
+// EnableFeature turns feature on for the invocation.
+func (inv *Invocation) EnableFeature(feature Feature) {
+	if inv.features == nil {
+		inv.features = make(map[Feature]bool)
+	}
+	inv.features[feature] = true
+}
+
+// DisableFeature turns feature off for the invocation.
+func (inv *Invocation) DisableFeature(feature Feature) {
+	delete(inv.features, feature)
+}
+
+// FeatureEnabled reports whether feature is on for the invocation.
+func (inv *Invocation) FeatureEnabled(feature Feature) bool {
+	return inv.features[feature]
+}
+
+// Features returns the enabled features in sorted order.
+func (inv *Invocation) Features() []Feature {
+	out := make([]Feature, 0, len(inv.features))
+	for f := range inv.features {
+		out = append(out, f)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// FeatureOption returns a boolean option named after feature that enables
+// it for the invocation when set by flag or env. Declare it on the root
+// (or in PersistentOptions) to make the toggle available everywhere.
+func FeatureOption(feature Feature, description string) Option {
+	return Option{
+		Flag:        string(feature),
+		Description: description,
+		Value:       BoolOf(new(bool)),
+		feature:     feature,
+	}
+}
+
+// applyFeatureOptions enables the features of FeatureOption flags that are
+// set. Deeper commands win on duplicate flags.
+func (inv *Invocation) applyFeatureOptions() {
+	if inv.Flags == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		for _, opt := range cmd.localOptions() {
+			if opt.Flag == "" || seen[opt.Flag] {
+				continue
+			}
+			seen[opt.Flag] = true
+			if opt.feature == "" {
+				continue
+			}
+			if f := inv.Flags.Lookup(opt.Flag); f != nil && isTrue(f.Value) {
+				inv.EnableFeature(opt.feature)
+			}
+		}
+	}
+}
+
+func isTrue(v Value) bool {
+	b, err := parseEnvBool(v.String())
+	return err == nil && b
+}
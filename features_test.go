@@ -0,0 +1,71 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestFeatureToggles(t *testing.T) {
+	const trace Feature = "trace"
+
+	tests := []struct {
+		name    string
+		args    []string
+		environ []string
+		want    bool
+	}{
+		{name: "off", args: []string{"run"}},
+		{name: "flag", args: []string{"run", "--trace"}, want: true},
+		{name: "env", args: []string{"run"}, environ: []string{"APP_TRACE=on"}, want: true},
+		{name: "explicit false", args: []string{"run", "--trace=false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromMiddleware, fromHandler bool
+			opt := FeatureOption(trace, "Trace execution.")
+			opt.Envs = []string{"APP_TRACE"}
+			root := &Command{
+				Use:     "app",
+				Options: OptionSet{opt},
+				Middleware: func(next HandlerFunc) HandlerFunc {
+					return func(ctx context.Context, inv *Invocation) error {
+						fromMiddleware = inv.FeatureEnabled(trace)
+						return next(ctx, inv)
+					}
+				},
+			}
+			root.Children = append(root.Children, &Command{
+				Use: "run",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					fromHandler = inv.FeatureEnabled(trace)
+					return nil
+				},
+			})
+
+			inv := root.Invoke(tt.args...).WithEnviron(tt.environ)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fromMiddleware != tt.want || fromHandler != tt.want {
+				t.Fatalf("feature enabled: middleware=%v handler=%v, want %v", fromMiddleware, fromHandler, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatureToggleAPI(t *testing.T) {
+	inv := (&Command{Use: "app"}).Invoke()
+	inv.EnableFeature("b")
+	inv.EnableFeature("a")
+	if got := inv.Features(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("Features() = %v", got)
+	}
+	inv.DisableFeature("a")
+	if inv.FeatureEnabled("a") || !inv.FeatureEnabled("b") {
+		t.Fatalf("unexpected features: %v", inv.Features())
+	}
+}
@@ -0,0 +1,58 @@
+package redant
+
+import (
+	"cmp"
+	"slices"
+)
+
+// FlagOrder is the order in which the options of each command are listed
+// in help, --list-flags and completions. See Command.FlagOrder.
+type FlagOrder int
+
+const (
+	// FlagOrderAlphabetical sorts options by flag, or env, name.
+	FlagOrderAlphabetical FlagOrder = iota
+	// FlagOrderDeclaration keeps options in the order they are declared.
+	FlagOrderDeclaration
+	// FlagOrderCategory sorts options by Category, uncategorized ones
+	// first, then by name.
+	FlagOrderCategory
+	// FlagOrderRequiredFirst lists required options before optional ones,
+	// each sorted by name.
+	FlagOrderRequiredFirst
+)
+
+// sortOptions orders opts in place. Options already in order are left
+// untouched, so that initializing an initialized tree does not write to it.
+func (o FlagOrder) sortOptions(opts OptionSet) {
+	var compare func(a, b Option) int
+	switch o {
+	case FlagOrderDeclaration:
+		return
+	case FlagOrderCategory:
+		compare = func(a, b Option) int {
+			return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.name(), b.name()))
+		}
+	case FlagOrderRequiredFirst:
+		compare = func(a, b Option) int {
+			return cmp.Or(-compareBool(a.Required, b.Required), cmp.Compare(a.name(), b.name()))
+		}
+	default:
+		compare = func(a, b Option) int { return cmp.Compare(a.name(), b.name()) }
+	}
+	if !slices.IsSortedFunc(opts, compare) {
+		slices.SortStableFunc(opts, compare)
+	}
+}
+
+// compareBool orders false before true.
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
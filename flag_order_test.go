@@ -0,0 +1,44 @@
+package redant
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFlagOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order FlagOrder
+		want  []string
+	}{
+		{name: "alphabetical", order: FlagOrderAlphabetical, want: []string{"APP_TOKEN", "dry-run", "output", "region", "zone"}},
+		{name: "declaration", order: FlagOrderDeclaration, want: []string{"zone", "region", "output", "APP_TOKEN", "dry-run"}},
+		{name: "category", order: FlagOrderCategory, want: []string{"APP_TOKEN", "dry-run", "region", "zone", "output"}},
+		{name: "required first", order: FlagOrderRequiredFirst, want: []string{"region", "zone", "APP_TOKEN", "dry-run", "output"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := &Command{
+				Use: "get",
+				Options: OptionSet{
+					{Flag: "zone", Category: "Location", Required: true, Value: StringOf(new(string))},
+					{Flag: "region", Category: "Location", Required: true, Value: StringOf(new(string))},
+					{Flag: "output", Category: "Output", Value: StringOf(new(string))},
+					{Envs: []string{"APP_TOKEN"}, Value: StringOf(new(string))},
+					{Flag: "dry-run", Value: BoolOf(new(bool))},
+				},
+			}
+			root := &Command{Use: "app", FlagOrder: tt.order, Children: []*Command{get}}
+			if err := root.Lint(); err != nil {
+				t.Fatalf("lint: %v", err)
+			}
+			var got []string
+			for _, opt := range get.Options {
+				got = append(got, opt.name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("options %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v3"
 )
 
@@ -25,15 +24,15 @@ type NoOptDefValuer interface {
 	NoOptDefValue() string
 }
 
-// Validator is a wrapper around a pflag.Value that allows for validation
+// Validator is a wrapper around a Value that allows for validation
 // of the value after or before it has been set.
-type Validator[T pflag.Value] struct {
+type Validator[T Value] struct {
 	Value T
 	// validate is called after the value is set.
 	validate func(T) error
 }
 
-func Validate[T pflag.Value](opt T, validate func(value T) error) *Validator[T] {
+func Validate[T Value](opt T, validate func(value T) error) *Validator[T] {
 	return &Validator[T]{Value: opt, validate: validate}
 }
 
@@ -79,7 +78,7 @@ func (i *Validator[T]) UnmarshalJSON(b []byte) error {
 	return json.Unmarshal(b, i.Value)
 }
 
-func (i *Validator[T]) Underlying() pflag.Value { return i.Value }
+func (i *Validator[T]) Underlying() Value { return i.Value }
 
 // values.go contains a standard set of value types that can be used as
 // Option Values.
@@ -192,11 +191,11 @@ func (String) Type() string {
 }
 
 var (
-	_ pflag.SliceValue = &StringArray{}
-	_ pflag.Value      = &StringArray{}
+	_ SliceValue = &StringArray{}
+	_ Value      = &StringArray{}
 )
 
-// StringArray is a slice of strings that implements pflag.Value and pflag.SliceValue.
+// StringArray is a slice of strings that implements Value and SliceValue.
 type StringArray []string
 
 func StringArrayOf(ss *[]string) *StringArray {
@@ -464,7 +463,7 @@ func (s *Struct[T]) UnmarshalJSON(b []byte) error {
 	return json.Unmarshal(b, &s.Value)
 }
 
-// DiscardValue does nothing but implements the pflag.Value interface.
+// DiscardValue does nothing but implements the Value interface.
 // It's useful in cases where you want to accept an option, but access the
 // underlying value directly instead of through the Option methods.
 var DiscardValue discardValue
@@ -488,7 +487,7 @@ func (discardValue) UnmarshalJSON([]byte) error {
 }
 
 // jsonValue is intentionally not exported. It is just used to store the raw JSON
-// data for a value to defer it's unmarshal. It implements the pflag.Value to be
+// data for a value to defer it's unmarshal. It implements the Value to be
 // usable in an Option.
 type jsonValue json.RawMessage
 
@@ -512,11 +511,14 @@ func (j *jsonValue) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-var _ pflag.Value = (*Enum)(nil)
+var _ Value = (*Enum)(nil)
 
 type Enum struct {
 	Choices []string
-	Value   *string
+	// ChoicesFunc, if set, computes the choices lazily (e.g. configured
+	// profiles) and takes precedence over Choices.
+	ChoicesFunc func() []string
+	Value       *string
 }
 
 func EnumOf(v *string, choices ...string) *Enum {
@@ -528,18 +530,35 @@ func EnumOf(v *string, choices ...string) *Enum {
 	}
 }
 
+// EnumFunc returns an Enum whose choices are computed by fn when needed.
+func EnumFunc(v *string, fn func() []string) *Enum {
+	return &Enum{
+		ChoicesFunc: fn,
+		Value:       v,
+	}
+}
+
+// AllowedChoices returns the effective choices of the enum.
+func (e *Enum) AllowedChoices() []string {
+	if e.ChoicesFunc != nil {
+		return e.ChoicesFunc()
+	}
+	return e.Choices
+}
+
 func (e *Enum) Set(v string) error {
-	for _, c := range e.Choices {
+	choices := e.AllowedChoices()
+	for _, c := range choices {
 		if strings.EqualFold(v, c) {
 			*e.Value = v
 			return nil
 		}
 	}
-	return fmt.Errorf("invalid choice: %s, should be one of %v", v, e.Choices)
+	return fmt.Errorf("invalid choice: %s, should be one of %v", v, choices)
 }
 
 func (e *Enum) Type() string {
-	return fmt.Sprintf("enum[%v]", strings.Join(e.Choices, "\\|"))
+	return fmt.Sprintf("enum[%v]", strings.Join(e.AllowedChoices(), "\\|"))
 }
 
 func (e *Enum) String() string {
@@ -614,23 +633,35 @@ func (Regexp) Type() string {
 }
 
 var (
-	_ pflag.SliceValue = (*EnumArray)(nil)
-	_ pflag.Value      = (*EnumArray)(nil)
+	_ SliceValue = (*EnumArray)(nil)
+	_ Value      = (*EnumArray)(nil)
 )
 
 type EnumArray struct {
 	Choices []string
-	Value   *[]string
+	// ChoicesFunc, if set, computes the choices lazily and takes
+	// precedence over Choices.
+	ChoicesFunc func() []string
+	Value       *[]string
+}
+
+// AllowedChoices returns the effective choices of the enum array.
+func (e *EnumArray) AllowedChoices() []string {
+	if e.ChoicesFunc != nil {
+		return e.ChoicesFunc()
+	}
+	return e.Choices
 }
 
 func (e *EnumArray) Append(s string) error {
-	for _, c := range e.Choices {
+	choices := e.AllowedChoices()
+	for _, c := range choices {
 		if strings.EqualFold(s, c) {
 			*e.Value = append(*e.Value, s)
 			return nil
 		}
 	}
-	return fmt.Errorf("invalid choice: %s, should be one of %v", s, e.Choices)
+	return fmt.Errorf("invalid choice: %s, should be one of %v", s, choices)
 }
 
 func (e *EnumArray) GetSlice() []string {
@@ -638,16 +669,17 @@ func (e *EnumArray) GetSlice() []string {
 }
 
 func (e *EnumArray) Replace(ss []string) error {
+	choices := e.AllowedChoices()
 	for _, s := range ss {
 		found := false
-		for _, c := range e.Choices {
+		for _, c := range choices {
 			if strings.EqualFold(s, c) {
 				found = true
 				break
 			}
 		}
 		if !found {
-			return fmt.Errorf("invalid choice: %s, should be one of %v", s, e.Choices)
+			return fmt.Errorf("invalid choice: %s, should be one of %v", s, choices)
 		}
 	}
 	*e.Value = ss
@@ -677,7 +709,7 @@ func (e *EnumArray) String() string {
 }
 
 func (e *EnumArray) Type() string {
-	return fmt.Sprintf("enum-array[%v]", strings.Join(e.Choices, "\\|"))
+	return fmt.Sprintf("enum-array[%v]", strings.Join(e.AllowedChoices(), "\\|"))
 }
 
 func EnumArrayOf(v *[]string, choices ...string) *EnumArray {
@@ -687,3 +719,12 @@ func EnumArrayOf(v *[]string, choices ...string) *EnumArray {
 		Value:   v,
 	}
 }
+
+// EnumArrayFunc returns an EnumArray whose choices are computed by fn when
+// needed.
+func EnumArrayFunc(v *[]string, fn func() []string) *EnumArray {
+	return &EnumArray{
+		ChoicesFunc: fn,
+		Value:       v,
+	}
+}
@@ -261,6 +261,39 @@ func TestEnumValue(t *testing.T) {
 	}
 }
 
+func TestEnumFuncValue(t *testing.T) {
+	profiles := []string{"dev"}
+	calls := 0
+	var v string
+	e := EnumFunc(&v, func() []string {
+		calls++
+		return profiles
+	})
+	if calls != 0 {
+		t.Fatalf("choices evaluated eagerly")
+	}
+
+	if err := e.Set("prod"); err == nil {
+		t.Fatal("expected error before prod is configured")
+	}
+	profiles = append(profiles, "prod")
+	if err := e.Set("prod"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got := e.Type(); got != "enum[dev\\|prod]" {
+		t.Errorf("Type() = %q", got)
+	}
+
+	var arr []string
+	ea := EnumArrayFunc(&arr, func() []string { return profiles })
+	if err := ea.Set("dev,prod"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got := ea.AllowedChoices(); len(got) != 2 {
+		t.Errorf("AllowedChoices() = %v", got)
+	}
+}
+
 func TestEnumArrayValue(t *testing.T) {
 	choices := []string{"read", "write", "delete"}
 
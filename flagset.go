@@ -0,0 +1,416 @@
+package redant
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value is implemented by option and argument values, see values in
+// flags.go. It has the method set of spf13/pflag's Value, so existing
+// Value implementations can be used as is.
+type Value interface {
+	String() string
+	Set(string) error
+	Type() string
+}
+
+// SliceValue is implemented by array values that support appending and
+// replacing items, like pflag's SliceValue.
+type SliceValue interface {
+	Append(string) error
+	Replace([]string) error
+	GetSlice() []string
+}
+
+// ErrHelp is returned by FlagSet.Parse when -h or --help is given but not
+// defined, e.g. for commands with DisableGlobalFlags.
+var ErrHelp = errors.New("help requested")
+
+// Flag is the state of a flag in a FlagSet. Its fields are those of
+// pflag's Flag.
+type Flag struct {
+	// Name is the flag as given after "--", normalized by the FlagSet.
+	Name string
+	// Shorthand is the one-letter flag given after a single "-".
+	Shorthand string
+	// Usage is the description of the flag.
+	Usage string
+	Value Value
+	// DefValue is the default value as text.
+	DefValue string
+	// Changed reports whether the value was set on the command line or
+	// from the environment.
+	Changed bool
+	// NoOptDefVal is the value of the flag when given without one, e.g.
+	// "true" for --verbose.
+	NoOptDefVal string
+	// Deprecated, when set, is printed when the flag is used.
+	Deprecated string
+	Hidden     bool
+	// ShorthandDeprecated, when set, is printed when the shorthand is
+	// used.
+	ShorthandDeprecated string
+	Annotations         map[string][]string
+}
+
+// FlagSet is the set of flags of an invocation, parsed without spf13/pflag.
+// Its methods follow those of pflag's FlagSet, so code reading
+// Invocation.Flags keeps working. Flags are visited in name order.
+type FlagSet struct {
+	name       string
+	formal     map[string]*Flag
+	shorthands map[byte]*Flag
+	// actual holds the flags set with Set, e.g. while parsing.
+	actual map[string]*Flag
+	args   []string
+	// argsLenAtDash is the length of args when "--" was found, or -1.
+	argsLenAtDash int
+	normalize     func(name string) string
+	output        io.Writer
+}
+
+// NewFlagSet returns an empty flag set called name.
+func NewFlagSet(name string) *FlagSet {
+	return &FlagSet{name: name, argsLenAtDash: -1}
+}
+
+// Name returns the name of the flag set.
+func (f *FlagSet) Name() string { return f.name }
+
+// SetOutput sets where deprecation warnings are written, os.Stderr by
+// default.
+func (f *FlagSet) SetOutput(w io.Writer) { f.output = w }
+
+// Output returns where deprecation warnings are written.
+func (f *FlagSet) Output() io.Writer {
+	if f.output == nil {
+		return os.Stderr
+	}
+	return f.output
+}
+
+// SetNormalizeFunc sets the function translating flag names, both of the
+// flags already added and of those looked up or parsed later, e.g. to
+// match them regardless of case.
+func (f *FlagSet) SetNormalizeFunc(normalize func(name string) string) {
+	f.normalize = normalize
+	for _, name := range slices.Collect(maps.Keys(f.formal)) {
+		flag := f.formal[name]
+		nname := f.normalizeName(name)
+		if nname == name {
+			continue
+		}
+		flag.Name = nname
+		delete(f.formal, name)
+		f.formal[nname] = flag
+		if _, ok := f.actual[name]; ok {
+			delete(f.actual, name)
+			f.actual[nname] = flag
+		}
+	}
+}
+
+func (f *FlagSet) normalizeName(name string) string {
+	if f.normalize == nil {
+		return name
+	}
+	return f.normalize(name)
+}
+
+// AddFlag adds flag to the set. It panics if the name or shorthand is
+// already used, as pflag does.
+func (f *FlagSet) AddFlag(flag *Flag) {
+	name := f.normalizeName(flag.Name)
+	if _, ok := f.formal[name]; ok {
+		panic(fmt.Sprintf("%s flag redefined: %s", f.name, flag.Name))
+	}
+	if len(flag.Shorthand) > 1 {
+		panic(fmt.Sprintf("%q shorthand is more than one ASCII character", flag.Shorthand))
+	}
+	if flag.Shorthand != "" {
+		if used, ok := f.shorthands[flag.Shorthand[0]]; ok {
+			panic(fmt.Sprintf("unable to redefine %q shorthand in %q flagset: it's already used for %q flag", flag.Shorthand, f.name, used.Name))
+		}
+	}
+
+	if f.formal == nil {
+		f.formal = make(map[string]*Flag)
+	}
+	flag.Name = name
+	f.formal[name] = flag
+	if flag.Shorthand != "" {
+		if f.shorthands == nil {
+			f.shorthands = make(map[byte]*Flag)
+		}
+		f.shorthands[flag.Shorthand[0]] = flag
+	}
+}
+
+// remove removes the flag called name and its shorthand, so that a flag of
+// a deeper command can replace it.
+func (f *FlagSet) remove(name string) {
+	name = f.normalizeName(name)
+	flag, ok := f.formal[name]
+	if !ok {
+		return
+	}
+	delete(f.formal, name)
+	delete(f.actual, name)
+	if flag.Shorthand != "" && f.shorthands[flag.Shorthand[0]] == flag {
+		delete(f.shorthands, flag.Shorthand[0])
+	}
+}
+
+// Lookup returns the flag called name, or nil.
+func (f *FlagSet) Lookup(name string) *Flag {
+	return f.formal[f.normalizeName(name)]
+}
+
+// ShorthandLookup returns the flag with the one-letter shorthand name, or
+// nil.
+func (f *FlagSet) ShorthandLookup(name string) *Flag {
+	if len(name) != 1 {
+		return nil
+	}
+	return f.shorthands[name[0]]
+}
+
+// VisitAll calls fn for each flag in name order.
+func (f *FlagSet) VisitAll(fn func(*Flag)) {
+	visitSorted(f.formal, fn)
+}
+
+// Visit calls fn for each flag set with Set, e.g. on the command line, in
+// name order. Flags set from the environment only are not visited.
+func (f *FlagSet) Visit(fn func(*Flag)) {
+	visitSorted(f.actual, fn)
+}
+
+// visitSorted calls fn for the flags in name order. Adding or removing
+// flags from fn does not affect the visit.
+func visitSorted(flags map[string]*Flag, fn func(*Flag)) {
+	for _, name := range slices.Sorted(maps.Keys(flags)) {
+		fn(flags[name])
+	}
+}
+
+// Set sets the value of the flag called name and marks it changed.
+func (f *FlagSet) Set(name, value string) error {
+	flag, ok := f.formal[f.normalizeName(name)]
+	if !ok {
+		return fmt.Errorf("no such flag -%s", name)
+	}
+	if err := flag.Value.Set(value); err != nil {
+		flagName := "--" + flag.Name
+		if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
+			flagName = "-" + flag.Shorthand + ", " + flagName
+		}
+		return fmt.Errorf("invalid argument %q for %q flag: %w", value, flagName, err)
+	}
+
+	if !flag.Changed {
+		if f.actual == nil {
+			f.actual = make(map[string]*Flag)
+		}
+		f.actual[flag.Name] = flag
+		flag.Changed = true
+	}
+	if flag.Deprecated != "" {
+		_, _ = fmt.Fprintf(f.Output(), "Flag --%s has been deprecated, %s\n", flag.Name, flag.Deprecated)
+	}
+	return nil
+}
+
+// Changed reports whether the flag called name was set.
+func (f *FlagSet) Changed(name string) bool {
+	flag := f.Lookup(name)
+	return flag != nil && flag.Changed
+}
+
+// Args returns the args left after parsing flags.
+func (f *FlagSet) Args() []string { return f.args }
+
+// NArg returns the number of args left after parsing flags.
+func (f *FlagSet) NArg() int { return len(f.args) }
+
+// Arg returns the i'th arg left after parsing flags, or "".
+func (f *FlagSet) Arg(i int) string {
+	if i < 0 || i >= len(f.args) {
+		return ""
+	}
+	return f.args[i]
+}
+
+// ArgsLenAtDash returns the number of args found before "--" while
+// parsing, or -1 when there was none.
+func (f *FlagSet) ArgsLenAtDash() int { return f.argsLenAtDash }
+
+// Parse parses the flags in args, setting them with Set. Flags and args
+// may be interspersed; args after "--" are never parsed as flags.
+func (f *FlagSet) Parse(args []string) error {
+	return f.ParseAll(args, func(flag *Flag, value string) error {
+		return f.Set(flag.Name, value)
+	})
+}
+
+// ParseAll parses the flags in args like Parse, calling fn for each flag
+// and its value instead of Set.
+func (f *FlagSet) ParseAll(args []string, fn func(flag *Flag, value string) error) error {
+	f.args = make([]string, 0, len(args))
+	f.argsLenAtDash = -1
+	for len(args) > 0 {
+		s := args[0]
+		args = args[1:]
+		if len(s) < 2 || s[0] != '-' {
+			f.args = append(f.args, s)
+			continue
+		}
+
+		var err error
+		switch {
+		case s == "--":
+			f.argsLenAtDash = len(f.args)
+			f.args = append(f.args, args...)
+			return nil
+		case s[1] == '-':
+			args, err = f.parseLong(s, args, fn)
+		default:
+			args, err = f.parseShorts(s, args, fn)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLong parses the "--name" or "--name=value" arg s, taking the value
+// from args when needed, and returns the remaining args.
+func (f *FlagSet) parseLong(s string, args []string, fn func(*Flag, string) error) ([]string, error) {
+	name := s[2:]
+	if name == "" || name[0] == '-' || name[0] == '=' {
+		return args, fmt.Errorf("bad flag syntax: %s", s)
+	}
+
+	name, value, hasValue := strings.Cut(name, "=")
+	flag, ok := f.formal[f.normalizeName(name)]
+	if !ok {
+		if name == "help" {
+			return args, ErrHelp
+		}
+		return args, fmt.Errorf("unknown flag: --%s", name)
+	}
+
+	switch {
+	case hasValue:
+	case flag.NoOptDefVal != "":
+		value = flag.NoOptDefVal
+	case len(args) > 0:
+		value, args = args[0], args[1:]
+	default:
+		return args, fmt.Errorf("flag needs an argument: --%s", name)
+	}
+	return args, fn(flag, value)
+}
+
+// parseShorts parses the arg s holding one or more shorthands, e.g. "-v",
+// "-vvv", "-n5" or "-n=5", and returns the remaining args.
+func (f *FlagSet) parseShorts(s string, args []string, fn func(*Flag, string) error) ([]string, error) {
+	shorthands := s[1:]
+	for len(shorthands) > 0 {
+		c := shorthands[0]
+		flag, ok := f.shorthands[c]
+		if !ok {
+			if c == 'h' {
+				return args, ErrHelp
+			}
+			return args, fmt.Errorf("unknown shorthand flag: %q in -%s", rune(c), shorthands)
+		}
+
+		var value string
+		switch {
+		case len(shorthands) > 2 && shorthands[1] == '=':
+			value, shorthands = shorthands[2:], ""
+		case flag.NoOptDefVal != "":
+			value, shorthands = flag.NoOptDefVal, shorthands[1:]
+		case len(shorthands) > 1:
+			value, shorthands = shorthands[1:], ""
+		case len(args) > 0:
+			value, args, shorthands = args[0], args[1:], ""
+		default:
+			return args, fmt.Errorf("flag needs an argument: %q in -%s", rune(c), shorthands)
+		}
+
+		if flag.ShorthandDeprecated != "" {
+			_, _ = fmt.Fprintf(f.Output(), "Flag shorthand -%s has been deprecated, %s\n", flag.Shorthand, flag.ShorthandDeprecated)
+		}
+		if err := fn(flag, value); err != nil {
+			return args, err
+		}
+	}
+	return args, nil
+}
+
+// typedValue returns the value of the flag called name, parsed with parse
+// from its text once checked to be of type typ, as pflag's getters do.
+func typedValue[T any](f *FlagSet, name, typ string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	flag := f.Lookup(name)
+	if flag == nil {
+		return zero, fmt.Errorf("flag accessed but not defined: %s", name)
+	}
+	if got := flag.Value.Type(); got != typ {
+		return zero, fmt.Errorf("trying to get %s value of flag of type %s", typ, got)
+	}
+	return parse(flag.Value.String())
+}
+
+// GetString returns the value of the string flag called name.
+func (f *FlagSet) GetString(name string) (string, error) {
+	return typedValue(f, name, "string", func(s string) (string, error) { return s, nil })
+}
+
+// GetBool returns the value of the bool flag called name.
+func (f *FlagSet) GetBool(name string) (bool, error) {
+	return typedValue(f, name, "bool", strconv.ParseBool)
+}
+
+// GetInt returns the value of the int flag called name.
+func (f *FlagSet) GetInt(name string) (int, error) {
+	return typedValue(f, name, "int", strconv.Atoi)
+}
+
+// GetInt64 returns the value of the int64 flag called name.
+func (f *FlagSet) GetInt64(name string) (int64, error) {
+	return typedValue(f, name, "int64", func(s string) (int64, error) { return strconv.ParseInt(s, 0, 64) })
+}
+
+// GetFloat64 returns the value of the float64 flag called name.
+func (f *FlagSet) GetFloat64(name string) (float64, error) {
+	return typedValue(f, name, "float64", func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+}
+
+// GetDuration returns the value of the duration flag called name.
+func (f *FlagSet) GetDuration(name string) (time.Duration, error) {
+	return typedValue(f, name, "duration", time.ParseDuration)
+}
+
+// GetStringArray returns the items of the string-array flag called name.
+func (f *FlagSet) GetStringArray(name string) ([]string, error) {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return nil, fmt.Errorf("flag accessed but not defined: %s", name)
+	}
+	slice, ok := flag.Value.(SliceValue)
+	if !ok || flag.Value.Type() != "string-array" {
+		return nil, fmt.Errorf("trying to get string-array value of flag of type %s", flag.Value.Type())
+	}
+	return slice.GetSlice(), nil
+}
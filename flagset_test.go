@@ -0,0 +1,129 @@
+package redant
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlagSetParse(t *testing.T) {
+	newFlagSet := func() (*FlagSet, *string, *bool, *int64, *[]string) {
+		var name string
+		var verbose bool
+		var count int64
+		var tags []string
+		fs := NewFlagSet("app")
+		fs.AddFlag(&Flag{Name: "name", Shorthand: "n", Value: StringOf(&name)})
+		fs.AddFlag(&Flag{Name: "verbose", Shorthand: "v", Value: BoolOf(&verbose), NoOptDefVal: "true"})
+		fs.AddFlag(&Flag{Name: "count", Shorthand: "c", Value: Int64Of(&count)})
+		fs.AddFlag(&Flag{Name: "tag", Shorthand: "t", Value: StringArrayOf(&tags)})
+		return fs, &name, &verbose, &count, &tags
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantName    string
+		wantVerbose bool
+		wantCount   int64
+		wantTags    []string
+		wantArgs    []string
+		wantDash    int
+		wantErr     string
+	}{
+		{name: "long with value", args: []string{"--name", "x", "a"}, wantName: "x", wantArgs: []string{"a"}, wantDash: -1},
+		{name: "long with equals", args: []string{"a", "--name=x=y"}, wantName: "x=y", wantArgs: []string{"a"}, wantDash: -1},
+		{name: "no value default", args: []string{"--verbose", "a"}, wantVerbose: true, wantArgs: []string{"a"}, wantDash: -1},
+		{name: "explicit bool", args: []string{"--verbose=false"}, wantArgs: []string{}, wantDash: -1},
+		{name: "combined shorthands", args: []string{"-vn", "x"}, wantName: "x", wantVerbose: true, wantArgs: []string{}, wantDash: -1},
+		{name: "attached short value", args: []string{"-c5", "-n=x"}, wantName: "x", wantCount: 5, wantArgs: []string{}, wantDash: -1},
+		{name: "repeated array", args: []string{"-t", "a", "--tag=b,c"}, wantTags: []string{"a", "b", "c"}, wantArgs: []string{}, wantDash: -1},
+		{name: "double dash", args: []string{"a", "--", "--name", "-v"}, wantArgs: []string{"a", "--name", "-v"}, wantDash: 1},
+		{name: "single dash is an arg", args: []string{"-"}, wantArgs: []string{"-"}, wantDash: -1},
+		{name: "unknown long", args: []string{"--nope"}, wantErr: "unknown flag: --nope"},
+		{name: "unknown short", args: []string{"-vx"}, wantErr: `unknown shorthand flag: 'x' in -x`},
+		{name: "missing long value", args: []string{"--name"}, wantErr: "flag needs an argument: --name"},
+		{name: "missing short value", args: []string{"-vn"}, wantErr: `flag needs an argument: 'n' in -n`},
+		{name: "invalid value", args: []string{"-c", "many"}, wantErr: `invalid argument "many" for "-c, --count" flag`},
+		{name: "bad syntax", args: []string{"---name"}, wantErr: "bad flag syntax: ---name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, name, verbose, count, tags := newFlagSet()
+			err := fs.Parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if *name != tt.wantName || *verbose != tt.wantVerbose || *count != tt.wantCount || !slices.Equal(*tags, tt.wantTags) {
+				t.Fatalf("name=%q verbose=%v count=%d tags=%q", *name, *verbose, *count, *tags)
+			}
+			if !slices.Equal(fs.Args(), tt.wantArgs) || fs.ArgsLenAtDash() != tt.wantDash {
+				t.Fatalf("args = %q (dash at %d), want %q (dash at %d)", fs.Args(), fs.ArgsLenAtDash(), tt.wantArgs, tt.wantDash)
+			}
+		})
+	}
+}
+
+func TestFlagSetHelpNotDefined(t *testing.T) {
+	for _, arg := range []string{"--help", "-h"} {
+		if err := NewFlagSet("app").Parse([]string{arg}); !errors.Is(err, ErrHelp) {
+			t.Errorf("%s: err = %v, want ErrHelp", arg, err)
+		}
+	}
+}
+
+func TestFlagSetState(t *testing.T) {
+	var region, old string
+	var timeout time.Duration
+	stderr := &bytes.Buffer{}
+	fs := NewFlagSet("app")
+	fs.SetOutput(stderr)
+	fs.AddFlag(&Flag{Name: "region", Shorthand: "r", Value: StringOf(&region)})
+	fs.AddFlag(&Flag{Name: "old", Value: StringOf(&old), Deprecated: "use --region"})
+	fs.AddFlag(&Flag{Name: "timeout", Value: DurationOf(&timeout)})
+	fs.Lookup("timeout").Changed = true // set from the environment
+	fs.SetNormalizeFunc(strings.ToLower)
+
+	if err := fs.Parse([]string{"--REGION", "eu", "--old", "x"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var visited []string
+	fs.Visit(func(f *Flag) { visited = append(visited, f.Name) })
+	if want := []string{"old", "region"}; !slices.Equal(visited, want) {
+		t.Errorf("visited %q, want %q", visited, want)
+	}
+	if !fs.Changed("Timeout") || fs.Changed("nope") {
+		t.Error("Changed does not follow the flags")
+	}
+	if got, err := fs.GetString("region"); got != "eu" || err != nil {
+		t.Errorf("GetString = %q, %v", got, err)
+	}
+	if _, err := fs.GetBool("region"); err == nil || err.Error() != "trying to get bool value of flag of type string" {
+		t.Errorf("GetBool of a string flag: %v", err)
+	}
+	if _, err := fs.GetDuration("nope"); err == nil || err.Error() != "flag accessed but not defined: nope" {
+		t.Errorf("GetDuration of an unknown flag: %v", err)
+	}
+	if want := "Flag --old has been deprecated, use --region\n"; stderr.String() != want {
+		t.Errorf("output = %q, want %q", stderr.String(), want)
+	}
+
+	fs.remove("region")
+	if fs.Lookup("region") != nil || fs.ShorthandLookup("r") != nil {
+		t.Fatal("remove left the flag or its shorthand")
+	}
+	var zone string
+	fs.AddFlag(&Flag{Name: "zone", Shorthand: "r", Value: StringOf(&zone)})
+	if err := fs.Parse([]string{"-r", "a"}); err != nil || zone != "a" {
+		t.Fatalf("shorthand reused after remove: zone=%q err=%v", zone, err)
+	}
+}
@@ -4,20 +4,16 @@ import (
 	"bufio"
 	"context"
 	_ "embed"
-	"flag"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
-	"sync"
 	"text/tabwriter"
 	"text/template"
 
 	"github.com/mitchellh/go-wordwrap"
-	"github.com/muesli/termenv"
-	"golang.org/x/term"
-
-	"github.com/pubgo/redant/internal/pretty"
 )
 
 //go:embed help.tpl
@@ -27,10 +23,13 @@ type optionGroup struct {
 	Name        string
 	Description string
 	Options     OptionSet
+	// global marks the group of the root command's options.
+	global bool
 }
 
 // getOptionGroupsByCommand returns option groups organized by command hierarchy
-func getOptionGroupsByCommand(cmd *Command) []optionGroup {
+// Hidden options are only included when showHidden is set.
+func getOptionGroupsByCommand(cmd *Command, showHidden bool) []optionGroup {
 	var groups []optionGroup
 
 	// Collect commands in hierarchy order (root to current)
@@ -43,13 +42,13 @@ func getOptionGroupsByCommand(cmd *Command) []optionGroup {
 
 	// Create a group for each command that has options
 	for _, c := range commands {
-		if len(c.Options) > 0 {
+		if localOpts := c.localOptions(); len(localOpts) > 0 {
 			// Filter out global flags for non-root commands
 			var opts OptionSet
 			if c.parent == nil {
 				// Root command: show all options as global options
-				for _, opt := range c.Options {
-					if opt.Flag != "" && !opt.Hidden {
+				for _, opt := range localOpts {
+					if opt.name() != "" && (showHidden || !opt.Hidden) {
 						opts = append(opts, opt)
 					}
 				}
@@ -60,8 +59,11 @@ func getOptionGroupsByCommand(cmd *Command) []optionGroup {
 				for _, gf := range globalFlags {
 					globalFlagMap[gf.Flag] = true
 				}
-				for _, opt := range c.Options {
-					if !globalFlagMap[opt.Flag] && opt.Flag != "" && !opt.Hidden {
+				for _, opt := range localOpts {
+					if opt.Flag == "" && len(opt.Envs) == 0 || opt.Hidden && !showHidden {
+						continue
+					}
+					if opt.Flag == "" || !globalFlagMap[opt.Flag] {
 						opts = append(opts, opt)
 					}
 				}
@@ -79,6 +81,7 @@ func getOptionGroupsByCommand(cmd *Command) []optionGroup {
 				groups = append(groups, optionGroup{
 					Name:    groupName,
 					Options: opts,
+					global:  c.parent == nil,
 				})
 			}
 		}
@@ -87,23 +90,9 @@ func getOptionGroupsByCommand(cmd *Command) []optionGroup {
 	return groups
 }
 
-func ttyWidth() int {
-	width, _, err := term.GetSize(0)
-	if err != nil {
-		return 80
-	}
-	return width
-}
-
-// wrapTTY wraps a string to the width of the terminal, or 80 no terminal
-// is detected.
-func wrapTTY(s string) string {
-	return wordwrap.WrapString(s, uint(ttyWidth()))
-}
-
-// indent indents a string with the given number of spaces and wraps it to terminal width
-func indent(body string, spaces int) string {
-	twidth := ttyWidth()
+// indentWidth indents a string with the given number of spaces and wraps it
+// to twidth.
+func indentWidth(body string, spaces, twidth int) string {
 	spacing := strings.Repeat(" ", spaces)
 	wrapLim := twidth - len(spacing)
 	body = wordwrap.WrapString(body, uint(wrapLim))
@@ -117,59 +106,34 @@ func indent(body string, spaces int) string {
 	return sb.String()
 }
 
-var (
-	helpColorProfile termenv.Profile
-	helpColorOnce    sync.Once
-)
-
-// Color returns a color for the given string.
-func helpColor(s string) termenv.Color {
-	helpColorOnce.Do(func() {
-		helpColorProfile = termenv.NewOutput(os.Stdout).ColorProfile()
-		if flag.Lookup("test.v") != nil {
-			// Use a consistent colorless profile in tests so that results
-			// are deterministic.
-			helpColorProfile = termenv.Ascii
-		}
-	})
-	return helpColorProfile.Color(s)
-}
-
-// prettyHeader formats a header string with consistent styling.
-// It uppercases the text, adds a colon, and applies the header color.
-func prettyHeader(s string) string {
-	headerFg := pretty.FgColor(helpColor("#337CA0"))
-	s = strings.ToUpper(s)
-	txt := pretty.String(s, ":")
-	headerFg.Format(txt)
-	return txt.String()
-}
-
-var defaultHelpTemplate = func() *template.Template {
-	optionFg := pretty.FgColor(
-		helpColor("#04A777"),
-	)
+// helpTemplate returns the help template wrapping text to width, coloring
+// it with p and translating its labels with localize. Hidden commands and
+// options are listed when showHidden is set.
+func helpTemplate(width int, showHidden bool, localize func(string) string, p palette) *template.Template {
 	return template.Must(
 		template.New("usage").Funcs(
 			template.FuncMap{
 				"wrapTTY": func(s string) string {
-					return wrapTTY(s)
+					return wordwrap.WrapString(s, uint(width))
 				},
 				"trimNewline": func(s string) string {
 					return strings.TrimSuffix(s, "\n")
 				},
-				"keyword": func(s string) string {
-					txt := pretty.String(s)
-					optionFg.Format(txt)
-					return txt.String()
+				"keyword":      p.keyword,
+				"prettyHeader": p.header,
+				"tr":           localize,
+				"groupHeader": func(group optionGroup) string {
+					if group.global {
+						return localize("Global Options")
+					}
+					return fmt.Sprintf(localize("%s Options"), group.Name)
 				},
-				"prettyHeader": prettyHeader,
 				"typeHelper": func(opt *Option) string {
 					switch v := opt.Value.(type) {
 					case *Enum:
-						return strings.Join(v.Choices, "|")
+						return strings.Join(v.AllowedChoices(), "|")
 					case *EnumArray:
-						return fmt.Sprintf("[%s]", strings.Join(v.Choices, "|"))
+						return fmt.Sprintf("[%s]", strings.Join(v.AllowedChoices(), "|"))
 					default:
 						return v.Type()
 					}
@@ -178,7 +142,7 @@ var defaultHelpTemplate = func() *template.Template {
 					return strings.Join(s, ", ")
 				},
 				"indent": func(body string, spaces int) string {
-					twidth := ttyWidth()
+					twidth := width
 
 					spacing := strings.Repeat(" ", spaces)
 
@@ -201,13 +165,23 @@ var defaultHelpTemplate = func() *template.Template {
 				"rootCommandName": func(cmd *Command) string {
 					return strings.Split(cmd.FullName(), " ")[0]
 				},
+				"formatExamples": func(examples []Example) string {
+					return formatExamples(examples, width)
+				},
+				"formatLong": func(long string) string {
+					return formatLong(long, 2, width, p)
+				},
+				"formatSeeAlso": func(cmd *Command) string {
+					return formatSeeAlso(cmd, p)
+				},
 				"formatSubcommand": func(cmd *Command) string {
 					// Minimize padding by finding the longest neighboring name.
-					maxNameLength := len(cmd.Name())
+					label := subcommandLabel(cmd)
+					maxNameLength := len(label)
 					if parent := cmd.parent; parent != nil {
 						for _, c := range parent.Children {
-							if len(c.Name()) > maxNameLength {
-								maxNameLength = len(c.Name())
+							if n := len(subcommandLabel(c)); n > maxNameLength {
+								maxNameLength = n
 							}
 						}
 					}
@@ -215,17 +189,23 @@ var defaultHelpTemplate = func() *template.Template {
 					var sb strings.Builder
 					_, _ = fmt.Fprintf(
 						&sb, "%s%s%s",
-						strings.Repeat(" ", 4), cmd.Name(), strings.Repeat(" ", maxNameLength-len(cmd.Name())+4),
+						strings.Repeat(" ", 4), label, strings.Repeat(" ", maxNameLength-len(label)+4),
 					)
 
 					// This is the point at which indentation begins if there's a
 					// next line.
 					descStart := sb.Len()
 
-					twidth := ttyWidth()
+					twidth := width
+
+					short := cmd.Short
+					if parent := cmd.parent; parent != nil && parent.DefaultChild != "" &&
+						parent.children()[parent.DefaultChild] == cmd {
+						short = strings.TrimSpace(short + " (" + localize("default") + ")")
+					}
 
 					for i, line := range strings.Split(
-						wordwrap.WrapString(cmd.Short, uint(twidth-descStart)), "\n",
+						wordwrap.WrapString(short, uint(twidth-descStart)), "\n",
 					) {
 						if i > 0 {
 							_, _ = sb.WriteString(strings.Repeat(" ", descStart))
@@ -243,16 +223,16 @@ var defaultHelpTemplate = func() *template.Template {
 				"formatGroupDescription": func(s string) string {
 					s = strings.ReplaceAll(s, "\n", "")
 					s = s + "\n"
-					s = wrapTTY(s)
+					s = wordwrap.WrapString(s, uint(width))
 					return s
 				},
 				"visibleChildren": func(cmd *Command) []*Command {
 					return filterSlice(cmd.Children, func(c *Command) bool {
-						return !c.Hidden
+						return showHidden || !c.Hidden
 					})
 				},
 				"optionGroups": func(cmd *Command) []optionGroup {
-					return getOptionGroupsByCommand(cmd)
+					return getOptionGroupsByCommand(cmd, showHidden)
 				},
 				"envName": func(opt Option) string {
 					if len(opt.Envs) > 0 {
@@ -265,6 +245,10 @@ var defaultHelpTemplate = func() *template.Template {
 					}
 					return ""
 				},
+				"deprecationSchedule": deprecationSchedule,
+				"optionNotes": func(opt Option) string {
+					return formatOptionNotes(opt, localize)
+				},
 				"isDeprecated": func(opt Option) bool {
 					return opt.Deprecated != ""
 				},
@@ -285,11 +269,10 @@ var defaultHelpTemplate = func() *template.Template {
 						argName = fmt.Sprintf("arg%d", index+1)
 					}
 
-					// Format arg name with color (using the keyword function from template)
-					argNameTxt := pretty.String(argName)
-					optionFg := pretty.FgColor(helpColor("#04A777"))
-					optionFg.Format(argNameTxt)
-					argNameColored := argNameTxt.String()
+					argNameColored := p.keyword(argName)
+					if arg.Variadic {
+						argNameColored += "..."
+					}
 					_, _ = sb.WriteString("    ")
 					_, _ = sb.WriteString(argNameColored)
 
@@ -299,25 +282,25 @@ var defaultHelpTemplate = func() *template.Template {
 						_, _ = fmt.Fprintf(&sb, " %s", argType)
 					}
 
-					// Add default and required info
-					if arg.Default != "" || arg.Required {
-						_, _ = sb.WriteString(" (")
-						if arg.Default != "" {
-							_, _ = fmt.Fprintf(&sb, "default: %s", arg.Default)
-						}
-						if arg.Default != "" && arg.Required {
-							_, _ = sb.WriteString(", ")
-						}
-						if arg.Required {
-							_, _ = sb.WriteString("required")
-						}
-						_, _ = sb.WriteString(")")
+					// Add default, required and alias info
+					var notes []string
+					if arg.Default != "" {
+						notes = append(notes, localize("default")+": "+arg.Default)
+					}
+					if arg.Required {
+						notes = append(notes, localize("required"))
+					}
+					if len(arg.Aliases) > 0 {
+						notes = append(notes, localize("aliases")+": "+strings.Join(arg.Aliases, ", "))
+					}
+					if len(notes) > 0 {
+						_, _ = fmt.Fprintf(&sb, " (%s)", strings.Join(notes, ", "))
 					}
 
 					// Add description
 					if arg.Description != "" {
 						_, _ = sb.WriteString("\n")
-						desc := indent(arg.Description, 10)
+						desc := indentWidth(arg.Description, 10, width)
 						_, _ = sb.WriteString(desc)
 					} else {
 						_, _ = sb.WriteString("\n")
@@ -328,7 +311,82 @@ var defaultHelpTemplate = func() *template.Template {
 			},
 		).Parse(helpTemplateRaw),
 	)
-}()
+}
+
+// formatExamples lists examples for help. When every description fits on
+// the line of its command within width, the commands are aligned with the
+// descriptions as comments after them:
+//
+//	$ app deploy prod      # Deploy to production.
+//	$ app deploy dev -n    # Preview a dev deploy.
+//
+// Otherwise each description is printed above its command.
+func formatExamples(examples []Example, width int) string {
+	cmdWidth := 0
+	for _, ex := range examples {
+		cmdWidth = max(cmdWidth, len(strings.TrimSpace(ex.Command)))
+	}
+	aligned := true
+	for _, ex := range examples {
+		desc := strings.TrimSpace(ex.Description)
+		if strings.Contains(desc, "\n") || desc != "" && 6+cmdWidth+4+len(desc) > width {
+			aligned = false
+			break
+		}
+	}
+
+	var sb strings.Builder
+	for i, ex := range examples {
+		command := strings.TrimSpace(ex.Command)
+		desc := strings.TrimSpace(ex.Description)
+		switch {
+		case aligned && desc != "":
+			_, _ = fmt.Fprintf(&sb, "    $ %-*s    # %s\n", cmdWidth, command, desc)
+		case aligned:
+			_, _ = fmt.Fprintf(&sb, "    $ %s\n", command)
+		default:
+			if i > 0 {
+				_, _ = sb.WriteString("\n")
+			}
+			if desc != "" {
+				_, _ = sb.WriteString(indentWidth(desc, 4, width))
+			}
+			_, _ = fmt.Fprintf(&sb, "      $ %s\n", command)
+		}
+	}
+	return sb.String()
+}
+
+// formatSeeAlso lists the SeeAlso entries of cmd, commands by their full
+// name and URLs, or paths naming no command, as given.
+func formatSeeAlso(cmd *Command, p palette) string {
+	var sb strings.Builder
+	for _, ref := range cmd.SeeAlso {
+		if target := cmd.root().lookupPath(ref); !isURL(ref) && target != nil {
+			ref = p.keyword(target.FullName())
+		}
+		_, _ = fmt.Fprintf(&sb, "    %s\n", ref)
+	}
+	return sb.String()
+}
+
+// isURL reports whether a SeeAlso entry is a URL rather than a command
+// path.
+func isURL(ref string) bool {
+	return strings.Contains(ref, "://")
+}
+
+// subcommandLabel is the name of cmd followed by its aliases, as listed
+// under Subcommands, e.g. "deploy, dep".
+func subcommandLabel(cmd *Command) string {
+	names := []string{cmd.Name()}
+	for _, alias := range cmd.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			names = append(names, alias)
+		}
+	}
+	return strings.Join(names, ", ")
+}
 
 func filterSlice[T any](s []T, f func(T) bool) []T {
 	var r []T
@@ -388,32 +446,21 @@ var usageWantsArgRe = regexp.MustCompile(`<.*>`)
 
 type UnknownSubcommandError struct {
 	Args []string
+	// Suggestions are the subcommands the user may have meant, see
+	// Command.SuggestionsFor.
+	Suggestions []string
 }
 
 func (e *UnknownSubcommandError) Error() string {
-	return fmt.Sprintf("unknown subcommand %q", strings.Join(e.Args, " "))
-}
-
-// formatCommandName formats a command name with keyword color
-func formatCommandName(name string) string {
-	optionFg := pretty.FgColor(helpColor("#04A777"))
-	txt := pretty.String(name)
-	optionFg.Format(txt)
-	return txt.String()
+	return fmt.Sprintf("unknown subcommand %q", strings.Join(e.Args, " ")) + suggestionText(e.Suggestions)
 }
 
 // formatFlagName formats a flag name with keyword color, returns colored shorthand and flag separately
-func formatFlagName(opt Option) (shorthandColored, flagColored string) {
-	optionFg := pretty.FgColor(helpColor("#04A777"))
+func formatFlagName(p palette, opt Option) (shorthandColored, flagColored string) {
 	if opt.Shorthand != "" {
-		shorthandTxt := pretty.String("-" + opt.Shorthand)
-		optionFg.Format(shorthandTxt)
-		shorthandColored = shorthandTxt.String()
+		shorthandColored = p.keyword("-" + opt.Shorthand)
 	}
-	flagTxt := pretty.String("--" + opt.Flag)
-	optionFg.Format(flagTxt)
-	flagColored = flagTxt.String()
-	return shorthandColored, flagColored
+	return shorthandColored, p.keyword("--" + opt.Flag)
 }
 
 // formatFlagType returns the type string for a flag
@@ -423,16 +470,39 @@ func formatFlagType(opt Option) string {
 	}
 	switch v := opt.Value.(type) {
 	case *Enum:
-		return strings.Join(v.Choices, "|")
+		return strings.Join(v.AllowedChoices(), "|")
 	case *EnumArray:
-		return fmt.Sprintf("[%s]", strings.Join(v.Choices, "|"))
+		return fmt.Sprintf("[%s]", strings.Join(v.AllowedChoices(), "|"))
 	default:
 		return v.Type()
 	}
 }
 
+// untranslated is the localize function of output that is not translated.
+func untranslated(msg string) string { return msg }
+
+// formatOptionNotes returns the parenthesized annotations shown after a flag:
+// the default value, labeled with localize, followed by the option's
+// constraints. Enum choices are skipped because the value type already
+// lists them.
+func formatOptionNotes(opt Option, localize func(string) string) string {
+	var notes []string
+	if opt.DefaultText != "" {
+		notes = append(notes, localize("default")+": "+opt.DefaultText)
+	} else if opt.Default != "" {
+		notes = append(notes, localize("default")+": "+opt.Default)
+	}
+	for _, c := range opt.Constraints() {
+		if c.Kind == ConstraintEnum {
+			continue
+		}
+		notes = append(notes, c.String())
+	}
+	return strings.Join(notes, ", ")
+}
+
 // formatFlagEnvNames formats environment variable names
-func formatFlagEnvNames(opt Option) string {
+func formatFlagEnvNames(p palette, opt Option) string {
 	if len(opt.Envs) == 0 {
 		return ""
 	}
@@ -440,11 +510,7 @@ func formatFlagEnvNames(opt Option) string {
 	for i, env := range opt.Envs {
 		envNames[i] = "$" + env
 	}
-	optionFg := pretty.FgColor(helpColor("#04A777"))
-	envStr := strings.Join(envNames, ", ")
-	txt := pretty.String(envStr)
-	optionFg.Format(txt)
-	return txt.String()
+	return p.keyword(strings.Join(envNames, ", "))
 }
 
 // formatArgType returns the type string for an arg
@@ -454,16 +520,77 @@ func formatArgType(arg Arg) string {
 	}
 	switch v := arg.Value.(type) {
 	case *Enum:
-		return strings.Join(v.Choices, "|")
+		return strings.Join(v.AllowedChoices(), "|")
 	case *EnumArray:
-		return fmt.Sprintf("[%s]", strings.Join(v.Choices, "|"))
+		return fmt.Sprintf("[%s]", strings.Join(v.AllowedChoices(), "|"))
 	default:
 		return v.Type()
 	}
 }
 
+// stdoutInvocation returns an invocation of cmd writing to os.Stdout, for
+// the package-level printers.
+func stdoutInvocation(cmd *Command) *Invocation {
+	return &Invocation{Command: cmd, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
 // PrintCommands prints all commands in a formatted list with full paths, using help formatting style
 func PrintCommands(cmd *Command) {
+	printCommands(stdoutInvocation(cmd), cmd, false, nil)
+}
+
+// listAllCommands is the value of --list-commands given without tags.
+const listAllCommands = "*"
+
+// listCommandsValue is the value of the --list-commands flag: set, and the
+// tags that listed commands must all carry, from repeated flags or CSV.
+type listCommandsValue struct {
+	set  bool
+	tags []string
+}
+
+var _ NoOptDefValuer = (*listCommandsValue)(nil)
+
+func (v *listCommandsValue) Set(s string) error {
+	v.set = true
+	if s == listAllCommands {
+		return nil
+	}
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			v.tags = append(v.tags, tag)
+		}
+	}
+	return nil
+}
+
+func (v *listCommandsValue) String() string { return strings.Join(v.tags, ",") }
+
+func (*listCommandsValue) Type() string { return "tags" }
+
+func (*listCommandsValue) NoOptDefValue() string { return listAllCommands }
+
+// listCommands reports whether --list-commands was given, and its tags.
+func (inv *Invocation) listCommands() (bool, []string) {
+	if inv.Flags == nil {
+		return false, nil
+	}
+	f := inv.Flags.Lookup(inv.Command.builtinFlagName(builtinListCommands))
+	if f == nil {
+		return false, nil
+	}
+	v, ok := f.Value.(*listCommandsValue)
+	if !ok {
+		// An application replaced the value, e.g. with a Bool.
+		return f.Changed, nil
+	}
+	return v.set, v.tags
+}
+
+// printCommands lists the commands below cmd carrying every one of tags
+// to the Stdout of inv, in its width and colors.
+func printCommands(inv *Invocation, cmd *Command, showHidden bool, tags []string) {
+	p, width := inv.palette(inv.Stdout), inv.Width()
 	// Collect all commands with their full paths
 	type cmdInfo struct {
 		path string
@@ -471,36 +598,24 @@ func PrintCommands(cmd *Command) {
 	}
 	var commands []cmdInfo
 
-	// Recursive function to collect commands
-	var collectCommands func(*Command, string)
-	collectCommands = func(c *Command, prefix string) {
-		if c.Hidden {
-			return
+	// Collect commands with the colon paths that invoke them, e.g.
+	// "repo:commit"
+	_ = cmd.Walk(func(c *Command) error {
+		if c == cmd {
+			return nil
 		}
-		// Build the full path for this command
-		var fullPath string
-		if prefix == "" {
-			fullPath = c.Name()
-		} else {
-			fullPath = prefix + ":" + c.Name()
+		if c.Hidden && !showHidden {
+			return SkipChildren
+		}
+		if !c.hasTags(tags) {
+			return nil
 		}
-
-		// Add this command to the list
 		commands = append(commands, cmdInfo{
-			path: fullPath,
+			path: c.ColonPath(),
 			cmd:  c,
 		})
-
-		// Recursively collect child commands
-		for _, child := range c.Children {
-			collectCommands(child, fullPath)
-		}
-	}
-
-	// Start collecting from the root command's children
-	for _, child := range cmd.Children {
-		collectCommands(child, cmd.Name())
-	}
+		return nil
+	})
 
 	if len(commands) == 0 {
 		return
@@ -519,14 +634,14 @@ func PrintCommands(cmd *Command) {
 		var sb strings.Builder
 
 		// Format command name with color
-		coloredPath := formatCommandName(info.path)
-		_, _ = fmt.Fprintf(&sb, "%s%s\n",
-			strings.Repeat(" ", 2), coloredPath,
+		coloredPath := p.keyword(info.path)
+		_, _ = fmt.Fprintf(&sb, "%s%s%s\n",
+			strings.Repeat(" ", 2), coloredPath, formatTags(info.cmd.Tags),
 		)
 
 		// Print description below the command name
 		if info.cmd.Short != "" {
-			desc := indent(info.cmd.Short, 4)
+			desc := indentWidth(info.cmd.Short, 4, width)
 			_, _ = sb.WriteString(desc)
 		}
 
@@ -561,7 +676,7 @@ func PrintCommands(cmd *Command) {
 
 				if arg.Description != "" {
 					_, _ = sb.WriteString("\n")
-					desc := indent(arg.Description, 6)
+					desc := indentWidth(arg.Description, 6, width)
 					_, _ = sb.WriteString(desc)
 				} else {
 					_, _ = sb.WriteString("\n")
@@ -569,16 +684,41 @@ func PrintCommands(cmd *Command) {
 			}
 		}
 
-		fmt.Print(sb.String())
+		_, _ = io.WriteString(inv.Stdout, sb.String())
+	}
+}
+
+// hasTags reports whether c carries every one of tags.
+func (c *Command) hasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(c.Tags, strings.TrimSpace(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTags formats command tags for listings, e.g. " [db, ops]".
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
 	}
+	return " [" + strings.Join(tags, ", ") + "]"
 }
 
 // PrintFlags prints all flags for all commands, using help formatting style
 func PrintFlags(rootCmd *Command) {
+	printFlags(stdoutInvocation(rootCmd), rootCmd, false)
+}
+
+// printFlags lists the flags of all commands to the Stdout of inv, in its
+// width and colors.
+func printFlags(inv *Invocation, rootCmd *Command, showHidden bool) {
+	w, p, width := inv.Stdout, inv.palette(inv.Stdout), inv.Width()
 	// Get all root command options as global flags (not just predefined ones)
 	var globalFlags OptionSet
-	for _, opt := range rootCmd.Options {
-		if opt.Flag != "" && !opt.Hidden {
+	for _, opt := range rootCmd.localOptions() {
+		if opt.Flag != "" && (showHidden || !opt.Hidden) {
 			globalFlags = append(globalFlags, opt)
 		}
 	}
@@ -620,154 +760,110 @@ func PrintFlags(rootCmd *Command) {
 
 	// Print global flags
 	if len(globalFlags) > 0 {
-		fmt.Println(prettyHeader("Global Options"))
-		for _, opt := range globalFlags {
-			if opt.Flag == "" || opt.Hidden {
-				continue
-			}
-
-			var sb strings.Builder
-			shorthandColored, flagColored := formatFlagName(opt)
-			if opt.Shorthand != "" {
-				_, _ = fmt.Fprintf(&sb, "\n ")
-				_, _ = sb.WriteString(shorthandColored)
-				_, _ = sb.WriteString(", ")
-				_, _ = sb.WriteString(flagColored)
-			} else {
-				_, _ = sb.WriteString("\n      ")
-				_, _ = sb.WriteString(flagColored)
-			}
-
-			flagType := formatFlagType(opt)
-			if flagType != "" {
-				_, _ = fmt.Fprintf(&sb, " %s", flagType)
-			}
-
-			if len(opt.Envs) > 0 {
-				envStr := formatFlagEnvNames(opt)
-				_, _ = fmt.Fprintf(&sb, ", %s", envStr)
-			}
-
-			if opt.Default != "" || opt.Required {
-				_, _ = sb.WriteString(" (")
-				if opt.Default != "" {
-					_, _ = fmt.Fprintf(&sb, "default: %s", opt.Default)
-				}
-				if opt.Default != "" && opt.Required {
-					_, _ = sb.WriteString(", ")
-				}
-				if opt.Required {
-					_, _ = sb.WriteString("required")
-				}
-				_, _ = sb.WriteString(")")
-			}
-
-			if opt.Description != "" {
-				desc := indent(opt.Description, 10)
-				_, _ = sb.WriteString("\n")
-				_, _ = sb.WriteString(desc)
-			}
-
-			if opt.Deprecated != "" {
-				deprecatedMsg := fmt.Sprintf("DEPRECATED: %s", opt.Deprecated)
-				deprecatedIndented := indent(deprecatedMsg, 10)
-				_, _ = sb.WriteString("\n")
-				_, _ = sb.WriteString(deprecatedIndented)
-			}
-
-			fmt.Print(sb.String())
-		}
-		fmt.Println()
+		_, _ = fmt.Fprintln(w, p.header("Global Options"))
+		writeFlagColumns(w, p, globalFlags, 2, width)
+		_, _ = fmt.Fprintln(w)
 	}
 
 	// Print flags for each command
 	hasCommandFlags := false
 	for _, info := range commands {
-		if len(info.cmd.Options) == 0 {
-			continue
-		}
-
 		// Filter out global flags from command options
 		var commandSpecificFlags OptionSet
-		for _, opt := range info.cmd.Options {
-			isGlobal := false
-			for _, globalOpt := range globalFlags {
-				if opt.Flag == globalOpt.Flag {
-					isGlobal = true
-					break
-				}
-			}
-			if !isGlobal && opt.Flag != "" && !opt.Hidden {
+		for _, opt := range info.cmd.localOptions() {
+			isGlobal := slices.ContainsFunc(globalFlags, func(g Option) bool { return g.Flag == opt.Flag })
+			if !isGlobal && opt.Flag != "" && (showHidden || !opt.Hidden) {
 				commandSpecificFlags = append(commandSpecificFlags, opt)
 			}
 		}
+		if len(commandSpecificFlags) == 0 {
+			continue
+		}
 
-		if len(commandSpecificFlags) > 0 {
-			if !hasCommandFlags {
-				fmt.Println(prettyHeader("Command-Specific Options"))
-				hasCommandFlags = true
-			}
-
-			fmt.Printf("\n  %s\n", info.path)
-
-			for _, opt := range commandSpecificFlags {
-				var sb strings.Builder
-				shorthandColored, flagColored := formatFlagName(opt)
-				if opt.Shorthand != "" {
-					_, _ = fmt.Fprintf(&sb, "    ")
-					_, _ = sb.WriteString(shorthandColored)
-					_, _ = sb.WriteString(", ")
-					_, _ = sb.WriteString(flagColored)
-				} else {
-					_, _ = sb.WriteString("      ")
-					_, _ = sb.WriteString(flagColored)
-				}
-
-				flagType := formatFlagType(opt)
-				if flagType != "" {
-					_, _ = fmt.Fprintf(&sb, " %s", flagType)
-				}
-
-				if len(opt.Envs) > 0 {
-					envStr := formatFlagEnvNames(opt)
-					_, _ = fmt.Fprintf(&sb, ", %s", envStr)
-				}
+		if !hasCommandFlags {
+			_, _ = fmt.Fprintln(w, p.header("Command-Specific Options"))
+			hasCommandFlags = true
+		}
+		_, _ = fmt.Fprintf(w, "\n  %s\n", info.path)
+		writeFlagColumns(w, p, commandSpecificFlags, 4, width)
+	}
 
-				if opt.Default != "" || opt.Required {
-					_, _ = sb.WriteString(" (")
-					if opt.Default != "" {
-						_, _ = fmt.Fprintf(&sb, "default: %s", opt.Default)
-					}
-					if opt.Default != "" && opt.Required {
-						_, _ = sb.WriteString(", ")
-					}
-					if opt.Required {
-						_, _ = sb.WriteString("required")
-					}
-					_, _ = sb.WriteString(")")
-				}
+	if !hasCommandFlags && len(globalFlags) == 0 {
+		_, _ = fmt.Fprintln(w, "No flags available.")
+	}
+}
 
-				if opt.Description != "" {
-					desc := indent(opt.Description, 10)
-					_, _ = sb.WriteString("\n")
-					_, _ = sb.WriteString(desc)
-				}
+// maxFlagColumn is the widest the flag column of --list-flags gets, as a
+// fraction of the output width; longer flags put their description on
+// the next line.
+const maxFlagColumn = 0.5
+
+// writeFlagColumns lists opts in two columns, indented by indent: the
+// shorthand, flag, type and env names of each, then its description,
+// default and other notes, example and deprecation, wrapped to width.
+func writeFlagColumns(w io.Writer, p palette, opts OptionSet, indent, width int) {
+	type row struct {
+		plain, colored string
+		desc           []string
+	}
+	rows := make([]row, 0, len(opts))
+	column := 0
+	for _, opt := range opts {
+		plain := "    --" + opt.Flag
+		shorthand, flag := formatFlagName(p, opt)
+		colored := "    " + flag
+		if opt.Shorthand != "" {
+			plain = "-" + opt.Shorthand + ", --" + opt.Flag
+			colored = shorthand + ", " + flag
+		}
+		if typ := formatFlagType(opt); typ != "" {
+			plain += " " + typ
+			colored += " " + typ
+		}
+		if len(opt.Envs) > 0 {
+			plain += ", $" + strings.Join(opt.Envs, ", $")
+			colored += ", " + formatFlagEnvNames(p, opt)
+		}
 
-				if opt.Deprecated != "" {
-					deprecatedMsg := fmt.Sprintf("DEPRECATED: %s", opt.Deprecated)
-					deprecatedIndented := indent(deprecatedMsg, 10)
-					_, _ = sb.WriteString("\n")
-					_, _ = sb.WriteString(deprecatedIndented)
-				}
+		desc := opt.Description
+		if notes := formatOptionNotes(opt, untranslated); notes != "" {
+			desc = strings.TrimSpace(desc + " (" + notes + ")")
+		}
+		lines := []string{desc}
+		if opt.Example != "" {
+			lines = append(lines, "Example: "+opt.Example)
+		}
+		if opt.Deprecated != "" {
+			lines = append(lines, "DEPRECATED: "+deprecationSchedule(opt.Deprecated, opt.RemovedIn))
+		}
 
-				fmt.Print(sb.String())
-				fmt.Println()
-			}
+		rows = append(rows, row{plain: plain, colored: colored, desc: lines})
+		if len(plain) <= int(float64(width)*maxFlagColumn) {
+			column = max(column, len(plain))
 		}
 	}
 
-	if !hasCommandFlags && len(globalFlags) == 0 {
-		fmt.Println("No flags available.")
+	descStart := indent + column + 2
+	descWidth := max(width-descStart, 20)
+	for _, r := range rows {
+		var desc []string
+		for _, line := range r.desc {
+			if line != "" {
+				desc = append(desc, strings.Split(wordwrap.WrapString(line, uint(descWidth)), "\n")...)
+			}
+		}
+		_, _ = fmt.Fprint(w, strings.Repeat(" ", indent), r.colored)
+		if len(r.plain) > column && len(desc) > 0 {
+			// Too wide for the column: start the description below.
+			_, _ = fmt.Fprintln(w)
+			_, _ = fmt.Fprint(w, strings.Repeat(" ", descStart), desc[0])
+		} else if len(desc) > 0 {
+			_, _ = fmt.Fprint(w, strings.Repeat(" ", column-len(r.plain)+2), desc[0])
+		}
+		_, _ = fmt.Fprintln(w)
+		for _, line := range desc[min(1, len(desc)):] {
+			_, _ = fmt.Fprintln(w, strings.Repeat(" ", descStart)+line)
+		}
 	}
 }
 
@@ -775,6 +871,19 @@ func PrintFlags(rootCmd *Command) {
 // output for a given command.
 func DefaultHelpFn() HandlerFunc {
 	return func(ctx context.Context, inv *Invocation) error {
+		if inv.helpSearch != "" && len(inv.Args) == 0 {
+			return inv.printHelpSearch()
+		}
+		if inv.helpAll && len(inv.Args) == 0 {
+			return inv.printAllHelp(ctx)
+		}
+		if strings.EqualFold(inv.builtinString(builtinHelpFormat), "json") {
+			if err := inv.writeHelpJSON(inv.Stdout); err != nil {
+				return err
+			}
+			return inv.unknownSubcommand(false)
+		}
+
 		// We use stdout for help and not stderr since there's no straightforward
 		// way to distinguish between a user error and a help request.
 		//
@@ -782,8 +891,14 @@ func DefaultHelpFn() HandlerFunc {
 		// rune at a time.
 		outBuf := bufio.NewWriter(inv.Stdout)
 		out := newlineLimiter{w: outBuf, limit: 2}
+		root := inv.Command.root()
+		if root.HelpHeader != nil {
+			if header := strings.TrimRight(root.HelpHeader(inv.Command), "\n"); header != "" {
+				_, _ = fmt.Fprintf(&out, "%s\n\n", header)
+			}
+		}
 		newWriter := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
-		err := defaultHelpTemplate.Execute(newWriter, inv.Command)
+		err := helpTemplate(inv.Width(), inv.builtinBool(builtinShowHidden), inv.localize, inv.palette(inv.Stdout)).Execute(newWriter, inv.Command)
 		if err != nil {
 			return fmt.Errorf("execute template: %w", err)
 		}
@@ -791,18 +906,29 @@ func DefaultHelpFn() HandlerFunc {
 		if err != nil {
 			return err
 		}
+		if root.HelpFooter != nil {
+			if footer := strings.TrimRight(root.HelpFooter(inv.Command), "\n"); footer != "" {
+				_, _ = fmt.Fprintf(&out, "\n\n%s\n", footer)
+			}
+		}
 		err = outBuf.Flush()
 		if err != nil {
 			return err
 		}
-		if len(inv.Args) > 0 && !usageWantsArgRe.MatchString(inv.Command.Use) {
-			_, _ = fmt.Fprintf(inv.Stderr, "---\nerror: unknown subcommand %q\n", inv.Args[0])
-		}
-		if len(inv.Args) > 0 {
-			// Return an error so that exit status is non-zero when
-			// a subcommand is not found.
-			return &UnknownSubcommandError{Args: inv.Args}
-		}
+		return inv.unknownSubcommand(true)
+	}
+}
+
+// unknownSubcommand returns an UnknownSubcommandError when help was shown
+// for args naming no subcommand, so that the exit status is non-zero,
+// reporting it on Stderr too when report is set.
+func (inv *Invocation) unknownSubcommand(report bool) error {
+	if len(inv.Args) == 0 {
 		return nil
 	}
+	suggestions := inv.Command.SuggestionsFor(inv.Args[0])
+	if report && !usageWantsArgRe.MatchString(inv.Command.Use) {
+		_, _ = fmt.Fprintf(inv.Stderr, "---\n%s: %s%s\n", inv.localize("error"), inv.Translate("unknown subcommand %q", inv.Args[0]), suggestionText(suggestions))
+	}
+	return &UnknownSubcommandError{Args: inv.Args, Suggestions: suggestions}
 }
@@ -1,5 +1,5 @@
 {{- /* Heavily inspired by the Go toolchain and fd */ -}}
-{{prettyHeader "Usage"}}
+{{prettyHeader (tr "Usage")}}
 {{indent .FullUsage 2}}
 
 
@@ -8,23 +8,28 @@
 {{"\n"}}
 {{- end}}
 
-{{- with .Deprecated }}
-{{- indent (printf "DEPRECATED: %s" .) 2 | wrapTTY }}
+{{- if .Deprecated }}
+{{- indent (printf (tr "DEPRECATED: %s") (deprecationSchedule .Deprecated .RemovedIn)) 2 | wrapTTY }}
 {{"\n"}}
 {{- end }}
 
 {{ with .Aliases }}
-{{"  Aliases: "}} {{- joinStrings .}}
+{{"  "}}{{tr "Aliases"}}{{": "}} {{- joinStrings .}}
 {{- end }}
 
 {{- with .Long}}
 {{"\n"}}
-{{- indent . 2}}
+{{- formatLong . }}
 {{ "\n" }}
 {{- end }}
+{{- with .Examples }}
+{{ prettyHeader (tr "Examples") }}
+{{- "\n" }}
+{{- formatExamples . }}
+{{- end }}
 {{- with .Args }}
 {{- if gt (len .) 0 }}
-{{ prettyHeader "Arguments" }}
+{{ prettyHeader (tr "Arguments") }}
 {{- "\n" }}
 {{- range $index, $arg := . }}
 {{- formatArg $arg $index }}
@@ -34,7 +39,7 @@
 {{ with visibleChildren . }}
 {{- range $index, $child := . }}
 {{- if eq $index 0 }}
-{{ prettyHeader "Subcommands"}}
+{{ prettyHeader (tr "Subcommands")}}
 {{- end }}
     {{- "\n" }}
     {{- formatSubcommand . | trimNewline }}
@@ -44,34 +49,38 @@
 {{- $groups := optionGroups . }}
 {{- if gt (len $groups) 0 }}
 {{- range $index, $group := $groups }}
-{{ prettyHeader (printf "%s Options" $group.Name) }}
+{{ prettyHeader (groupHeader $group) }}
     {{- range $optIndex, $option := $group.Options }}
 	{{- if not (eq $option.Shorthand "") }}{{- print "\n "}} {{ keyword "-"}}{{keyword $option.Shorthand }}{{", "}}
 	{{- else }}{{- print "\n      " -}}
 	{{- end }}
-    {{- with flagName $option }}{{keyword "--"}}{{ keyword . }}{{ end }} {{- with typeHelper $option }} {{ . }}{{ end }}
-    {{- with envName $option }}, {{ . | keyword }}{{ end }}
-    {{- if or $option.Default $option.Required }}{{- " (" -}}
-      {{- with $option.Default }}default: {{ . }}{{ end }}
-      {{- if and $option.Default $option.Required }}, {{ end }}
-      {{- if $option.Required }}required{{ end }}
-    {{- ")" -}}{{- end }}
+    {{- with flagName $option }}{{keyword "--"}}{{ keyword . }}{{ else }}{{ envName $option | keyword }}{{ end }} {{- with typeHelper $option }} {{ . }}{{ end }}
+    {{- if flagName $option }}{{- with envName $option }}, {{ . | keyword }}{{ end }}{{ end }}
+    {{- with optionNotes $option }} ({{ . }}){{- end }}
         {{- with $option.Description }}
             {{- $desc := $option.Description }}
 {{ indent $desc 10 }}
 {{- if isDeprecated $option }}
 {{- if $option.Deprecated }}
-{{ indent (printf "DEPRECATED: %s" $option.Deprecated) 10 }}
+{{ indent (printf (tr "DEPRECATED: %s") (deprecationSchedule $option.Deprecated $option.RemovedIn)) 10 }}
 {{- else }}
-{{ indent "DEPRECATED: This option is deprecated." 10 }}
+{{ indent (tr "DEPRECATED: This option is deprecated.") 10 }}
 {{- end }}
 {{- end }}
         {{- end -}}
+        {{- with $option.Example }}
+            {{- if not $option.Description }}{{ "\n" }}{{ end }}
+            {{- indent (printf (tr "Example: %s") .) 10 }}
+        {{- end }}
     {{- end }}
 {{- end }}
 {{- end }}
+{{- with .SeeAlso }}
+{{"\n"}}{{ prettyHeader (tr "See Also") }}
+{{ formatSeeAlso $ | trimNewline }}
+{{- end }}
 {{- if hasParent . }}
 ———
-Run `{{ rootCommandName . }} --help` for a list of global options.
+{{ printf (tr "Run `%s --help` for a list of global options.") (rootCommandName .) }}
 {{- else }}
 {{- end }}
\ No newline at end of file
@@ -0,0 +1,122 @@
+package redant
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// helpCommandName is the implicit subcommand showing the help of the
+// command path following it.
+const helpCommandName = "help"
+
+// rewriteHelpCommand turns "help [path...] [flags...]" into
+// "[path...] [flags...] --help", so that "app help repo commit" shows the
+// help of "app repo commit", when the command has subcommands and none is
+// named help. "--all" among the flags shows the help of every command
+// below the path instead, and "--search KEYWORD" the commands below the
+// path matching KEYWORD.
+func (inv *Invocation) rewriteHelpCommand() {
+	c := inv.Command
+	if len(inv.Args) == 0 || inv.Args[0] != helpCommandName || len(c.Children) == 0 {
+		return
+	}
+	help := c.builtinFlagName(builtinHelp)
+	if _, ok := c.children()[helpCommandName]; ok || help == "" {
+		return
+	}
+	args := slices.Clone(inv.Args[1:])
+	if i := slices.Index(args, "--all"); i >= 0 {
+		args = slices.Delete(args, i, i+1)
+		inv.helpAll = true
+	}
+	for i := 0; i < len(args); i++ {
+		if keyword, ok := strings.CutPrefix(args[i], "--search="); ok {
+			inv.helpSearch = keyword
+			args = slices.Delete(args, i, i+1)
+			break
+		}
+		if args[i] == "--search" && i+1 < len(args) {
+			inv.helpSearch = args[i+1]
+			args = slices.Delete(args, i, i+2)
+			break
+		}
+	}
+	inv.Args = append(args, "--"+help)
+}
+
+// printAllHelp shows the help of inv.Command and of each of its visible
+// descendants, for "app help --all", as a JSON array of HelpDocs with
+// --help-format json.
+func (inv *Invocation) printAllHelp(ctx context.Context) error {
+	if strings.EqualFold(inv.builtinString(builtinHelpFormat), "json") {
+		return inv.writeAllHelpJSON(inv.Stdout)
+	}
+	showHidden := inv.builtinBool(builtinShowHidden)
+	first := true
+	return inv.Command.Walk(func(cmd *Command) error {
+		if cmd.Hidden && !showHidden {
+			return SkipChildren
+		}
+		if !first {
+			if _, err := fmt.Fprintln(inv.Stdout); err != nil {
+				return err
+			}
+		}
+		first = false
+		return DefaultHelpFn()(ctx, inv.with(func(i *Invocation) {
+			i.Command = cmd
+			i.Args = nil
+			i.helpAll = false
+		}))
+	})
+}
+
+// printHelpSearch lists inv.Command and its visible descendants whose name,
+// aliases, Short or Long text or flag names contain inv.helpSearch, case
+// insensitively, each followed by what matched, for "app help --search".
+func (inv *Invocation) printHelpSearch() error {
+	showHidden := inv.builtinBool(builtinShowHidden)
+	keyword := strings.ToLower(inv.helpSearch)
+	contains := func(s string) bool { return strings.Contains(strings.ToLower(s), keyword) }
+	p, width := inv.palette(inv.Stdout), inv.Width()
+
+	var sb strings.Builder
+	_ = inv.Command.Walk(func(cmd *Command) error {
+		if cmd.Hidden && !showHidden {
+			return SkipChildren
+		}
+		var matches []string
+		if contains(cmd.Name()) || slices.ContainsFunc(cmd.Aliases, contains) {
+			matches = append(matches, subcommandLabel(cmd))
+		}
+		if contains(cmd.Short) {
+			matches = append(matches, cmd.Short)
+		}
+		for _, line := range strings.Split(cmd.Long, "\n") {
+			if contains(line) {
+				matches = append(matches, strings.TrimSpace(line))
+			}
+		}
+		for _, opt := range cmd.localOptions() {
+			if opt.Flag != "" && opt.builtin == "" && (showHidden || !opt.Hidden) && contains(opt.Flag) {
+				matches = append(matches, "--"+opt.Flag)
+			}
+		}
+		if len(matches) == 0 {
+			return nil
+		}
+		_, _ = fmt.Fprintf(&sb, "  %s\n", p.keyword(cmd.FullName()))
+		for _, match := range matches {
+			_, _ = sb.WriteString(indentWidth(match, 6, width))
+		}
+		return nil
+	})
+	if sb.Len() == 0 {
+		_, err := fmt.Fprintln(inv.Stdout, inv.Translate("no commands match %q", inv.helpSearch))
+		return err
+	}
+	_, err := fmt.Fprint(inv.Stdout, sb.String())
+	return err
+}
@@ -0,0 +1,120 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHelpCommand(t *testing.T) {
+	newRoot := func() *Command {
+		noop := func(ctx context.Context, inv *Invocation) error { return nil }
+		return &Command{
+			Use: "app",
+			Children: []*Command{
+				{Use: "server", Short: "Manage servers.", Children: []*Command{
+					{
+						Use:     "start",
+						Short:   "Start a server.",
+						Long:    "Starts the server in the background.\nUse --port to pick the listening port.",
+						Options: OptionSet{{Flag: "port", Value: Int64Of(new(int64))}},
+						Handler: noop,
+					},
+					{Use: "debug", Short: "Debug a server.", Hidden: true, Handler: noop},
+				}},
+				{Use: "version", Short: "Print the version.", Handler: noop},
+			},
+		}
+	}
+	help := func(t *testing.T, root *Command, args ...string) string {
+		t.Helper()
+		var stdout bytes.Buffer
+		inv := root.Invoke(args...)
+		inv.Stdout = &stdout
+		if err := inv.Run(); err != nil {
+			t.Fatalf("run %q: %v", args, err)
+		}
+		return stdout.String()
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{name: "root", args: []string{"help"}, want: []string{"USAGE:\n  app\n", "server"}},
+		{name: "path", args: []string{"help", "server", "start"}, want: []string{"USAGE:\n  app server start [--port <port>]\n", "Start a server."}},
+		{
+			name:    "all",
+			args:    []string{"help", "--all"},
+			want:    []string{"USAGE:\n  app\n", "  app server\n", "  app server start [--port <port>]\n", "  app version\n"},
+			notWant: []string{"app server debug"},
+		},
+		{
+			name:    "all with hidden",
+			args:    []string{"help", "server", "--all", "--show-hidden"},
+			want:    []string{"  app server\n", "  app server start [--port <port>]\n", "  app server debug\n"},
+			notWant: []string{"app version"},
+		},
+		{
+			name: "search",
+			args: []string{"help", "--search", "PORT"},
+			want: []string{
+				"  app server start\n      Use --port to pick the listening port.\n      --port\n",
+			},
+			notWant: []string{"app version", "USAGE:"},
+		},
+		{
+			name:    "search below path",
+			args:    []string{"help", "server", "--search=server"},
+			want:    []string{"  app server\n      server\n      Manage servers.\n", "  app server start\n      Start a server.\n      Starts the server in the background.\n"},
+			notWant: []string{"app server debug"},
+		},
+		{name: "search without match", args: []string{"help", "--search", "nope"}, want: []string{"no commands match \"nope\"\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := help(t, newRoot(), tt.args...)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("help missing %q:\n%s", want, out)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out, notWant) {
+					t.Errorf("help contains %q:\n%s", notWant, out)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown path", func(t *testing.T) {
+		inv := newRoot().Invoke("help", "server", "stop")
+		inv.Stdout = &bytes.Buffer{}
+		inv.Stderr = &bytes.Buffer{}
+		var unknown *UnknownSubcommandError
+		if err := inv.Run(); !errors.As(err, &unknown) {
+			t.Fatalf("got %v, want an UnknownSubcommandError", err)
+		}
+	})
+
+	t.Run("own help command", func(t *testing.T) {
+		var got []string
+		root := newRoot()
+		root.Children = append(root.Children, &Command{
+			Use:  "help",
+			Args: ArgSet{{Name: "topic", Value: StringOf(new(string))}},
+			Handler: func(ctx context.Context, inv *Invocation) error {
+				got = inv.Args
+				return nil
+			},
+		})
+		help(t, root, "help", "server")
+		if len(got) != 1 || got[0] != "server" {
+			t.Fatalf("own help command got args %q", got)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package redant
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// HelpDoc is the help page of a command as printed by --help-format json:
+// the same sections as the text help, for wrappers, IDEs and TUIs
+// presenting help their own way. Hidden commands and options are only
+// included with --show-hidden.
+type HelpDoc struct {
+	Name       string          `json:"name"`
+	Path       string          `json:"path"`
+	Usage      string          `json:"usage"`
+	Aliases    []string        `json:"aliases,omitempty"`
+	Short      string          `json:"short,omitempty"`
+	Long       string          `json:"long,omitempty"`
+	Deprecated string          `json:"deprecated,omitempty"`
+	RemovedIn  string          `json:"removedIn,omitempty"`
+	Examples   []Example       `json:"examples,omitempty"`
+	SeeAlso    []string        `json:"seeAlso,omitempty"`
+	Args       []ArgManifest   `json:"args,omitempty"`
+	Commands   []HelpCommand   `json:"commands,omitempty"`
+	Options    []HelpOptionSet `json:"options,omitempty"`
+}
+
+// HelpCommand is a subcommand listed in a HelpDoc.
+type HelpCommand struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Short   string   `json:"short,omitempty"`
+	// Default marks the DefaultChild of the command.
+	Default bool `json:"default,omitempty"`
+}
+
+// HelpOptionSet is a group of options of a HelpDoc: the global options of
+// the root command, then those of each command down to the documented
+// one, named after the command declaring them.
+type HelpOptionSet struct {
+	Command string     `json:"command"`
+	Global  bool       `json:"global,omitempty"`
+	Flags   []HelpFlag `json:"flags"`
+}
+
+// HelpFlag is an option listed in a HelpDoc: its manifest, constraints
+// included, plus the example shown with it in the text help.
+type HelpFlag struct {
+	FlagManifest
+	Example string `json:"example,omitempty"`
+}
+
+// helpDoc describes the help page of inv.Command.
+func (inv *Invocation) helpDoc() HelpDoc {
+	c := inv.Command
+	showHidden := inv.builtinBool(builtinShowHidden)
+	doc := HelpDoc{
+		Name:       c.Name(),
+		Path:       c.FullName(),
+		Usage:      c.FullUsage(),
+		Aliases:    c.Aliases,
+		Short:      c.Short,
+		Long:       c.Long,
+		Deprecated: c.Deprecated,
+		RemovedIn:  c.RemovedIn,
+		Examples:   c.Examples,
+		SeeAlso:    c.SeeAlso,
+	}
+	for i, arg := range c.Args {
+		doc.Args = append(doc.Args, arg.manifest(i))
+	}
+	for _, child := range c.Children {
+		if child.Hidden && !showHidden {
+			continue
+		}
+		doc.Commands = append(doc.Commands, HelpCommand{
+			Name:    child.Name(),
+			Aliases: child.Aliases,
+			Short:   child.Short,
+			Default: c.DefaultChild != "" && c.children()[c.DefaultChild] == child,
+		})
+	}
+	for _, group := range getOptionGroupsByCommand(c, showHidden) {
+		set := HelpOptionSet{Command: group.Name, Global: group.global}
+		if group.global {
+			set.Command = c.root().Name()
+		}
+		for _, opt := range group.Options {
+			set.Flags = append(set.Flags, HelpFlag{
+				FlagManifest: opt.manifest(group.global, false),
+				Example:      opt.Example,
+			})
+		}
+		doc.Options = append(doc.Options, set)
+	}
+	return doc
+}
+
+// writeHelpJSON writes the HelpDoc of inv.Command to w as indented JSON.
+func (inv *Invocation) writeHelpJSON(w io.Writer) error {
+	return writeIndentedJSON(w, inv.helpDoc())
+}
+
+// writeAllHelpJSON writes the HelpDocs of inv.Command and of each of its
+// visible descendants to w as an indented JSON array, for
+// "app help --all --help-format json".
+func (inv *Invocation) writeAllHelpJSON(w io.Writer) error {
+	showHidden := inv.builtinBool(builtinShowHidden)
+	var docs []HelpDoc
+	_ = inv.Command.Walk(func(cmd *Command) error {
+		if cmd.Hidden && !showHidden {
+			return SkipChildren
+		}
+		docs = append(docs, inv.with(func(i *Invocation) { i.Command = cmd }).helpDoc())
+		return nil
+	})
+	return writeIndentedJSON(w, docs)
+}
+
+// writeIndentedJSON writes v to w as indented JSON, leaving HTML
+// characters unescaped.
+func writeIndentedJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
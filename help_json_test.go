@@ -0,0 +1,114 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHelpFormatJSON(t *testing.T) {
+	newRoot := func() *Command {
+		return &Command{
+			Use:     "app",
+			Options: OptionSet{{Flag: "region", Default: "eu", Example: "--region us", Value: StringOf(new(string))}},
+			Children: []*Command{{
+				Use:          "deploy",
+				Short:        "Deploy a release.",
+				DefaultChild: "prod",
+				Options: OptionSet{
+					{Flag: "force", Value: BoolOf(new(bool))},
+					{Flag: "replicas", Min: "1", Max: "9", Value: Int64Of(new(int64))},
+				},
+				SeeAlso: []string{"https://example.com/deploy"},
+				Children: []*Command{
+					{Use: "prod", Aliases: []string{"p"}},
+					{Use: "debug", Hidden: true},
+				},
+			}},
+		}
+	}
+
+	var stdout bytes.Buffer
+	inv := newRoot().Invoke("deploy", "--help", "--help-format", "json")
+	inv.Stdout = &stdout
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var doc HelpDoc
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding %q: %v", stdout.String(), err)
+	}
+
+	if doc.Path != "app deploy" || doc.Usage != "app deploy [--force] [--replicas <replicas>]" || doc.Short != "Deploy a release." {
+		t.Errorf("unexpected header: %+v", doc)
+	}
+	if len(doc.SeeAlso) != 1 || doc.SeeAlso[0] != "https://example.com/deploy" {
+		t.Errorf("see also = %q", doc.SeeAlso)
+	}
+	wantCommands := []HelpCommand{{Name: "prod", Aliases: []string{"p"}, Default: true}}
+	if len(doc.Commands) != 1 || doc.Commands[0].Name != wantCommands[0].Name || !doc.Commands[0].Default {
+		t.Errorf("commands = %+v, want %+v", doc.Commands, wantCommands)
+	}
+	if len(doc.Options) != 2 {
+		t.Fatalf("options = %+v, want global and deploy groups", doc.Options)
+	}
+	if global := doc.Options[0]; global.Command != "app" || !global.Global {
+		t.Errorf("first group = %+v, want the global options of app", global)
+	}
+	for _, flag := range doc.Options[0].Flags {
+		if flag.Hidden {
+			t.Errorf("hidden flag %q listed", flag.Name)
+		}
+	}
+	var region HelpFlag
+	for _, flag := range doc.Options[0].Flags {
+		if flag.Name == "region" {
+			region = flag
+		}
+	}
+	if region.Example != "--region us" || region.Default != "eu" {
+		t.Errorf("region flag = %+v, want its example and default", region)
+	}
+	if deploy := doc.Options[1]; deploy.Command != "deploy" || len(deploy.Flags) != 2 || deploy.Flags[0].Name != "force" || deploy.Flags[0].Type != "bool" {
+		t.Errorf("second group = %+v, want the flags of deploy", deploy)
+	} else if got, want := deploy.Flags[1].Constraints, []Constraint{{Kind: ConstraintMin, Value: "1"}, {Kind: ConstraintMax, Value: "9"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replicas constraints = %+v, want %+v", got, want)
+	}
+
+	stdout.Reset()
+	inv = newRoot().Invoke("deploy", "nope", "--help", "--help-format", "json")
+	inv.Stdout = &stdout
+	var unknown *UnknownSubcommandError
+	if err := inv.Run(); !errors.As(err, &unknown) {
+		t.Fatalf("run: got %v, want an UnknownSubcommandError", err)
+	}
+	if !json.Valid(stdout.Bytes()) {
+		t.Fatalf("invalid JSON: %s", stdout.String())
+	}
+}
+
+func TestHelpAllFormatJSON(t *testing.T) {
+	root := &Command{
+		Use: "app",
+		Children: []*Command{
+			{Use: "deploy", Short: "Deploy a release.", Handler: func(ctx context.Context, inv *Invocation) error { return nil }},
+			{Use: "debug", Hidden: true, Handler: func(ctx context.Context, inv *Invocation) error { return nil }},
+		},
+	}
+	var stdout bytes.Buffer
+	inv := root.Invoke("help", "--all", "--help-format", "json")
+	inv.Stdout = &stdout
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var docs []HelpDoc
+	if err := json.Unmarshal(stdout.Bytes(), &docs); err != nil {
+		t.Fatalf("decoding %q: %v", stdout.String(), err)
+	}
+	if len(docs) != 2 || docs[0].Path != "app" || docs[1].Path != "app deploy" || docs[1].Short != "Deploy a release." {
+		t.Fatalf("docs = %+v, want app and app deploy", docs)
+	}
+}
@@ -0,0 +1,398 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// renderHelp runs cmd with --help and returns the rendered help text.
+func renderHelp(t *testing.T, cmd *Command, args ...string) string {
+	t.Helper()
+	var stdout bytes.Buffer
+	inv := cmd.Invoke(append(args, "--help")...)
+	inv.Stdout = &stdout
+	if err := inv.Run(); err != nil {
+		t.Fatalf("help failed: %v", err)
+	}
+	return stdout.String()
+}
+
+func TestHelpShowsOptionNotes(t *testing.T) {
+	cmd := &Command{
+		Use: "serve",
+		Options: OptionSet{
+			{Flag: "port", Default: "8080", Min: "1", Max: "65535", Value: Int64Of(new(int64))},
+			{Flag: "name", Required: true, Value: StringOf(new(string))},
+		},
+	}
+
+	out := renderHelp(t, cmd)
+	for _, want := range []string{
+		"(default: 8080, min: 1, max: 65535)",
+		"--name string (required)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestHelpShowsDefaultText(t *testing.T) {
+	var jobs int64
+	cmd := &Command{
+		Use: "build",
+		Options: OptionSet{
+			{Flag: "dir", DefaultText: "current directory", Value: StringOf(new(string))},
+			{Flag: "jobs", Default: "4", DefaultText: "number of CPUs", Value: Int64Of(&jobs)},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	out := renderHelp(t, cmd)
+	for _, want := range []string{
+		"--dir string (default: current directory)",
+		"--jobs int64 (default: number of CPUs)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := cmd.Invoke().Run(); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if jobs != 4 {
+		t.Fatalf("DefaultText must not change the applied default, got %d", jobs)
+	}
+}
+
+func TestHelpShowsDynamicEnumChoices(t *testing.T) {
+	var profile string
+	cmd := &Command{
+		Use: "app",
+		Options: OptionSet{
+			{Flag: "profile", Value: EnumFunc(&profile, func() []string { return []string{"dev", "prod"} })},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	out := renderHelp(t, cmd)
+	if !strings.Contains(out, "dev|prod") {
+		t.Fatalf("expected computed choices in help, got:\n%s", out)
+	}
+}
+
+func TestHelpShowsEnvOnlyOption(t *testing.T) {
+	var token string
+	cmd := &Command{
+		Use: "app",
+		Options: OptionSet{
+			{Envs: []string{"APP_TOKEN"}, Value: StringOf(&token), Description: "API token"},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	out := renderHelp(t, cmd)
+	if !strings.Contains(out, "$APP_TOKEN string") {
+		t.Fatalf("expected env-only option listed by env name, got:\n%s", out)
+	}
+	if strings.Contains(out, "string, $APP_TOKEN") {
+		t.Fatalf("env-only option rendered with empty flag, got:\n%s", out)
+	}
+}
+
+func TestHelpHiddenOptions(t *testing.T) {
+	var debug bool
+	newCmd := func() *Command {
+		return &Command{
+			Use: "app",
+			Options: OptionSet{
+				{Flag: "trace-internals", Value: BoolOf(&debug), Hidden: true},
+			},
+			Children: []*Command{
+				{Use: "secret", Hidden: true, Handler: func(ctx context.Context, inv *Invocation) error { return nil }},
+			},
+			Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+		}
+	}
+
+	if err := newCmd().Invoke("--trace-internals").Run(); err != nil {
+		t.Fatalf("hidden flag should parse: %v", err)
+	}
+	if !debug {
+		t.Fatal("hidden flag value was not set")
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		listed bool
+	}{
+		{name: "default", listed: false},
+		{name: "show hidden", args: []string{"--show-hidden"}, listed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := renderHelp(t, newCmd(), tt.args...)
+			for _, want := range []string{"--trace-internals", "secret", "--show-hidden"} {
+				if got := strings.Contains(out, want); got != tt.listed {
+					t.Errorf("%q listed = %v, want %v:\n%s", want, got, tt.listed, out)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpShowsOptionExample(t *testing.T) {
+	cmd := &Command{
+		Use: "get",
+		Options: OptionSet{
+			{Flag: "selector", Description: "Filter by labels.", Example: "--selector app=web,env=prod", Value: StringOf(new(string))},
+			{Flag: "output", Example: "--output json", Value: StringOf(new(string))},
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	// Descriptions are styled (upper-cased) in tests, compare case-insensitively.
+	out := strings.ToLower(renderHelp(t, cmd))
+	for _, want := range []string{
+		"filter by labels.\n          example: --selector app=web,env=prod\n",
+		"--output string\n          example: --output json\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("help output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestHelpShowsVariadicArg(t *testing.T) {
+	cmd := &Command{
+		Use:     "cat",
+		Args:    ArgSet{{Name: "files", Variadic: true, Value: StringArrayOf(new([]string))}},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	if out := renderHelp(t, cmd); !strings.Contains(out, "files... string-array") {
+		t.Fatalf("expected variadic arg marker in help, got:\n%s", out)
+	}
+}
+
+func TestHelpShowsExamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		examples []Example
+		columns  string
+		want     string
+	}{
+		{
+			name: "aligned",
+			examples: []Example{
+				{Command: "app deploy prod", Description: "Deploy to production."},
+				{Command: "app deploy dev --dry-run"},
+				{Command: "app deploy dev -f", Description: "Force a dev deploy."},
+			},
+			want: "EXAMPLES:\n" +
+				"    $ app deploy prod             # Deploy to production.\n" +
+				"    $ app deploy dev --dry-run\n" +
+				"    $ app deploy dev -f           # Force a dev deploy.\n",
+		},
+		{
+			name: "too wide",
+			examples: []Example{
+				{Command: "app deploy prod", Description: "Deploy to production."},
+				{Command: "app deploy dev --dry-run"},
+			},
+			columns: "40",
+			want:    "EXAMPLES:\n    Deploy to production.\n      $ app deploy prod\n\n      $ app deploy dev --dry-run\n",
+		},
+		{
+			name: "multi-line description",
+			examples: []Example{
+				{Command: "app deploy prod", Description: "Deploy to production.\nRequires approval."},
+			},
+			want: "EXAMPLES:\n    Deploy to production.\n    Requires approval.\n      $ app deploy prod\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Command{Use: "app"}
+			root.Children = append(root.Children, &Command{
+				Use:      "deploy",
+				Short:    "Deploy the app.",
+				Examples: tt.examples,
+			})
+
+			var stdout bytes.Buffer
+			inv := root.Invoke("deploy", "--help")
+			if tt.columns != "" {
+				inv = inv.WithEnviron([]string{"COLUMNS=" + tt.columns})
+			}
+			inv.Stdout = &stdout
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Fatalf("help missing examples %q:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestHelpShowsSeeAlso(t *testing.T) {
+	root := &Command{Use: "app", Children: []*Command{
+		{Use: "config", Children: []*Command{{Use: "show", Aliases: []string{"s"}}}},
+		{Use: "get", SeeAlso: []string{"config s", "https://example.com/docs/get"}},
+	}}
+	out := renderHelp(t, root, "get")
+	want := "SEE ALSO:\n    app config show\n    https://example.com/docs/get\n"
+	if !strings.Contains(out, want) {
+		t.Fatalf("help missing %q:\n%s", want, out)
+	}
+	if out := renderHelp(t, root, "config"); strings.Contains(out, "SEE ALSO") {
+		t.Fatalf("unexpected SEE ALSO section:\n%s", out)
+	}
+}
+
+func TestHelpHeaderFooter(t *testing.T) {
+	root := &Command{
+		Use: "app",
+		HelpHeader: func(cmd *Command) string {
+			return "app 1.0 - " + cmd.FullName() + "\n"
+		},
+		HelpFooter: func(cmd *Command) string {
+			if cmd.parent == nil {
+				return ""
+			}
+			return "Report issues at https://example.com/issues"
+		},
+		Children: []*Command{{Use: "get", Short: "Get things."}},
+	}
+
+	out := renderHelp(t, root, "get")
+	if !strings.HasPrefix(out, "app 1.0 - app get\n\nUSAGE:\n") {
+		t.Errorf("help does not start with the header:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "global options.\n\nReport issues at https://example.com/issues\n") {
+		t.Errorf("help does not end with the footer:\n%s", out)
+	}
+
+	out = renderHelp(t, root)
+	if !strings.HasPrefix(out, "app 1.0 - app\n\nUSAGE:\n") || strings.Contains(out, "Report issues") {
+		t.Errorf("unexpected root help:\n%s", out)
+	}
+}
+
+func TestListFlagsColumns(t *testing.T) {
+	root := &Command{
+		Use:                   "app",
+		DisableDefaultGlobals: true,
+		Children: []*Command{{
+			Use: "serve",
+			Options: OptionSet{
+				{Flag: "port", Shorthand: "p", Default: "80", Envs: []string{"PORT"}, Description: "Port to listen on.", Value: Int64Of(new(int64))},
+				{Flag: "mode", Description: "Scheduling mode.", Value: EnumOf(new(string), "fast", "slow", "balanced-with-a-long-name")},
+				{Flag: "dry-run", Example: "--dry-run", Deprecated: "use --plan", Value: BoolOf(new(bool))},
+			},
+		}},
+	}
+	if err := root.Lint(); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	inv := root.Invoke().WithEnviron([]string{"COLUMNS=70"})
+	inv.Stdout = &stdout
+	printFlags(inv, root, false)
+
+	want := "COMMAND-SPECIFIC OPTIONS:\n\n" +
+		"  serve\n" +
+		"        --dry-run bool       Example: --dry-run\n" +
+		"                             DEPRECATED: use --plan\n" +
+		"        --mode fast|slow|balanced-with-a-long-name\n" +
+		"                             SCHEDULING MODE.\n" +
+		"    -p, --port int64, $PORT  PORT TO LISTEN ON. (default: 80)\n"
+	if got := stdout.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnknownSubcommandSuggestions(t *testing.T) {
+	newRoot := func() *Command {
+		return &Command{Use: "app", Children: []*Command{
+			{Use: "delete", SuggestFor: []string{"rm", "remove"}},
+			{Use: "deploy", Aliases: []string{"ship"}},
+			{Use: "status"},
+			{Use: "secret", Hidden: true},
+		}}
+	}
+
+	tests := []struct {
+		typed string
+		want  string
+	}{
+		{typed: "rm", want: "delete"},
+		{typed: "REMOVE", want: "delete"},
+		{typed: "statsu", want: "status"},
+		{typed: "de", want: "delete,deploy"},
+		{typed: "shp", want: "deploy"},
+		{typed: "secrt", want: ""},
+		{typed: "zzz", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typed, func(t *testing.T) {
+			if got := strings.Join(newRoot().SuggestionsFor(tt.typed), ","); got != tt.want {
+				t.Fatalf("SuggestionsFor(%q) = %q, want %q", tt.typed, got, tt.want)
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	inv := newRoot().Invoke("rm")
+	inv.Stderr = &stderr
+	err := inv.Run()
+	var unknown *UnknownSubcommandError
+	if !errors.As(err, &unknown) || strings.Join(unknown.Suggestions, ",") != "delete" {
+		t.Fatalf("expected unknown subcommand error with suggestions, got %v", err)
+	}
+	if want := `unknown subcommand "rm", did you mean "delete"?`; !strings.Contains(stderr.String(), want) || !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected %q in output and error, got %q / %v", want, stderr.String(), err)
+	}
+}
+
+func TestListCommandsTags(t *testing.T) {
+	root := &Command{Use: "ops", Children: []*Command{
+		{Use: "db", Tags: []string{"database"}, Children: []*Command{
+			{Use: "drop", Tags: []string{"database", "dangerous"}},
+			{Use: "status"},
+		}},
+		{Use: "deploy", Tags: []string{"release"}},
+	}}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "all", args: []string{"--list-commands"}, want: []string{"db [database]", "db:drop [database, dangerous]", "db:status", "deploy [release]"}},
+		{name: "one tag", args: []string{"--list-commands=database"}, want: []string{"db [database]", "db:drop [database, dangerous]"}},
+		{name: "every tag", args: []string{"--list-commands=database,dangerous"}, want: []string{"db:drop [database, dangerous]"}},
+		{name: "repeated", args: []string{"--list-commands=database", "--list-commands=dangerous"}, want: []string{"db:drop [database, dangerous]"}},
+		{name: "no match", args: []string{"--list-commands=missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &stdout
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			var got []string
+			for _, line := range strings.Split(stdout.String(), "\n") {
+				if strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   ") {
+					got = append(got, strings.TrimSpace(line))
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("listed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package redant
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Localizer translates the messages printed by the framework: help section
+// headers, warnings and errors. msg is the English message, which for
+// messages with values is the fmt format they are formatted with, e.g.
+// "Usage" or "missing values for the required flags: %s". Localize returns
+// its translation into lang, a tag such as "de" or "zh-CN", or "" to keep
+// msg. See Command.Localizer.
+type Localizer interface {
+	Localize(lang, msg string) string
+}
+
+// Catalog is a Localizer holding translations by language, then by
+// English message. Messages missing for a regional language such as
+// "zh-TW" are looked up for its base language "zh":
+//
+//	root.Localizer = redant.Catalog{
+//		"de": {"Usage": "Verwendung", "%s Options": "%s-Optionen"},
+//	}
+type Catalog map[string]map[string]string
+
+// Localize implements Localizer.
+func (c Catalog) Localize(lang, msg string) string {
+	for lang != "" {
+		if s, ok := c[lang][msg]; ok {
+			return s
+		}
+		i := strings.LastIndexByte(lang, '-')
+		if i < 0 {
+			break
+		}
+		lang = lang[:i]
+	}
+	return ""
+}
+
+// langOption is the built-in --lang global flag, added to the root command
+// when it sets a Localizer.
+func langOption() Option {
+	return Option{
+		Flag:        "lang",
+		Description: "Language of help and messages, e.g. de or zh-CN; defaults to $LANG.",
+		Value:       StringOf(new(string)),
+		builtin:     builtinLang,
+	}
+}
+
+// Language returns the language the invocation's messages are translated
+// into: the value of --lang, or else of the first of the LC_ALL,
+// LC_MESSAGES and LANG environment variables that is set, as a tag such
+// as "zh-CN". It is empty for the "C" and "POSIX" locales.
+func (inv *Invocation) Language() string {
+	lang := inv.builtinString(builtinLang)
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang != "" {
+			break
+		}
+		lang = inv.Getenv(name)
+	}
+	return normalizeLanguage(lang)
+}
+
+// normalizeLanguage turns a POSIX locale such as "zh_CN.UTF-8" into a
+// language tag such as "zh-CN".
+func normalizeLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	if lang == "C" || lang == "POSIX" {
+		return ""
+	}
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// Translate formats msg, translated with the Localizer of the root command
+// into the invocation's Language, with args as fmt.Sprintf does. Handlers
+// may use it for their own messages, listed in the same Localizer.
+func (inv *Invocation) Translate(msg string, args ...any) string {
+	if len(args) == 0 {
+		return inv.localize(msg)
+	}
+	return fmt.Sprintf(inv.localize(msg), args...)
+}
+
+// localize returns msg translated with the Localizer of the root command,
+// or msg itself. Unlike Translate it keeps formats intact for fmt.Errorf.
+func (inv *Invocation) localize(msg string) string {
+	if inv.Command == nil || inv.Command.root().Localizer == nil {
+		return msg
+	}
+	if s := inv.Command.root().Localizer.Localize(inv.Language(), msg); s != "" {
+		return s
+	}
+	return msg
+}
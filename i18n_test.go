@@ -0,0 +1,122 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+var testCatalog = Catalog{
+	"de": {
+		"Usage":                     "Verwendung",
+		"Global Options":            "Globale Optionen",
+		"%s Options":                "%s-Optionen",
+		"Subcommands":               "Unterbefehle",
+		"default":                   "Standard",
+		"error":                     "Fehler",
+		"hint":                      "Hinweis",
+		"run '%s --help' for usage": "siehe '%s --help'",
+		"missing values for the required flags: %s": "fehlende Pflicht-Flags: %s",
+		"greeting %s": "Hallo %s",
+	},
+	"de-AT": {"greeting %s": "Servus %s"},
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "", want: ""},
+		{locale: "C", want: ""},
+		{locale: "POSIX", want: ""},
+		{locale: "C.UTF-8", want: ""},
+		{locale: "de", want: "de"},
+		{locale: "zh_CN.UTF-8", want: "zh-CN"},
+		{locale: "sr_RS@latin", want: "sr-RS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := normalizeLanguage(tt.locale); got != tt.want {
+				t.Fatalf("normalizeLanguage(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		environ []string
+		want    string
+	}{
+		{name: "untranslated", want: "greeting bob"},
+		{name: "lang env", environ: []string{"LANG=de_DE.UTF-8"}, want: "Hallo bob"},
+		{name: "regional", environ: []string{"LANG=de_AT.UTF-8"}, want: "Servus bob"},
+		{name: "lc_all wins", environ: []string{"LANG=fr_FR", "LC_ALL=de_DE"}, want: "Hallo bob"},
+		{name: "flag wins", args: []string{"--lang", "de"}, environ: []string{"LANG=fr_FR"}, want: "Hallo bob"},
+		{name: "unknown language", args: []string{"--lang", "fr"}, want: "greeting bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			root := &Command{
+				Use:       "app",
+				Localizer: testCatalog,
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					got = inv.Translate("greeting %s", "bob")
+					return nil
+				},
+			}
+			if err := root.Invoke(tt.args...).WithEnviron(tt.environ).Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizedHelpAndErrors(t *testing.T) {
+	newRoot := func() *Command {
+		return &Command{
+			Use:       "app",
+			Localizer: testCatalog,
+			Children: []*Command{{
+				Use: "get",
+				Options: OptionSet{
+					{Flag: "name", Required: true, Value: StringOf(new(string))},
+					{Flag: "port", Default: "80", Value: Int64Of(new(int64))},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			}},
+		}
+	}
+
+	help := renderHelp(t, newRoot(), "get", "--lang", "de")
+	for _, want := range []string{"VERWENDUNG:", "GLOBALE OPTIONEN:", "GET-OPTIONEN:", "(Standard: 80)", "--lang string"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help missing %q:\n%s", want, help)
+		}
+	}
+
+	var stderr bytes.Buffer
+	inv := newRoot().Invoke("get", "--lang", "de")
+	inv.Stdout = &bytes.Buffer{}
+	inv.Stderr = &stderr
+	if code := inv.main(); code != ExitUsage {
+		t.Fatalf("exit code %d, want %d", code, ExitUsage)
+	}
+	want := "Fehler: fehlende Pflicht-Flags: name (checked --name)\nHinweis: siehe 'app get --help'\n"
+	if stderr.String() != want {
+		t.Fatalf("stderr %q, want %q", stderr.String(), want)
+	}
+
+	plain := renderHelp(t, &Command{Use: "app"})
+	if strings.Contains(plain, "--lang") {
+		t.Errorf("--lang shown without a Localizer:\n%s", plain)
+	}
+}
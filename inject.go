@@ -0,0 +1,85 @@
+package redant
+
+import (
+	"context"
+	"fmt"
+)
+
+// Key is the context key under which From finds the value of type T, so
+//
+//	inv.WithValue(redant.Key[*Client]{}, client)
+//
+// makes redant.From[*Client](ctx) return client.
+type Key[T any] struct{}
+
+// ProvideFunc returns ctx extended with shared values, such as clients or
+// loggers, for the handlers of a command tree. See Command.Provide.
+type ProvideFunc func(ctx context.Context, inv *Invocation) (context.Context, error)
+
+// WithValue returns the invocation with val attached to its context under
+// key, as context.WithValue does. Use Key[T] as key to read it back with
+// From.
+func (inv *Invocation) WithValue(key, val any) *Invocation {
+	return inv.WithContext(context.WithValue(inv.Context(), key, val))
+}
+
+// From returns the value of type T attached to ctx under Key[T], by
+// Invocation.WithValue, Command.Provide or context.WithValue.
+func From[T any](ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(Key[T]{}).(T)
+	return v, ok
+}
+
+// MustFrom is like From but panics when no value of type T is attached to
+// ctx, for values the application always provides.
+func MustFrom[T any](ctx context.Context) T {
+	v, ok := From[T](ctx)
+	if !ok {
+		panic(fmt.Sprintf("redant: no %T value in context", v))
+	}
+	return v
+}
+
+// SetCtx attaches v to the context of the invocation under Key[T], for
+// GetCtx[T], replacing any value of type T attached before. Middleware
+// uses it to hand typed values, such as an auth token or a client, to the
+// handler:
+//
+//	redant.SetCtx(inv, &Session{Token: token})
+//	return next(ctx, inv)
+//
+// As the value lives in inv.Context(), From[T] and MustFrom on it agree
+// with GetCtx. Values are meant to be set before the handler runs; SetCtx
+// must not be called concurrently with itself or GetCtx.
+func SetCtx[T any](inv *Invocation, v T) {
+	inv.ctx = context.WithValue(inv.Context(), Key[T]{}, v)
+}
+
+// GetCtx returns the value of type T attached to the context of the
+// invocation under Key[T], by SetCtx, Command.Provide or
+// Invocation.WithValue.
+func GetCtx[T any](inv *Invocation) (T, bool) {
+	return From[T](inv.Context())
+}
+
+// provide runs the Provide hooks of the command and its ancestors, from
+// the root down, each extending the context of the previous one.
+func (inv *Invocation) provide(ctx context.Context) (context.Context, error) {
+	var lineage []*Command
+	for cmd := inv.Command; cmd != nil; cmd = cmd.parent {
+		lineage = append(lineage, cmd)
+	}
+	for i := len(lineage) - 1; i >= 0; i-- {
+		if lineage[i].Provide == nil {
+			continue
+		}
+		next, err := lineage[i].Provide(ctx, inv)
+		if err != nil {
+			return ctx, fmt.Errorf("providing values for %q: %w", lineage[i].FullName(), err)
+		}
+		if next != nil {
+			ctx = next
+		}
+	}
+	return ctx, nil
+}
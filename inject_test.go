@@ -0,0 +1,123 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type (
+	testClient struct{ name string }
+	regionKey  struct{}
+)
+
+func TestProvideAndFrom(t *testing.T) {
+	var got []string
+	var provided int
+	root := &Command{
+		Use: "app",
+		Provide: func(ctx context.Context, inv *Invocation) (context.Context, error) {
+			provided++
+			return context.WithValue(ctx, Key[*testClient]{}, &testClient{name: "root"}), nil
+		},
+		Children: []*Command{{
+			Use: "repo",
+			Provide: func(ctx context.Context, inv *Invocation) (context.Context, error) {
+				c := MustFrom[*testClient](ctx)
+				return context.WithValue(ctx, Key[string]{}, c.name+"/repo"), nil
+			},
+			Children: []*Command{{
+				Use: "list",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					client, _ := From[*testClient](ctx)
+					scope, _ := From[string](inv.Context())
+					region, _ := ctx.Value(regionKey{}).(string)
+					got = append(got, client.name, scope, region)
+					return nil
+				},
+			}},
+		}},
+	}
+
+	if err := root.Invoke("repo", "list").WithValue(regionKey{}, "eu").Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Join(got, ",") != "root,root/repo,eu" {
+		t.Fatalf("unexpected values %q", got)
+	}
+
+	if err := root.Invoke("repo", "list", "--help").Run(); err != nil {
+		t.Fatalf("help: %v", err)
+	}
+	if provided != 1 {
+		t.Fatalf("expected Provide to run once and not for help, ran %d times", provided)
+	}
+
+	if _, ok := From[int](context.Background()); ok {
+		t.Fatal("expected no int value in an empty context")
+	}
+}
+
+func TestProvideError(t *testing.T) {
+	errDial := errors.New("dial failed")
+	ran := false
+	root := &Command{
+		Use: "app",
+		Provide: func(ctx context.Context, inv *Invocation) (context.Context, error) {
+			return nil, errDial
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			ran = true
+			return nil
+		},
+	}
+	err := root.Invoke().Run()
+	if !errors.Is(err, errDial) || !strings.Contains(err.Error(), `providing values for "app"`) {
+		t.Fatalf("expected provide error, got %v", err)
+	}
+	if ran {
+		t.Fatal("handler ran despite Provide failing")
+	}
+}
+
+func TestSetCtxGetCtx(t *testing.T) {
+	type session struct{ token string }
+	if _, ok := GetCtx[int](&Invocation{}); ok {
+		t.Fatal("expected no int value in a new invocation")
+	}
+	var (
+		got    *session
+		region string
+	)
+	root := &Command{
+		Use: "app",
+		Provide: func(ctx context.Context, inv *Invocation) (context.Context, error) {
+			return context.WithValue(ctx, Key[string]{}, "eu"), nil
+		},
+		Middleware: func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, inv *Invocation) error {
+				SetCtx(inv, &session{token: "old"})
+				SetCtx(inv, &session{token: "secret"})
+				return next(ctx, inv)
+			}
+		},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			got, _ = GetCtx[*session](inv)
+			region, _ = GetCtx[string](inv)
+			if MustFrom[*session](inv.Context()) != got {
+				t.Error("From disagrees with GetCtx")
+			}
+			return nil
+		},
+	}
+	if err := root.Invoke().Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got == nil || got.token != "secret" {
+		t.Fatalf("got session %+v, want token %q", got, "secret")
+	}
+	if region != "eu" {
+		t.Fatalf("got region %q from the context, want %q", region, "eu")
+	}
+}
@@ -3,7 +3,9 @@ package gitshell
 import (
 	"bytes"
 	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -72,3 +74,32 @@ func IsDirty(startDir string) bool {
 
 	return strings.TrimSpace(output) != ""
 }
+
+// RepoRoot returns the top-level directory of the repository or worktree
+// containing startDir. It asks git when available and otherwise walks up
+// looking for a .git entry (a directory, or a file in linked worktrees).
+func RepoRoot(startDir string) (string, error) {
+	startDir = strings.TrimSpace(startDir)
+	if startDir == "" {
+		return "", errors.New("empty start dir")
+	}
+
+	if root, err := RunInDir(startDir, "rev-parse", "--show-toplevel"); err == nil && root != "" {
+		return filepath.Clean(root), nil
+	}
+
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not a git repository")
+		}
+		dir = parent
+	}
+}
@@ -127,3 +127,29 @@ func runGitForTest(t *testing.T, dir string, args ...string) {
 		t.Fatalf("git %v failed: %v, output=%s", args, err, strings.TrimSpace(string(out)))
 	}
 }
+
+func TestRepoRoot(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmp, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(tmp, "a", "b")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := RepoRoot(nested)
+	if err != nil {
+		t.Fatalf("RepoRoot failed: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(tmp)
+	if gotReal, _ := filepath.EvalSymlinks(got); gotReal != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRepoRoot_NotRepo(t *testing.T) {
+	if _, err := RepoRoot(t.TempDir()); err == nil {
+		t.Fatal("expected error outside a repository")
+	}
+}
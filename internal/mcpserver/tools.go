@@ -76,18 +76,29 @@ func collectTools(root *redant.Command) []toolDef {
 }
 
 func commandDescription(cmd *redant.Command) string {
-	short := strings.TrimSpace(cmd.Short)
-	long := strings.TrimSpace(cmd.Long)
-	switch {
-	case short != "" && long != "":
-		return short + "\n\n" + long
-	case short != "":
-		return short
-	case long != "":
-		return long
-	default:
+	var parts []string
+	for _, s := range []string{cmd.Short, cmd.Long, commandExamples(cmd)} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// commandExamples lists the examples of cmd, each described by a comment.
+func commandExamples(cmd *redant.Command) string {
+	if len(cmd.Examples) == 0 {
 		return ""
 	}
+	var sb strings.Builder
+	sb.WriteString("Examples:")
+	for _, ex := range cmd.Examples {
+		sb.WriteString("\n  " + strings.TrimSpace(ex.Command))
+		if desc := strings.TrimSpace(ex.Description); desc != "" {
+			sb.WriteString("  # " + desc)
+		}
+	}
+	return sb.String()
 }
 
 func buildInputSchema(args redant.ArgSet, options redant.OptionSet) map[string]any {
@@ -196,7 +207,7 @@ func buildFlagsSchema(opts redant.OptionSet) map[string]any {
 	var required []string
 
 	for _, opt := range opts {
-		if opt.Flag == "" || opt.Hidden || isSystemFlag(opt.Flag) {
+		if opt.Flag == "" || opt.Hidden || opt.IsBuiltin() {
 			continue
 		}
 
@@ -210,6 +221,7 @@ func buildFlagsSchema(opts redant.OptionSet) map[string]any {
 		if len(opt.Envs) > 0 {
 			flagSchema["x-env"] = opt.Envs
 		}
+		applyConstraints(flagSchema, opt)
 
 		props[opt.Flag] = flagSchema
 		if opt.Required && opt.Default == "" && len(opt.Envs) == 0 {
@@ -228,6 +240,27 @@ func buildFlagsSchema(opts redant.OptionSet) map[string]any {
 	return schema
 }
 
+// applyConstraints maps the option's constraints onto JSON schema keywords.
+// Required is handled by the enclosing object and enums by the value type.
+func applyConstraints(schema map[string]any, opt redant.Option) {
+	for _, c := range opt.Constraints() {
+		var keyword string
+		switch c.Kind {
+		case redant.ConstraintMin:
+			keyword = "minimum"
+		case redant.ConstraintMax:
+			keyword = "maximum"
+		default:
+			continue
+		}
+		if n, err := strconv.ParseFloat(c.Value, 64); err == nil && schema["type"] != "string" {
+			schema[keyword] = n
+		} else {
+			schema["x-redant-"+string(c.Kind)] = c.Value
+		}
+	}
+}
+
 func typeOfValue(v any) string {
 	if v == nil {
 		return "string"
@@ -372,7 +405,7 @@ func buildArgv(tool toolDef, input map[string]any) ([]string, error) {
 
 	flagByName := map[string]redant.Option{}
 	for _, opt := range tool.Options {
-		if opt.Flag == "" || opt.Hidden || isSystemFlag(opt.Flag) {
+		if opt.Flag == "" || opt.Hidden || opt.IsBuiltin() {
 			continue
 		}
 		flagByName[opt.Flag] = opt
@@ -591,12 +624,3 @@ func buildToolResult(stdout, stderr string, runErr error) map[string]any {
 		"structuredContent": structured,
 	}
 }
-
-func isSystemFlag(flag string) bool {
-	switch flag {
-	case "help", "list-commands", "list-flags", "args":
-		return true
-	default:
-		return false
-	}
-}
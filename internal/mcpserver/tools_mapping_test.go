@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pubgo/redant"
 )
@@ -97,7 +98,7 @@ func TestCollectToolsCommandToToolDefComprehensive(t *testing.T) {
 			t.Fatalf("missing expected flag %q in schema", want)
 		}
 	}
-	for _, notWant := range []string{"internal", "hidden-child", "help", "list-commands", "list-flags", "args"} {
+	for _, notWant := range []string{"internal", "hidden-child", "help", "list-commands", "list-flags", "args", "quiet", "non-interactive", "explain", "no-color"} {
 		if _, exists := flagProps[notWant]; exists {
 			t.Fatalf("unexpected flag %q in schema", notWant)
 		}
@@ -527,3 +528,39 @@ func mustFindToolByName(t *testing.T, tools []toolDef, name string) toolDef {
 	t.Fatalf("tool %q not found in %#v", name, tools)
 	return toolDef{}
 }
+
+func TestBuildFlagsSchemaConstraints(t *testing.T) {
+	var (
+		port    int64
+		timeout time.Duration
+	)
+	schema := buildFlagsSchema(redant.OptionSet{
+		{Flag: "port", Value: redant.Int64Of(&port), Min: "1", Max: "65535"},
+		{Flag: "timeout", Value: redant.DurationOf(&timeout), Max: "1m"},
+	})
+
+	props := schema["properties"].(map[string]any)
+	portSchema := props["port"].(map[string]any)
+	if portSchema["minimum"] != float64(1) || portSchema["maximum"] != float64(65535) {
+		t.Fatalf("unexpected port bounds: %#v", portSchema)
+	}
+	timeoutSchema := props["timeout"].(map[string]any)
+	if timeoutSchema["x-redant-max"] != "1m" {
+		t.Fatalf("unexpected timeout bounds: %#v", timeoutSchema)
+	}
+}
+
+func TestCommandDescriptionIncludesExamples(t *testing.T) {
+	cmd := &redant.Command{
+		Use:   "deploy",
+		Short: "deploy the app",
+		Examples: []redant.Example{
+			{Command: "app deploy prod", Description: "deploy to production"},
+			{Command: "app deploy dev"},
+		},
+	}
+	want := "deploy the app\n\nExamples:\n  app deploy prod  # deploy to production\n  app deploy dev"
+	if got := commandDescription(cmd); got != want {
+		t.Fatalf("description = %q, want %q", got, want)
+	}
+}
@@ -23,7 +23,6 @@ import (
 	"github.com/coder/websocket"
 	"github.com/coder/websocket/wsjson"
 	"github.com/creack/pty"
-	"github.com/spf13/pflag"
 
 	"github.com/pubgo/redant"
 )
@@ -35,6 +34,8 @@ type ArgMeta struct {
 	EnumValues  []string `json:"enumValues,omitempty"`
 	Required    bool     `json:"required"`
 	Default     string   `json:"default,omitempty"`
+
+	Constraints []redant.Constraint `json:"constraints,omitempty"`
 }
 
 type FlagMeta struct {
@@ -46,22 +47,28 @@ type FlagMeta struct {
 	EnumValues  []string `json:"enumValues,omitempty"`
 	Required    bool     `json:"required"`
 	Default     string   `json:"default,omitempty"`
+	DefaultText string   `json:"defaultText,omitempty"`
+	Example     string   `json:"example,omitempty"`
+
+	Constraints []redant.Constraint `json:"constraints,omitempty"`
 }
 
 type CommandMeta struct {
-	ID             string     `json:"id"`
-	Name           string     `json:"name"`
-	Use            string     `json:"use"`
-	Aliases        []string   `json:"aliases,omitempty"`
-	Short          string     `json:"short,omitempty"`
-	Long           string     `json:"long,omitempty"`
-	Deprecated     string     `json:"deprecated,omitempty"`
-	RawArgs        bool       `json:"rawArgs"`
-	Path           []string   `json:"path"`
-	Description    string     `json:"description,omitempty"`
-	Flags          []FlagMeta `json:"flags"`
-	Args           []ArgMeta  `json:"args"`
-	SupportsStream bool       `json:"supportsStream,omitempty"`
+	ID             string           `json:"id"`
+	Name           string           `json:"name"`
+	Use            string           `json:"use"`
+	Aliases        []string         `json:"aliases,omitempty"`
+	Short          string           `json:"short,omitempty"`
+	Long           string           `json:"long,omitempty"`
+	Examples       []redant.Example `json:"examples,omitempty"`
+	Deprecated     string           `json:"deprecated,omitempty"`
+	RemovedIn      string           `json:"removedIn,omitempty"`
+	RawArgs        bool             `json:"rawArgs"`
+	Path           []string         `json:"path"`
+	Description    string           `json:"description,omitempty"`
+	Flags          []FlagMeta       `json:"flags"`
+	Args           []ArgMeta        `json:"args"`
+	SupportsStream bool             `json:"supportsStream,omitempty"`
 }
 
 type RunRequest struct {
@@ -1256,7 +1263,9 @@ func toCommandMeta(cmd *redant.Command, path []string, opts redant.OptionSet) Co
 		Aliases:        append([]string(nil), cmd.Aliases...),
 		Short:          strings.TrimSpace(cmd.Short),
 		Long:           strings.TrimSpace(cmd.Long),
+		Examples:       append([]redant.Example(nil), cmd.Examples...),
 		Deprecated:     strings.TrimSpace(cmd.Deprecated),
+		RemovedIn:      strings.TrimSpace(cmd.RemovedIn),
 		RawArgs:        cmd.RawArgs,
 		Path:           append([]string(nil), path...),
 		Description:    commandDescription(cmd),
@@ -1269,7 +1278,7 @@ func toCommandMeta(cmd *redant.Command, path []string, opts redant.OptionSet) Co
 func toFlagMeta(opts redant.OptionSet) []FlagMeta {
 	byName := map[string]redant.Option{}
 	for _, opt := range opts {
-		if opt.Hidden || opt.Flag == "" || isSystemFlag(opt.Flag) {
+		if opt.Hidden || opt.Flag == "" || opt.IsBuiltin() {
 			continue
 		}
 		byName[opt.Flag] = opt
@@ -1293,6 +1302,9 @@ func toFlagMeta(opts redant.OptionSet) []FlagMeta {
 			EnumValues:  extractEnumValues(opt.Value, opt.Type()),
 			Required:    opt.Required,
 			Default:     opt.Default,
+			DefaultText: opt.DefaultText,
+			Example:     opt.Example,
+			Constraints: opt.Constraints(),
 		})
 	}
 	return out
@@ -1323,7 +1335,7 @@ func toArgMeta(args redant.ArgSet) []ArgMeta {
 	return out
 }
 
-func extractEnumValues(value pflag.Value, typ string) []string {
+func extractEnumValues(value redant.Value, typ string) []string {
 	vals := extractEnumValuesFromValue(value)
 	if len(vals) == 0 {
 		vals = parseEnumValuesFromType(typ)
@@ -1331,17 +1343,17 @@ func extractEnumValues(value pflag.Value, typ string) []string {
 	return normalizeEnumValues(vals)
 }
 
-func extractEnumValuesFromValue(value pflag.Value) []string {
+func extractEnumValuesFromValue(value redant.Value) []string {
 	if value == nil {
 		return nil
 	}
 
 	switch v := value.(type) {
 	case *redant.Enum:
-		return append([]string(nil), v.Choices...)
+		return append([]string(nil), v.AllowedChoices()...)
 	case *redant.EnumArray:
-		return append([]string(nil), v.Choices...)
-	case interface{ Underlying() pflag.Value }:
+		return append([]string(nil), v.AllowedChoices()...)
+	case interface{ Underlying() redant.Value }:
 		return extractEnumValuesFromValue(v.Underlying())
 	default:
 		return nil
@@ -1410,12 +1422,3 @@ func commandDescription(cmd *redant.Command) string {
 		return ""
 	}
 }
-
-func isSystemFlag(flag string) bool {
-	switch flag {
-	case "help", "list-commands", "list-flags", "args":
-		return true
-	default:
-		return false
-	}
-}
@@ -106,6 +106,11 @@ func TestCommandsEndpoint(t *testing.T) {
 	if !slices.Contains(flagNames, "global") || !slices.Contains(flagNames, "local") {
 		t.Fatalf("expected global+local flags, got: %v", flagNames)
 	}
+	for _, builtin := range []string{"help", "list-commands", "quiet", "verbose", "non-interactive", "explain"} {
+		if slices.Contains(flagNames, builtin) {
+			t.Fatalf("unexpected built-in flag %q in: %v", builtin, flagNames)
+		}
+	}
 	if !slices.Equal(flagByName["format"].EnumValues, []string{"json", "text"}) {
 		t.Fatalf("unexpected format enum values: %+v", flagByName["format"].EnumValues)
 	}
@@ -0,0 +1,57 @@
+package redant
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits bounds the resources a command may use while its handler runs.
+// Zero fields are not limited. On platforms without support they are
+// skipped with a warning.
+//
+// Limits are rlimits of the whole process, not of the handler: they also
+// bind every other goroutine until restored after the handler returns.
+// Only set them on commands run once per process, e.g. from Main, and not
+// when commands run concurrently in one process, as in tests or when
+// served through MCP or the web UI, where one command's limits would
+// apply to the others and restoring them could race.
+type Limits struct {
+	// MaxMemory is the maximum address space in bytes.
+	MaxMemory int64
+	// MaxCPUTime is the CPU time the handler may consume.
+	MaxCPUTime time.Duration
+	// MaxOpenFiles is the maximum number of open file descriptors.
+	MaxOpenFiles int
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+func (l Limits) validate() error {
+	if l.MaxMemory < 0 || l.MaxCPUTime < 0 || l.MaxOpenFiles < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	return nil
+}
+
+// applyLimits applies the command's limits and returns a function restoring
+// the previous ones.
+func (inv *Invocation) applyLimits() (restore func() error, err error) {
+	limits := inv.Command.Limits
+	if limits.IsZero() {
+		return func() error { return nil }, nil
+	}
+	restore, err = setLimits(limits)
+	if err == errLimitsUnsupported {
+		if inv.Stderr != nil {
+			_, _ = fmt.Fprintf(inv.Stderr, "warning: resource limits are not supported on this platform and were not applied\n")
+		}
+		return func() error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("applying resource limits: %w", err)
+	}
+	return restore, nil
+}
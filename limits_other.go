@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package redant
+
+import "errors"
+
+var errLimitsUnsupported = errors.New("resource limits unsupported")
+
+func setLimits(Limits) (func() error, error) {
+	return nil, errLimitsUnsupported
+}
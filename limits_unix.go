@@ -0,0 +1,69 @@
+//go:build linux || darwin || freebsd
+
+package redant
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+var errLimitsUnsupported = errors.New("resource limits unsupported")
+
+// setLimits lowers the soft rlimits of the process. Hard limits are left
+// untouched so the previous soft limits can be restored.
+func setLimits(l Limits) (func() error, error) {
+	type saved struct {
+		resource int
+		prev     syscall.Rlimit
+	}
+	var applied []saved
+
+	restore := func() error {
+		var err error
+		for i := len(applied) - 1; i >= 0; i-- {
+			prev := applied[i].prev
+			err = errors.Join(err, syscall.Setrlimit(applied[i].resource, &prev))
+		}
+		return err
+	}
+
+	set := func(resource int, cur uint64) error {
+		var prev syscall.Rlimit
+		if err := syscall.Getrlimit(resource, &prev); err != nil {
+			return err
+		}
+		next := prev
+		if cur < next.Max {
+			next.Cur = cur
+		} else {
+			next.Cur = next.Max
+		}
+		if err := syscall.Setrlimit(resource, &next); err != nil {
+			return err
+		}
+		applied = append(applied, saved{resource: resource, prev: prev})
+		return nil
+	}
+
+	var err error
+	if l.MaxMemory > 0 {
+		err = set(syscall.RLIMIT_AS, uint64(l.MaxMemory))
+	}
+	if err == nil && l.MaxOpenFiles > 0 {
+		err = set(syscall.RLIMIT_NOFILE, uint64(l.MaxOpenFiles))
+	}
+	if err == nil && l.MaxCPUTime > 0 {
+		// RLIMIT_CPU counts the CPU time of the whole process, so the
+		// budget starts from what has been used so far.
+		var usage syscall.Rusage
+		if err = syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err == nil {
+			used := time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+			err = set(syscall.RLIMIT_CPU, uint64((used+l.MaxCPUTime+time.Second-1)/time.Second))
+		}
+	}
+	if err != nil {
+		return nil, errors.Join(err, restore())
+	}
+	return restore, nil
+}
@@ -0,0 +1,57 @@
+//go:build linux || darwin || freebsd
+
+package redant
+
+import (
+	"bytes"
+	"context"
+	"syscall"
+	"testing"
+)
+
+func TestCommandLimits(t *testing.T) {
+	var before syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &before); err != nil {
+		t.Fatal(err)
+	}
+	if before.Max < 64 {
+		t.Skipf("hard open files limit too low: %d", before.Max)
+	}
+
+	var during syscall.Rlimit
+	cmd := &Command{
+		Use:    "plugin",
+		Limits: Limits{MaxOpenFiles: 64},
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			return syscall.Getrlimit(syscall.RLIMIT_NOFILE, &during)
+		},
+	}
+	inv := cmd.Invoke()
+	inv.Stdout = &bytes.Buffer{}
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if during.Cur != 64 {
+		t.Fatalf("open files limit during handler = %d, want 64", during.Cur)
+	}
+
+	var after syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &after); err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Fatalf("limit not restored: before %+v, after %+v", before, after)
+	}
+}
+
+func TestCommandLimitsValidation(t *testing.T) {
+	cmd := &Command{
+		Use:     "plugin",
+		Limits:  Limits{MaxOpenFiles: -1},
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	if err := cmd.Invoke().Run(); err == nil {
+		t.Fatal("expected error for negative limit")
+	}
+}
@@ -0,0 +1,127 @@
+package redant
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Lint initializes the command tree rooted at c and checks it for
+// definition mistakes that Run would otherwise only report, or panic on,
+// when the affected command is invoked:
+//
+//   - flags declared twice by a command;
+//   - shorthands used by two flags of a command's effective flag set,
+//     which includes the global and inherited flags;
+//   - flags of subcommands shadowing the built-in global flags;
+//   - invalid args, such as args following a variadic arg;
+//   - SeeAlso paths naming no command.
+//
+// Lint also reports the problems Run fails on, such as subcommand names or
+// aliases used by two children. The problems are returned joined, each
+// naming the affected command. Lint is meant for tests:
+//
+//	if err := root.Lint(); err != nil {
+//		t.Fatal(err)
+//	}
+//
+// Set StrictLint on the root command to run the same checks on every Run.
+func (c *Command) Lint() error {
+	err := c.init()
+	if !c.StrictLint {
+		err = errors.Join(err, c.lint())
+	}
+	return err
+}
+
+// lint walks the tree rooted at c and returns the problems found, see Lint.
+func (c *Command) lint() error {
+	var merr error
+	_ = c.Walk(func(cmd *Command) error {
+		for _, err := range cmd.lintCommand() {
+			merr = errors.Join(merr, fmt.Errorf("%s: %w", cmd.FullName(), err))
+		}
+		return nil
+	})
+	return merr
+}
+
+// lintCommand returns the problems of c itself.
+func (c *Command) lintCommand() []error {
+	var errs []error
+
+	for _, opts := range []OptionSet{c.Options, c.PersistentOptions} {
+		seen := make(map[string]bool)
+		for _, opt := range opts {
+			if opt.Flag == "" {
+				continue
+			}
+			if seen[opt.Flag] {
+				errs = append(errs, fmt.Errorf("flag %q is declared more than once", opt.Flag))
+			}
+			seen[opt.Flag] = true
+		}
+	}
+
+	local := c.localOptions()
+	if c.parent != nil && !c.DisableGlobalFlags {
+		for _, opt := range local {
+			isBuiltin := func(o Option) bool { return o.builtin != "" && o.Flag == opt.Flag }
+			if opt.Flag != "" && opt.builtin == "" && slices.ContainsFunc(c.root().Options, isBuiltin) {
+				errs = append(errs, fmt.Errorf("flag %q shadows the built-in global flag", opt.Flag))
+			}
+		}
+	}
+
+	byShorthand := make(map[string][]string)
+	for _, opt := range c.effectiveOptions() {
+		if opt.Shorthand != "" {
+			byShorthand[opt.Shorthand] = append(byShorthand[opt.Shorthand], opt.Flag)
+		}
+	}
+	for _, opt := range local {
+		flags := byShorthand[opt.Shorthand]
+		if opt.Shorthand == "" || len(flags) < 2 {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("shorthand %q is used by flags %q", opt.Shorthand, flags))
+		delete(byShorthand, opt.Shorthand)
+	}
+
+	for _, ref := range c.SeeAlso {
+		if !isURL(ref) && c.root().lookupPath(ref) == nil {
+			errs = append(errs, fmt.Errorf("see also %q names no command", ref))
+		}
+	}
+
+	return errs
+}
+
+// effectiveOptions returns the flag options in the flag set Run builds for
+// c, in the same order of precedence: global flags, then the options of
+// the ancestors, nearest first, then the command's own options, which
+// replace flags of the same name.
+func (c *Command) effectiveOptions() []Option {
+	var opts []Option
+	add := func(opt Option) {
+		if opt.Flag != "" && !slices.ContainsFunc(opts, func(o Option) bool { return o.Flag == opt.Flag }) {
+			opts = append(opts, opt)
+		}
+	}
+	for _, opt := range c.GetGlobalFlags() {
+		add(opt)
+	}
+	for p := c.parent; p != nil; p = p.parent {
+		for _, opt := range p.localOptions() {
+			add(opt)
+		}
+	}
+	if c.DisableGlobalFlags {
+		opts = slices.DeleteFunc(opts, func(o Option) bool { return o.builtin != "" })
+	}
+	for _, opt := range c.localOptions() {
+		opts = slices.DeleteFunc(opts, func(o Option) bool { return o.Flag == opt.Flag })
+		add(opt)
+	}
+	return opts
+}
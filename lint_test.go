@@ -0,0 +1,158 @@
+package redant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	str := func() *String { return StringOf(new(string)) }
+
+	tests := []struct {
+		name    string
+		root    func() *Command
+		wantErr []string
+	}{
+		{
+			name: "clean tree",
+			root: func() *Command {
+				return &Command{
+					Use:     "app",
+					Options: OptionSet{{Flag: "output", Shorthand: "o", Value: str()}},
+					Children: []*Command{{
+						Use:     "get",
+						Aliases: []string{"g"},
+						Options: OptionSet{{Flag: "output", Shorthand: "o", Value: str()}},
+						Args:    ArgSet{{Name: "names", Variadic: true, Value: StringArrayOf(new([]string))}},
+					}},
+				}
+			},
+		},
+		{
+			name: "duplicate flag",
+			root: func() *Command {
+				return &Command{Use: "app", Children: []*Command{{
+					Use:     "get",
+					Options: OptionSet{{Flag: "name", Value: str()}, {Flag: "name", Value: str()}},
+				}}}
+			},
+			wantErr: []string{`app get: flag "name" is declared more than once`},
+		},
+		{
+			name: "shorthand collision with inherited flag",
+			root: func() *Command {
+				return &Command{
+					Use:     "app",
+					Options: OptionSet{{Flag: "output", Shorthand: "o", Value: str()}},
+					Children: []*Command{{
+						Use:     "get",
+						Options: OptionSet{{Flag: "owner", Shorthand: "o", Value: str()}},
+					}},
+				}
+			},
+			wantErr: []string{`app get: shorthand "o" is used by flags ["output" "owner"]`},
+		},
+		{
+			name: "shorthand collision with built-in flag",
+			root: func() *Command {
+				return &Command{Use: "app", Children: []*Command{{
+					Use:     "get",
+					Options: OptionSet{{Flag: "host", Shorthand: "h", Value: str()}},
+				}}}
+			},
+			wantErr: []string{`app get: shorthand "h" is used by flags ["help" "host"]`},
+		},
+		{
+			name: "duplicate subcommand names",
+			root: func() *Command {
+				return &Command{Use: "app", Children: []*Command{
+					{Use: "get", Aliases: []string{"g"}},
+					{Use: "gc", Aliases: []string{"g"}},
+					{Use: "get"},
+				}}
+			},
+			wantErr: []string{
+				`duplicate command name "g": alias of Children[0] "app get" and alias of Children[1] "app gc"`,
+				`duplicate command name "get": name of Children[0] "app get" and name of Children[2] "app get"`,
+			},
+		},
+		{
+			name: "reserved global name",
+			root: func() *Command {
+				return &Command{Use: "app", Children: []*Command{
+					{Use: "get", Options: OptionSet{{Flag: "list-flags", Value: str()}}},
+					{Use: "exec", DisableGlobalFlags: true, Options: OptionSet{{Flag: "env", Shorthand: "e", Value: str()}}},
+				}}
+			},
+			wantErr: []string{`app get: flag "list-flags" shadows the built-in global flag`},
+		},
+		{
+			name: "arg after variadic arg",
+			root: func() *Command {
+				return &Command{Use: "app", Children: []*Command{{
+					Use: "cp",
+					Args: ArgSet{
+						{Name: "src", Variadic: true, Value: StringArrayOf(new([]string))},
+						{Name: "dst"},
+					},
+				}}}
+			},
+			wantErr: []string{`arg "src": only the last arg can be variadic`},
+		},
+		{
+			name: "see also unknown command",
+			root: func() *Command {
+				return &Command{Use: "app", Children: []*Command{
+					{Use: "config", Children: []*Command{{Use: "show"}}},
+					{Use: "get", SeeAlso: []string{"config show", "config list", "https://example.com"}},
+				}}
+			},
+			wantErr: []string{`app get: see also "config list" names no command`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.root().Lint()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got nil", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected %q in error:\n%v", want, err)
+				}
+			}
+			if got, want := strings.Count(err.Error(), "\n")+1, len(tt.wantErr); got != want {
+				t.Fatalf("expected %d errors, got %d:\n%v", want, got, err)
+			}
+		})
+	}
+}
+
+func TestStrictLint(t *testing.T) {
+	newRoot := func(strict bool) *Command {
+		return &Command{
+			Use:        "app",
+			StrictLint: strict,
+			Children: []*Command{
+				{Use: "get", Handler: func(ctx context.Context, inv *Invocation) error { return nil }},
+				{Use: "list", Options: OptionSet{{Flag: "help", Value: BoolOf(new(bool))}}},
+			},
+		}
+	}
+
+	if err := newRoot(false).Invoke("get").Run(); err != nil {
+		t.Fatalf("unexpected error without strict mode: %v", err)
+	}
+	err := newRoot(true).Invoke("get").Run()
+	if err == nil || !strings.Contains(err.Error(), `app list: flag "help" shadows the built-in global flag`) {
+		t.Fatalf("expected lint error in strict mode, got %v", err)
+	}
+}
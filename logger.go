@@ -0,0 +1,64 @@
+package redant
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LoggerFrom returns the logger of the invocation running with ctx, see
+// Invocation.Logger, or slog.Default() outside of a Run.
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := From[*slog.Logger](ctx); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// setupLogger sets inv.Logger, unless the caller did, and attaches it to
+// ctx for LoggerFrom and From[*slog.Logger]. The logger is built by the
+// NewLogger of the nearest command defining one, or defaults to a text
+// logger on Stderr.
+func (inv *Invocation) setupLogger(ctx context.Context) context.Context {
+	if inv.Logger == nil {
+		for c := inv.Command; c != nil && inv.Logger == nil; c = c.parent {
+			if c.NewLogger != nil {
+				inv.Logger = c.NewLogger(inv)
+			}
+		}
+	}
+	if inv.Logger == nil {
+		inv.Logger = inv.defaultLogger()
+	}
+	return context.WithValue(ctx, Key[*slog.Logger]{}, inv.Logger)
+}
+
+// defaultLogger logs to Stderr at the level set with the --log-level flag
+// or its env var, as declared by the "logging" bundle, or else at the
+// level matching the verbosity: errors only under --quiet, debug under
+// "--verbose=2". The --log-format flag selects JSON output.
+func (inv *Invocation) defaultLogger() *slog.Logger {
+	level := slog.LevelInfo
+	switch v := inv.Verbosity(); {
+	case v <= VerbosityQuiet:
+		level = slog.LevelError
+	case v >= VerbosityDebug:
+		level = slog.LevelDebug
+	}
+	if f := inv.lookupFlag("log-level"); f != nil && f.Changed {
+		_ = level.UnmarshalText([]byte(f.Value.String()))
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if f := inv.lookupFlag("log-format"); f != nil && f.Value.String() == "json" {
+		return slog.New(slog.NewJSONHandler(inv.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(inv.Stderr, opts))
+}
+
+// lookupFlag returns the named flag of the invocation, or nil.
+func (inv *Invocation) lookupFlag(name string) *Flag {
+	if inv.Flags == nil {
+		return nil
+	}
+	return inv.Flags.Lookup(name)
+}
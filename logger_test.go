@@ -0,0 +1,67 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestInvocationLogger(t *testing.T) {
+	logAll := func(ctx context.Context, inv *Invocation) error {
+		logger := LoggerFrom(ctx)
+		if logger != inv.Logger {
+			t.Error("context logger differs from inv.Logger")
+		}
+		logger.Debug("dbg")
+		logger.Info("inf")
+		logger.Error("err")
+		return nil
+	}
+
+	tests := []struct {
+		name      string
+		bundles   []string
+		newLogger func(inv *Invocation) *slog.Logger
+		args      []string
+		want      []string
+	}{
+		{name: "default", want: []string{"level=INFO msg=inf", "level=ERROR msg=err"}},
+		{name: "quiet", args: []string{"--quiet"}, want: []string{"level=ERROR msg=err"}},
+		{name: "debug", args: []string{"--verbose=2"}, want: []string{"level=DEBUG msg=dbg", "level=INFO msg=inf", "level=ERROR msg=err"}},
+		{name: "log level flag", bundles: []string{"logging"}, args: []string{"--verbose=2", "--log-level", "error"}, want: []string{"level=ERROR msg=err"}},
+		{name: "json", bundles: []string{"logging"}, args: []string{"--log-format", "json"}, want: []string{`"level":"INFO","msg":"inf"`, `"level":"ERROR","msg":"err"`}},
+		{
+			name: "root logger",
+			newLogger: func(inv *Invocation) *slog.Logger {
+				return slog.New(slog.NewTextHandler(inv.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})).With("app", "demo")
+			},
+			want: []string{"level=ERROR msg=err app=demo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Command{
+				Use:       "app",
+				NewLogger: tt.newLogger,
+				Children:  []*Command{{Use: "run", Bundles: tt.bundles, Handler: logAll}},
+			}
+			var stderr bytes.Buffer
+			inv := root.Invoke(append([]string{"run"}, tt.args...)...)
+			inv.Stderr = &stderr
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("logged %q, want %q", lines, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Fatalf("line %d %q does not contain %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
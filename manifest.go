@@ -0,0 +1,198 @@
+package redant
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandManifest describes a command and its subcommands for tools
+// outside the application, such as docs sites, GUIs or policy checks. See
+// CommandTree.
+type CommandManifest struct {
+	Name       string   `json:"name" yaml:"name"`
+	Path       string   `json:"path" yaml:"path"`
+	Usage      string   `json:"usage" yaml:"usage"`
+	Aliases    []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Short      string   `json:"short,omitempty" yaml:"short,omitempty"`
+	Long       string   `json:"long,omitempty" yaml:"long,omitempty"`
+	Tags       []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Hidden     bool     `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Deprecated string   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	RemovedIn  string   `json:"removedIn,omitempty" yaml:"removedIn,omitempty"`
+	// Version is only set on the root command.
+	Version  string            `json:"version,omitempty" yaml:"version,omitempty"`
+	Examples []Example         `json:"examples,omitempty" yaml:"examples,omitempty"`
+	SeeAlso  []string          `json:"seeAlso,omitempty" yaml:"seeAlso,omitempty"`
+	Flags    []FlagManifest    `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Args     []ArgManifest     `json:"args,omitempty" yaml:"args,omitempty"`
+	Commands []CommandManifest `json:"commands,omitempty" yaml:"commands,omitempty"`
+}
+
+// FlagManifest describes an option of a command. Options without a flag,
+// set only from the environment, have an empty Name.
+type FlagManifest struct {
+	Name        string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Shorthand   string   `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Type        string   `json:"type" yaml:"type"`
+	Default     string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Env         []string `json:"env,omitempty" yaml:"env,omitempty"`
+	Choices     []string `json:"choices,omitempty" yaml:"choices,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Hidden      bool     `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	Secret      bool     `json:"secret,omitempty" yaml:"secret,omitempty"`
+	Deprecated  string   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	RemovedIn   string   `json:"removedIn,omitempty" yaml:"removedIn,omitempty"`
+	// Constraints are those enforced on the option, see Option.Constraints.
+	// They include Required and Choices, kept as fields of their own.
+	Constraints []Constraint `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	// Global marks the options of the root command, accepted by every
+	// command, and Persistent those cascading to the command's descendants.
+	Global     bool `json:"global,omitempty" yaml:"global,omitempty"`
+	Persistent bool `json:"persistent,omitempty" yaml:"persistent,omitempty"`
+}
+
+// ArgManifest describes a positional arg of a command.
+type ArgManifest struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Type        string   `json:"type" yaml:"type"`
+	Default     string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Choices     []string `json:"choices,omitempty" yaml:"choices,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Variadic    bool     `json:"variadic,omitempty" yaml:"variadic,omitempty"`
+}
+
+// CommandTree initializes the command tree rooted at root and describes
+// it, hidden commands and flags included. Each command lists the flags it
+// declares; inherited ones are listed once, on the command declaring them,
+// and marked Global or Persistent. Subcommands are sorted by name.
+func CommandTree(root *Command) (*CommandManifest, error) {
+	initMu.Lock()
+	err := root.init()
+	initMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("initializing command: %w", err)
+	}
+	m := root.manifest()
+	m.Version = root.Version
+	return &m, nil
+}
+
+// MarshalCommandTree returns the CommandTree of root as indented JSON.
+func MarshalCommandTree(root *Command) ([]byte, error) {
+	m, err := CommandTree(root)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalCommandTreeYAML returns the CommandTree of root as YAML.
+func MarshalCommandTreeYAML(root *Command) ([]byte, error) {
+	m, err := CommandTree(root)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(m)
+}
+
+// manifest describes c and its subcommands.
+func (c *Command) manifest() CommandManifest {
+	m := CommandManifest{
+		Name:       c.Name(),
+		Path:       c.FullName(),
+		Usage:      c.FullUsage(),
+		Aliases:    c.Aliases,
+		Short:      c.Short,
+		Long:       c.Long,
+		Tags:       c.Tags,
+		Hidden:     c.Hidden,
+		Deprecated: c.Deprecated,
+		RemovedIn:  c.RemovedIn,
+		Examples:   c.Examples,
+		SeeAlso:    c.SeeAlso,
+	}
+	global := c.parent == nil
+	for _, opt := range c.Options {
+		m.Flags = append(m.Flags, opt.manifest(global, false))
+	}
+	for _, opt := range c.PersistentOptions {
+		m.Flags = append(m.Flags, opt.manifest(global, true))
+	}
+	for i, arg := range c.Args {
+		m.Args = append(m.Args, arg.manifest(i))
+	}
+
+	children := slices.SortedFunc(slices.Values(c.Children), func(a, b *Command) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	for _, child := range children {
+		m.Commands = append(m.Commands, child.manifest())
+	}
+	return m
+}
+
+func (o Option) manifest(global, persistent bool) FlagManifest {
+	def := o.Default
+	if o.DefaultText != "" {
+		def = o.DefaultText
+	}
+	typ, choices := valueType(o.Value)
+	return FlagManifest{
+		Name:        o.Flag,
+		Shorthand:   o.Shorthand,
+		Description: o.Description,
+		Type:        typ,
+		Default:     def,
+		Env:         o.Envs,
+		Choices:     choices,
+		Required:    o.Required,
+		Hidden:      o.Hidden,
+		Secret:      o.IsSecret(),
+		Deprecated:  o.Deprecated,
+		RemovedIn:   o.RemovedIn,
+		Constraints: o.Constraints(),
+		Global:      global,
+		Persistent:  persistent,
+	}
+}
+
+func (a Arg) manifest(i int) ArgManifest {
+	typ, choices := valueType(a.Value)
+	return ArgManifest{
+		Name:        a.displayName(i),
+		Description: a.Description,
+		Type:        typ,
+		Default:     a.Default,
+		Choices:     choices,
+		Required:    a.Required && a.Default == "",
+		Variadic:    a.Variadic,
+	}
+}
+
+// valueType returns the type name of val, "string" for nil, and the
+// choices of enum values, which are left out of their type name.
+func valueType(val Value) (string, []string) {
+	if val == nil {
+		return "string", nil
+	}
+	if enum, ok := val.(interface{ AllowedChoices() []string }); ok {
+		typ, _, _ := strings.Cut(val.Type(), "[")
+		return typ, enum.AllowedChoices()
+	}
+	return val.Type(), nil
+}
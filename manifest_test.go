@@ -0,0 +1,120 @@
+package redant
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func newManifestTree() *Command {
+	var region, env, token string
+	var files []string
+	return &Command{
+		Use:                   "app",
+		Short:                 "Manage deployments.",
+		Version:               "1.2.0",
+		DisableDefaultGlobals: true,
+		Options: OptionSet{
+			{Flag: "region", Description: "Cloud region.", Default: "eu", Envs: []string{"APP_REGION"}, Value: StringOf(&region)},
+		},
+		Children: []*Command{
+			{
+				Use:     "deploy <env> [files...]",
+				Aliases: []string{"d"},
+				Short:   "Deploy a release.",
+				Tags:    []string{"dangerous"},
+				Options: OptionSet{
+					{Flag: "token", Description: "API token.", Required: true, Value: StringOf(&token)},
+				},
+				Args: ArgSet{
+					{Name: "env", Required: true, Value: EnumOf(&env, "dev", "prod")},
+					{Name: "files", Variadic: true, Value: StringArrayOf(&files)},
+				},
+				Examples: []Example{{Command: "app deploy prod", Description: "Deploy to production."}},
+			},
+			{
+				Use:               "debug",
+				Hidden:            true,
+				Deprecated:        "use deploy --verbose",
+				RemovedIn:         "2.0.0",
+				PersistentOptions: OptionSet{{Flag: "trace-id", Deprecated: "traces are always on", RemovedIn: "1.5.0", Value: StringOf(new(string))}},
+			},
+		},
+	}
+}
+
+func TestCommandTree(t *testing.T) {
+	want := &CommandManifest{
+		Name:    "app",
+		Path:    "app",
+		Usage:   "app [--region <region>]",
+		Short:   "Manage deployments.",
+		Version: "1.2.0",
+		Flags: []FlagManifest{
+			{Name: "region", Description: "CLOUD REGION.", Type: "string", Default: "eu", Env: []string{"APP_REGION"}, Global: true},
+		},
+		Commands: []CommandManifest{
+			{
+				Name:       "debug",
+				Path:       "app debug",
+				Usage:      "app debug",
+				Hidden:     true,
+				Deprecated: "use deploy --verbose",
+				RemovedIn:  "2.0.0",
+				Flags:      []FlagManifest{{Name: "trace-id", Type: "string", Deprecated: "traces are always on", RemovedIn: "1.5.0", Persistent: true}},
+			},
+			{
+				Name:     "deploy",
+				Path:     "app deploy",
+				Usage:    "app deploy <env> [files...]",
+				Aliases:  []string{"d"},
+				Short:    "Deploy a release.",
+				Tags:     []string{"dangerous"},
+				Examples: []Example{{Command: "app deploy prod", Description: "Deploy to production."}},
+				Flags:    []FlagManifest{{Name: "token", Description: "API TOKEN.", Type: "string", Required: true, Secret: true, Constraints: []Constraint{{Kind: ConstraintRequired}}}},
+				Args: []ArgManifest{
+					{Name: "env", Type: "enum", Choices: []string{"dev", "prod"}, Required: true},
+					{Name: "files", Type: "string-array", Variadic: true},
+				},
+			},
+		},
+	}
+
+	got, err := CommandTree(newManifestTree())
+	if err != nil {
+		t.Fatalf("command tree: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		marshal   func(*Command) ([]byte, error)
+		unmarshal func([]byte, any) error
+	}{
+		{name: "json", marshal: MarshalCommandTree, unmarshal: json.Unmarshal},
+		{name: "yaml", marshal: MarshalCommandTreeYAML, unmarshal: yaml.Unmarshal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.marshal(newManifestTree())
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if !bytes.Contains(data, []byte("app deploy <env> [files...]")) {
+				t.Errorf("usage escaped or missing in:\n%s", data)
+			}
+			var decoded CommandManifest
+			if err := tt.unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(&decoded, want) {
+				t.Fatalf("round trip got %+v\nwant %+v", decoded, want)
+			}
+		})
+	}
+}
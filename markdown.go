@@ -0,0 +1,92 @@
+package redant
+
+import (
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/muesli/termenv"
+
+	"github.com/pubgo/redant/internal/pretty"
+)
+
+// formatLong renders the lightweight markdown of a Long description for
+// help, indented by indent and wrapped to width: paragraphs are wrapped,
+// "- " and "* " bullet items get a hanging indent, code fenced with ```
+// is indented further and never wrapped, and **bold** text is shown in
+// bold, or without its asterisks when p has no colors. Other text,
+// including single line breaks, is kept as written.
+func formatLong(long string, indent, width int, p palette) string {
+	spacing := strings.Repeat(" ", indent)
+	var (
+		sb     strings.Builder
+		inCode bool
+		bold   bool
+	)
+	for _, line := range strings.Split(strings.TrimRight(long, "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			_, _ = sb.WriteString(strings.TrimRight(spacing+"  "+line, " ") + "\n")
+			continue
+		}
+
+		first, rest := spacing, spacing
+		trimmed := strings.TrimLeft(line, " ")
+		if item, ok := cutBullet(trimmed); ok {
+			lead := strings.Repeat(" ", len(line)-len(trimmed))
+			first, rest = spacing+lead+"- ", spacing+lead+"  "
+			line = item
+		}
+		wrapped := wordwrap.WrapString(line, uint(max(width-len(rest), 1)))
+		for i, l := range strings.Split(wrapped, "\n") {
+			prefix := rest
+			if i == 0 {
+				prefix = first
+			}
+			l, bold = p.emphasize(l, bold)
+			_, _ = sb.WriteString(strings.TrimRight(prefix+l, " ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// cutBullet returns the text of a "- " or "* " bullet item.
+func cutBullet(line string) (string, bool) {
+	for _, marker := range []string{"- ", "* "} {
+		if item, ok := strings.CutPrefix(line, marker); ok {
+			return item, true
+		}
+	}
+	return "", false
+}
+
+// emphasize renders the **bold** spans of line. bold reports whether the
+// line starts inside a span opened on a previous line; emphasize returns
+// whether the line ends inside one.
+func (p palette) emphasize(line string, bold bool) (string, bool) {
+	if !strings.Contains(line, "**") && !bold {
+		return line, false
+	}
+	parts := strings.Split(line, "**")
+	for i, part := range parts {
+		if i > 0 {
+			bold = !bold
+		}
+		if bold && part != "" {
+			parts[i] = p.bold(part)
+		}
+	}
+	return strings.Join(parts, ""), bold
+}
+
+// bold renders s in bold when p has colors.
+func (p palette) bold(s string) string {
+	if p.profile == termenv.Ascii {
+		return s
+	}
+	txt := pretty.String(s)
+	pretty.Bold().Format(txt)
+	return txt.String()
+}
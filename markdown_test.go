@@ -0,0 +1,61 @@
+package redant
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestFormatLong(t *testing.T) {
+	tests := []struct {
+		name    string
+		long    string
+		profile termenv.Profile
+		want    string
+	}{
+		{
+			name: "paragraph",
+			long: "Deploys the current release to the selected environment.",
+			want: "  Deploys the current release to the\n  selected environment.\n",
+		},
+		{
+			name: "bullets",
+			long: "Targets:\n- prod, the customer facing cluster in every region\n  * canary",
+			want: "  Targets:\n  - prod, the customer facing cluster in\n    every region\n    - canary\n",
+		},
+		{
+			name: "code fence",
+			long: "Example config:\n```yaml\nreplicas: 3    # keep it odd\n```\nDone.",
+			want: "  Example config:\n    replicas: 3    # keep it odd\n  Done.\n",
+		},
+		{
+			name: "bold without colors",
+			long: "This is **irreversible**.",
+			want: "  This is irreversible.\n",
+		},
+		{
+			name:    "bold",
+			long:    "This is **really very very irreversible** indeed.",
+			profile: termenv.ANSI,
+			want:    "  This is \x1b[1mreally very very\x1b[0m\n  \x1b[1mirreversible\x1b[0m indeed.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := tt.profile
+			if profile == 0 {
+				profile = termenv.Ascii
+			}
+			got := formatLong(tt.long, 2, 40, palette{profile: profile})
+			if got != tt.want {
+				t.Fatalf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	help := renderHelp(t, &Command{Use: "app", Long: "Runs **everything**:\n- build\n- test"})
+	if !strings.Contains(help, "  Runs everything:\n  - build\n  - test\n") {
+		t.Fatalf("help does not render the markdown of Long:\n%s", help)
+	}
+}
@@ -0,0 +1,110 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout returns middleware that cancels the handler context after d on
+// the invocation clock (see WithClock). An error returned once the
+// deadline passed is reported as a timeout, wrapping
+// context.DeadlineExceeded so that Main exits with ExitTimeout.
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) error {
+			ctx, cancel := withClockTimeout(ctx, inv.Clock(), d)
+			defer cancel()
+
+			err := next(ctx, inv)
+			if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return err
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%w: %w", err, context.DeadlineExceeded)
+			}
+			return fmt.Errorf("timed out after %s: %w", d, err)
+		}
+	}
+}
+
+// withClockTimeout is context.WithTimeout driven by clock. With a clock
+// other than the real one, the context reports no deadline of its own, as
+// the time of a fake clock means nothing to code comparing deadlines with
+// the wall clock.
+func withClockTimeout(ctx context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(ctx, d)
+	}
+	parent, cancel := context.WithCancel(ctx)
+	tctx := &clockTimeoutContext{Context: parent}
+	expired := clock.After(d)
+	go func() {
+		select {
+		case <-expired:
+			tctx.expired.Store(true)
+			cancel()
+		case <-parent.Done():
+		}
+	}()
+	return tctx, cancel
+}
+
+// clockTimeoutContext is the context of withClockTimeout, failing with
+// context.DeadlineExceeded once expired.
+type clockTimeoutContext struct {
+	context.Context
+	expired atomic.Bool
+}
+
+func (c *clockTimeoutContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && c.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// Retry returns middleware that runs the handler up to attempts times
+// until it succeeds, waiting backoff after the first failure and doubling
+// the wait after each further one. Every failed attempt is logged as a
+// warning with Invocation.Logger. Retrying stops early once the context
+// is done, and usage errors are never retried. Chained before Timeout,
+// each attempt gets the full timeout; chained after it, the timeout
+// covers all attempts together:
+//
+//	// Up to 3 attempts of 10s each.
+//	Middleware: redant.Chain(redant.Retry(3, time.Second), redant.Timeout(10*time.Second))
+func Retry(attempts int, backoff time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) error {
+			logger := LoggerFrom(ctx)
+			wait := backoff
+			for attempt := 1; ; attempt++ {
+				err := next(ctx, inv)
+				if err == nil {
+					return nil
+				}
+				if attempt >= attempts || ctx.Err() != nil || ClassifyError(err) == ErrorClassUsage {
+					if attempt > 1 {
+						err = fmt.Errorf("after %d attempts: %w", attempt, err)
+					}
+					return err
+				}
+				logger.Warn("attempt failed, retrying",
+					"command", inv.Command.FullName(),
+					"attempt", attempt,
+					"attempts", attempts,
+					"retry_in", wait,
+					"error", err,
+				)
+				if sleepErr := inv.Sleep(ctx, wait); sleepErr != nil {
+					return errors.Join(err, sleepErr)
+				}
+				wait *= 2
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingClock fires timers at once and records their durations.
+type recordingClock struct{ waits []time.Duration }
+
+func (c *recordingClock) Now() time.Time { return time.Time{} }
+func (c *recordingClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+// cancelingClock cancels the context when a timer is started and never
+// fires it.
+type cancelingClock struct{ cancel context.CancelFunc }
+
+func (c cancelingClock) Now() time.Time { return time.Time{} }
+func (c cancelingClock) After(time.Duration) <-chan time.Time {
+	c.cancel()
+	return make(chan time.Time)
+}
+
+func TestRetryCanceledWhileWaiting(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	cmd := &Command{
+		Use:        "sync",
+		Middleware: Retry(3, time.Second),
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			calls++
+			return errFlaky
+		},
+	}
+	err := cmd.Invoke().WithClock(cancelingClock{cancel: cancel}).WithContext(ctx).WithStderr(&bytes.Buffer{}).Run()
+	if calls != 1 || !errors.Is(err, errFlaky) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("calls %d, run error %v, want flaky and context canceled", calls, err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name      string
+		failures  int
+		ctx       context.Context
+		timeout   time.Duration
+		wantCalls int
+		wantWaits []time.Duration
+		wantErr   string
+	}{
+		{name: "first attempt", wantCalls: 1},
+		{name: "recovers", failures: 2, wantCalls: 3, wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "gives up", failures: 5, wantCalls: 3, wantWaits: []time.Duration{time.Second, 2 * time.Second}, wantErr: "after 3 attempts: flaky"},
+		{name: "canceled", failures: 5, ctx: canceledCtx, wantCalls: 1, wantErr: "flaky"},
+		{name: "attempt timed out", failures: 1, timeout: 5 * time.Millisecond, wantCalls: 2, wantWaits: []time.Duration{5 * time.Millisecond, time.Second, 5 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			cmd := &Command{
+				Use:        "sync",
+				Middleware: Retry(3, time.Second),
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					calls++
+					if calls > tt.failures {
+						return nil
+					}
+					if tt.timeout > 0 {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+					return errFlaky
+				},
+			}
+			if tt.timeout > 0 {
+				cmd.Middleware = Chain(Retry(3, time.Second), Timeout(tt.timeout))
+			}
+
+			clock := &recordingClock{}
+			var stderr bytes.Buffer
+			inv := cmd.Invoke().WithClock(clock).WithStderr(&stderr)
+			if tt.ctx != nil {
+				inv = inv.WithContext(tt.ctx)
+			}
+			err := inv.Run()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.HasSuffix(err.Error(), tt.wantErr)) {
+				t.Fatalf("run error %v, want %q", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || len(clock.waits) != len(tt.wantWaits) {
+				t.Fatalf("calls %d, waits %v", calls, clock.waits)
+			}
+			for i, want := range tt.wantWaits {
+				if clock.waits[i] != want {
+					t.Fatalf("waits %v, want %v", clock.waits, tt.wantWaits)
+				}
+			}
+			if got := strings.Count(stderr.String(), "attempt failed, retrying"); got != tt.wantCalls-1 {
+				t.Fatalf("logged %d retries:\n%s", got, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRetrySkipsUsageErrors(t *testing.T) {
+	clock := &recordingClock{}
+	cmd := &Command{
+		Use:        "sync",
+		Middleware: Retry(3, time.Second),
+		Handler:    func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	err := cmd.Invoke("--bogus").WithClock(clock).Run()
+	if ExitCode(err) != ExitUsage || len(clock.waits) != 0 {
+		t.Fatalf("run error %v, waits %v", err, clock.waits)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler HandlerFunc
+		wantErr string
+	}{
+		{
+			name:    "in time",
+			handler: func(ctx context.Context, inv *Invocation) error { return nil },
+		},
+		{
+			name: "context error",
+			handler: func(ctx context.Context, inv *Invocation) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantErr: "timed out after 10ms: context deadline exceeded",
+		},
+		{
+			name: "other error",
+			handler: func(ctx context.Context, inv *Invocation) error {
+				<-ctx.Done()
+				return errors.New("dial failed")
+			},
+			wantErr: "timed out after 10ms: dial failed: context deadline exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{Use: "fetch", Middleware: Timeout(10 * time.Millisecond), Handler: tt.handler}
+			err := cmd.Invoke().Run()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("run: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) || ExitCode(err) != ExitTimeout {
+				t.Fatalf("run error %v (exit code %d), want %q", err, ExitCode(err), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeoutUsesInvocationClock(t *testing.T) {
+	clock := &recordingClock{}
+	cmd := &Command{
+		Use:        "fetch",
+		Middleware: Timeout(time.Hour),
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	err := cmd.Invoke().WithClock(clock).Run()
+	if err == nil || !strings.HasSuffix(err.Error(), "timed out after 1h0m0s: context deadline exceeded") || ExitCode(err) != ExitTimeout {
+		t.Fatalf("run error %v (exit code %d)", err, ExitCode(err))
+	}
+	if len(clock.waits) != 1 || clock.waits[0] != time.Hour {
+		t.Fatalf("waited %v on the clock, want [1h0m0s]", clock.waits)
+	}
+}
@@ -0,0 +1,114 @@
+package redant
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Mount grafts a copy of the foreign command tree root under the space
+// separated prefix path and returns the top-level command of that path,
+// ready to be appended to an umbrella command's Children. Intermediate
+// path segments become plain group commands, and the copy of root is
+// renamed to the last segment. root itself is left untouched, so it can
+// still run standalone or be mounted elsewhere.
+//
+// The copy is made with Clone but shares the option and arg Values of
+// root, so handlers reading variables bound by the original definition
+// keep working. Environment variables named after root, e.g. LINT_STRICT
+// for a tree named "lint", are renamed after the mount path, e.g.
+// TOOLS_GO_LINT_STRICT when mounted at "tools go lint"; other variables
+// keep their names.
+//
+// Root-only settings of the foreign tree (Version, StateDir, global flag
+// customization, ...) are superseded by the umbrella root once mounted, and
+// built-in global flags it may have added during a previous init are
+// dropped. FullName and help paths follow the new position automatically.
+func Mount(prefix string, root *Command) *Command {
+	segments := strings.Fields(prefix)
+	if len(segments) == 0 {
+		return root
+	}
+
+	oldEnvPrefix := envPrefix(root.Name())
+	root = root.Clone(func(v Value) Value { return v })
+	root.Options = slices.DeleteFunc(root.Options, func(opt Option) bool {
+		return opt.builtin != ""
+	})
+	root.renameEnvs(oldEnvPrefix, envPrefix(strings.Join(segments, "_")))
+
+	name := segments[len(segments)-1]
+	if _, rest, ok := strings.Cut(root.Use, " "); ok {
+		root.Use = name + " " + rest
+	} else {
+		root.Use = name
+	}
+
+	top := root
+	for i := len(segments) - 2; i >= 0; i-- {
+		top = &Command{
+			Use:      segments[i],
+			Short:    root.Short,
+			Children: []*Command{top},
+		}
+	}
+	return top
+}
+
+// envPrefix returns the environment variable prefix derived from a command
+// name, e.g. "DB_TOOL_" for "db-tool".
+func envPrefix(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name)) + "_"
+}
+
+// renameEnvs replaces the prefix from by to in the environment variables
+// of the options of c and its descendants.
+func (c *Command) renameEnvs(from, to string) {
+	for _, opts := range []OptionSet{c.Options, c.PersistentOptions} {
+		for i := range opts {
+			for j, env := range opts[i].Envs {
+				if rest, ok := strings.CutPrefix(env, from); ok {
+					opts[i].Envs[j] = to + rest
+				}
+			}
+		}
+	}
+	for _, child := range c.Children {
+		child.renameEnvs(from, to)
+	}
+}
+
+// Mount grafts the command tree sub under c at the space separated path,
+// e.g. "db" so that `app db ...` runs sub, like the package-level Mount.
+// Existing commands along the path are reused as parents, missing ones
+// become plain group commands. It fails if a command already exists at
+// path or path is empty.
+func (c *Command) Mount(path string, sub *Command) error {
+	segments := strings.Fields(path)
+	if len(segments) == 0 {
+		return fmt.Errorf("mount path is empty")
+	}
+
+	// Descend through existing commands; the package-level Mount creates
+	// the remaining groups.
+	parent := c
+	for len(segments) > 1 {
+		child, ok := parent.children()[segments[0]]
+		if !ok {
+			break
+		}
+		child.parent = parent
+		parent = child
+		segments = segments[1:]
+	}
+	if len(segments) == 1 {
+		if _, ok := parent.children()[segments[0]]; ok {
+			return fmt.Errorf("cannot mount at %q: command %q already exists", path, parent.FullName()+" "+segments[0])
+		}
+	}
+
+	top := Mount(strings.Join(segments, " "), sub)
+	top.parent = parent
+	parent.Children = append(parent.Children, top)
+	return nil
+}
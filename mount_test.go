@@ -0,0 +1,150 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	var strict bool
+	newLint := func() *Command {
+		lint := &Command{
+			Use:   "lint [path]",
+			Short: "Lint sources.",
+			Options: OptionSet{
+				{Flag: "strict", Envs: []string{"LINT_STRICT"}, Value: BoolOf(&strict)},
+				{Flag: "home", Envs: []string{"HOME_DIR"}, Value: StringOf(new(string))},
+			},
+		}
+		lint.Children = append(lint.Children, &Command{
+			Use: "check",
+			Handler: func(ctx context.Context, inv *Invocation) error {
+				_, _ = fmt.Fprintf(inv.Stdout, "%s strict=%v", inv.Command.FullName(), strict)
+				return nil
+			},
+		})
+		return lint
+	}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		args    []string
+		environ []string
+		want    string
+		wantEnv string
+	}{
+		{name: "single segment", prefix: "lint", args: []string{"lint", "check", "--strict"}, want: "umbrella lint check strict=true", wantEnv: "LINT_STRICT"},
+		{name: "renamed", prefix: "golint", args: []string{"golint", "check"}, environ: []string{"GOLINT_STRICT=true"}, want: "umbrella golint check strict=true", wantEnv: "GOLINT_STRICT"},
+		{name: "nested path", prefix: "tools go lint", args: []string{"tools", "go", "lint", "check"}, want: "umbrella tools go lint check strict=false", wantEnv: "TOOLS_GO_LINT_STRICT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strict = false
+			lint := newLint()
+			// A tree that already ran standalone carries its own globals.
+			if err := lint.init(); err != nil {
+				t.Fatal(err)
+			}
+			options := len(lint.Options)
+
+			umbrella := &Command{Use: "umbrella"}
+			umbrella.Children = append(umbrella.Children, Mount(tt.prefix, lint))
+
+			stdout := &bytes.Buffer{}
+			inv := umbrella.Invoke(tt.args...).WithEnviron(tt.environ)
+			inv.Stdout = stdout
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("output = %q, want %q", stdout.String(), tt.want)
+			}
+
+			mounted := umbrella
+			for _, name := range strings.Fields(tt.prefix) {
+				mounted = mounted.children()[name]
+			}
+			envs := map[string]string{}
+			for _, opt := range mounted.Options {
+				if opt.builtin != "" {
+					t.Fatalf("mounted root kept built-in flag %q", opt.Flag)
+				}
+				envs[opt.Flag] = strings.Join(opt.Envs, ",")
+			}
+			if envs["strict"] != tt.wantEnv || envs["home"] != "HOME_DIR" {
+				t.Fatalf("envs = %v, want strict=%s and home unchanged", envs, tt.wantEnv)
+			}
+
+			// The foreign tree itself is left as is.
+			if lint.Use != "lint [path]" || lint.parent != nil || len(lint.Options) != options {
+				t.Fatalf("Mount modified the foreign tree: %+v", lint)
+			}
+			for _, opt := range lint.Options {
+				if opt.Flag == "strict" && opt.Envs[0] != "LINT_STRICT" {
+					t.Fatalf("Mount renamed the env of the foreign tree to %q", opt.Envs[0])
+				}
+			}
+		})
+	}
+}
+
+func TestCommandMount(t *testing.T) {
+	newDBTool := func() *Command {
+		return &Command{
+			Use:   "dbtool",
+			Short: "Database tools.",
+			Children: []*Command{{
+				Use: "migrate",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					_, _ = inv.Stdout.Write([]byte(inv.Command.FullName()))
+					return nil
+				},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "top level", path: "db", args: []string{"db", "migrate"}, want: "app db migrate"},
+		{name: "existing group", path: "data db", args: []string{"data", "db", "migrate"}, want: "app data db migrate"},
+		{name: "new groups", path: "x y db", args: []string{"x", "y", "db", "migrate"}, want: "app x y db migrate"},
+		{name: "taken", path: "data", wantErr: `command "app data" already exists`},
+		{name: "empty", path: " ", wantErr: "mount path is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Command{Use: "app", Children: []*Command{{Use: "data", Short: "Data commands."}}}
+			err := root.Mount(tt.path, newDBTool())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mount: %v", err)
+			}
+
+			stdout := &bytes.Buffer{}
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = stdout
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Fatalf("FullName = %q, want %q", stdout.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package redant
+
+import "strings"
+
+// MultiCallNames returns the executable names that dispatch to a
+// subcommand of c when used as argv[0]: the name and aliases of each
+// visible child, in order. Each is a name a symlink to the binary can take
+// for busybox-style invocation, see Invocation.WithOS.
+func (c *Command) MultiCallNames() []string {
+	var names []string
+	seen := map[string]bool{c.Name(): true}
+	for _, child := range c.Children {
+		if child.Hidden {
+			continue
+		}
+		for _, name := range append([]string{child.Name()}, child.Aliases...) {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
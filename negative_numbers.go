@@ -0,0 +1,89 @@
+package redant
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// negativeNumberMark prefixes the placeholders standing for negative number
+// args while flags are parsed. It cannot appear in real arguments.
+const negativeNumberMark = "\x00redant-negative-number\x00"
+
+// isNegativeNumber reports whether s is a number like -5 or -1.5.
+func isNegativeNumber(s string) bool {
+	if len(s) < 2 || s[0] != '-' || (s[1] < '0' || s[1] > '9') && s[1] != '.' {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// protectNegativeNumbers hides negative number args from the flag parser,
+// which would otherwise parse `-5` as a shorthand flag, so they stay
+// positional values. It only applies to commands declaring a numeric arg;
+// elsewhere `-5` remains a flag. Numbers given as flag values and numbers
+// that are declared shorthands are left alone. restore maps the parsed
+// positional args back.
+func (inv *Invocation) protectNegativeNumbers(args []string) (protected []string, restore func([]string) []string) {
+	if !inv.Command.hasNumericArg() {
+		return args, func(parsed []string) []string { return parsed }
+	}
+	fs := inv.Flags
+	var originals []string
+	protected = make([]string, len(args))
+	for i, arg := range args {
+		protected[i] = arg
+		if arg == "--" {
+			copy(protected[i:], args[i:])
+			break
+		}
+		if !isNegativeNumber(arg) || fs.ShorthandLookup(arg[1:2]) != nil {
+			continue
+		}
+		if i > 0 && flagExpectsValue(fs, args[i-1]) {
+			continue
+		}
+		protected[i] = negativeNumberMark + strconv.Itoa(len(originals))
+		originals = append(originals, arg)
+	}
+
+	restore = func(parsed []string) []string {
+		if len(originals) == 0 {
+			return parsed
+		}
+		out := make([]string, len(parsed))
+		for i, arg := range parsed {
+			out[i] = arg
+			if idx, ok := strings.CutPrefix(arg, negativeNumberMark); ok {
+				if n, err := strconv.Atoi(idx); err == nil && n < len(originals) {
+					out[i] = originals[n]
+				}
+			}
+		}
+		return out
+	}
+	return protected, restore
+}
+
+// hasNumericArg reports whether c declares an arg with a numeric value.
+func (c *Command) hasNumericArg() bool {
+	return slices.ContainsFunc(c.Args, func(a Arg) bool {
+		return a.Value != nil && isNumericType(a.Value.Type())
+	})
+}
+
+// flagExpectsValue reports whether arg is a flag taking the next arg as its
+// value, e.g. `--offset` or `-o` but not `--offset=1` or a bool flag.
+func flagExpectsValue(fs *FlagSet, arg string) bool {
+	if !strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") || arg == "-" || arg == "--" {
+		return false
+	}
+	var f *Flag
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		f = fs.Lookup(name)
+	} else {
+		f = fs.ShorthandLookup(arg[len(arg)-1:])
+	}
+	return f != nil && f.NoOptDefVal == ""
+}
@@ -1,9 +1,12 @@
 package redant
 
 import (
+	"fmt"
 	"os"
-
-	"github.com/spf13/pflag"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Option is a configuration option for a CLI application.
@@ -14,6 +17,10 @@ type Option struct {
 
 	Description string `json:"description,omitempty"`
 
+	// Example is a sample usage of the flag shown under its description in
+	// help, e.g. `--selector app=web,env=prod`, for complex value syntaxes.
+	Example string `json:"example,omitempty"`
+
 	// Required means this value must be set by some means. It requires
 	// `ValueSourceType != ValueSourceNone`
 	// If `Default` is set, then `Required` is ignored.
@@ -28,22 +35,72 @@ type Option struct {
 	// If unset, environment configuring is disabled.
 	Envs []string `json:"env,omitempty"`
 
+	// EnvSeparator splits env values of array options into items. When
+	// empty, items are separated by commas. Bool options accept 1/0,
+	// true/false, yes/no and on/off from env.
+	EnvSeparator string `json:"envSeparator,omitempty"`
+
 	// Default is parsed into Value if set.
 	Default string `json:"default,omitempty"`
 
+	// DefaultText replaces the default shown in help with a description
+	// such as "current directory" or "auto-detected". It does not change
+	// the default applied, which may then be computed by the handler.
+	DefaultText string `json:"defaultText,omitempty"`
+
+	// Min and Max bound numeric values (int64, float64 and duration) and are
+	// checked after parsing, whatever the value source. Bounds use the same
+	// encoding as the value, e.g. "1", "0.5" or "30s".
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+
 	// Value includes the types listed in values.go.
-	Value pflag.Value `json:"value,omitempty"`
+	Value Value `json:"value,omitempty"`
 
 	Hidden bool `json:"hidden,omitempty"`
 
 	Deprecated string
 
+	// RemovedIn is the application version in which a deprecated option is
+	// removed. Once the root command's Version reaches it, using the flag
+	// fails instead of printing a warning.
+	RemovedIn string `json:"removedIn,omitempty"`
+
 	Category string
 
 	// Action is called after the flag is parsed and set.
 	// It receives the flag value and can perform additional validation or side effects.
 	// If Action returns an error, command execution will fail.
-	Action func(val pflag.Value) error `json:"-"`
+	Action func(val Value) error `json:"-"`
+
+	// NoSplit makes an array flag take each value literally instead of
+	// splitting it on commas: `--header "a, b"` adds a single item and only
+	// repeating the flag appends more, as curl and kubectl do.
+	NoSplit bool `json:"noSplit,omitempty"`
+
+	// ExpandEnv applies $VAR / ${VAR} and leading ~ expansion to values
+	// from flags, env vars and Default before they are set, so e.g.
+	// `--path $HOME/data` works even when the shell did not expand it.
+	ExpandEnv bool `json:"expandEnv,omitempty"`
+
+	// Secret marks a value that must not be shown, such as a token: it is
+	// prompted for without echo and redacted by --explain.
+	Secret bool `json:"secret,omitempty"`
+
+	// Validate is called once all flags and args are parsed, whether or not
+	// the option was set, and can inspect the whole invocation for
+	// cross-flag checks (e.g. --replicas must not exceed --max-replicas).
+	Validate func(inv *Invocation, val Value) error `json:"-"`
+
+	// bundle names the Command.Bundles entry that added the option.
+	bundle string
+
+	// feature is the toggle enabled by options created with FeatureOption.
+	feature Feature
+
+	// builtin identifies options created by GlobalFlags(), so the framework
+	// can recognize them even after SetGlobalFlags renamed them.
+	builtin string
 }
 
 // OptionSet is a group of options that can be applied to a command.
@@ -73,12 +130,261 @@ func (o Option) Type() string {
 	return "string"
 }
 
-func (optSet *OptionSet) FlagSet(name string) *pflag.FlagSet {
+// IsSecret reports whether the option's value must not be shown: it is
+// marked Secret or its name looks sensitive, e.g. "api-token".
+func (o Option) IsSecret() bool {
+	return o.Secret || IsSensitiveName(o.name())
+}
+
+// IsBuiltin reports whether the option is one of the built-in global flags
+// added by redant, such as --help or --list-commands, rather than declared
+// by the application.
+func (o Option) IsBuiltin() bool {
+	return o.builtin != ""
+}
+
+// name returns the identifier of the option: its flag, or first env name.
+func (o Option) name() string {
+	if o.Flag == "" && len(o.Envs) > 0 {
+		return o.Envs[0]
+	}
+	return o.Flag
+}
+
+// sources lists where the option's value can come from, in the form shown
+// to users: "--flag" and "$ENV".
+func (o Option) sources() []string {
+	var out []string
+	if o.Flag != "" {
+		out = append(out, "--"+o.Flag)
+	}
+	for _, env := range o.Envs {
+		out = append(out, "$"+env)
+	}
+	return out
+}
+
+// isNumericType reports whether Min/Max bounds apply to values of typ.
+func isNumericType(typ string) bool {
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "duration":
+		return true
+	}
+	return false
+}
+
+// parseNumeric parses s as a number for values of typ.
+func parseNumeric(typ, s string) (float64, error) {
+	if typ == "duration" {
+		d, err := time.ParseDuration(s)
+		return float64(d), err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// setFromEnv sets val from the raw env value using env semantics: bools
+// accept common truthy/falsy words and arrays are split on EnvSeparator.
+// Values are expanded first for options with ExpandEnv.
+func (o Option) setFromEnv(val Value, raw string, lookupEnv func(string) (string, bool)) error {
+	if sv, ok := val.(SliceValue); ok {
+		sep := o.EnvSeparator
+		if sep == "" {
+			sep = ","
+		}
+		var items []string
+		for _, item := range strings.Split(raw, sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				if o.ExpandEnv {
+					item = expandShell(item, lookupEnv)
+				}
+				items = append(items, item)
+			}
+		}
+		return sv.Replace(items)
+	}
+	if o.ExpandEnv {
+		raw = expandShell(raw, lookupEnv)
+	}
+	if val.Type() == "bool" {
+		b, err := parseEnvBool(raw)
+		if err != nil {
+			return err
+		}
+		return val.Set(strconv.FormatBool(b))
+	}
+	return val.Set(raw)
+}
+
+func parseEnvBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, nil
+	case "0", "f", "false", "n", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value %q", s)
+}
+
+// wrapValue decorates val according to the option's NoSplit setting.
+func (o Option) wrapValue(val Value) Value {
+	if sv, ok := val.(SliceValue); ok && o.NoSplit {
+		val = &literalSliceValue{Value: val, slice: sv}
+	}
+	return val
+}
+
+// expandEnvAnnotation marks the flags of options with ExpandEnv, whose
+// command line values are expanded by Invocation.setFlag.
+const expandEnvAnnotation = "redant_expand_env"
+
+// set sets val from s, expanding it first when the option has ExpandEnv.
+func (o Option) set(val Value, s string, lookupEnv func(string) (string, bool)) error {
+	if !o.ExpandEnv {
+		return val.Set(s)
+	}
+	return setExpanded(val, s, val.Set, lookupEnv)
+}
+
+// setExpanded sets val from s through set, with env references and ~
+// expanded. The Value itself is left as is, so handlers and hooks still
+// see the type they declared. Items of array values are expanded after
+// set split them, so each item gets ~ expansion.
+func setExpanded(val Value, s string, set func(string) error, lookupEnv func(string) (string, bool)) error {
+	sv, ok := val.(SliceValue)
+	if !ok {
+		return set(expandShell(s, lookupEnv))
+	}
+	before := slices.Clone(sv.GetSlice())
+	if err := set(s); err != nil {
+		return err
+	}
+	items := slices.Clone(sv.GetSlice())
+	start := len(before)
+	if start > len(items) || !slices.Equal(items[:start], before) {
+		// The first value of a flag replaces its default.
+		start = 0
+	}
+	for i := start; i < len(items); i++ {
+		items[i] = expandShell(items[i], lookupEnv)
+	}
+	return sv.Replace(items)
+}
+
+// parseFlags parses args into inv.Flags like FlagSet.Parse, setting
+// values through setFlag.
+func (inv *Invocation) parseFlags(args []string) error {
+	return inv.Flags.ParseAll(args, inv.setFlag)
+}
+
+// setFlag sets a flag parsed from the command line, expanding the value
+// first for options with ExpandEnv, and notes it as set on the command
+// line.
+func (inv *Invocation) setFlag(flag *Flag, value string) error {
+	if inv.argvFlags == nil {
+		inv.argvFlags = make(map[string]bool)
+	}
+	inv.argvFlags[flag.Name] = true
+	set := func(s string) error { return inv.Flags.Set(flag.Name, s) }
+	if _, ok := flag.Annotations[expandEnvAnnotation]; !ok {
+		return set(value)
+	}
+	return setExpanded(flag.Value, value, set, inv.LookupEnv)
+}
+
+// expandShell expands env references and ~ in a raw value. Unset variables
+// expand to the empty string, as in a shell.
+func expandShell(s string, lookupEnv func(string) (string, bool)) string {
+	getenv := func(key string) string {
+		v, _ := lookupEnv(key)
+		return v
+	}
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		home := getenv("HOME")
+		if home == "" {
+			home, _ = os.UserHomeDir()
+		}
+		if home != "" {
+			s = home + s[1:]
+		}
+	}
+	return os.Expand(s, getenv)
+}
+
+// literalSliceValue appends each Set value as a single item, used for
+// options with NoSplit.
+type literalSliceValue struct {
+	Value
+	slice SliceValue
+}
+
+var _ SliceValue = (*literalSliceValue)(nil)
+
+func (v *literalSliceValue) Set(s string) error { return v.slice.Append(s) }
+
+func (v *literalSliceValue) Append(s string) error { return v.slice.Append(s) }
+
+func (v *literalSliceValue) Replace(ss []string) error { return v.slice.Replace(ss) }
+
+func (v *literalSliceValue) GetSlice() []string { return v.slice.GetSlice() }
+
+// validateRange checks that Min and Max are usable for the option.
+func (o Option) validateRange() error {
+	if o.Min == "" && o.Max == "" {
+		return nil
+	}
+	typ := o.Type()
+	if !isNumericType(typ) {
+		return fmt.Errorf("option %q: min/max require a numeric value, got %s", o.name(), typ)
+	}
+	for _, bound := range []string{o.Min, o.Max} {
+		if bound == "" {
+			continue
+		}
+		if _, err := parseNumeric(typ, bound); err != nil {
+			return fmt.Errorf("option %q: invalid bound %q: %w", o.name(), bound, err)
+		}
+	}
+	return nil
+}
+
+// checkRange checks val against the option's Min and Max.
+func (o Option) checkRange(val Value) error {
+	if o.Min == "" && o.Max == "" {
+		return nil
+	}
+	typ := o.Type()
+	v, err := parseNumeric(typ, val.String())
+	if err != nil {
+		return fmt.Errorf("option %q: %w", o.name(), err)
+	}
+	if o.Min != "" {
+		if lo, err := parseNumeric(typ, o.Min); err == nil && v < lo {
+			return fmt.Errorf("option %q: value %s is less than min %s", o.name(), val.String(), o.Min)
+		}
+	}
+	if o.Max != "" {
+		if hi, err := parseNumeric(typ, o.Max); err == nil && v > hi {
+			return fmt.Errorf("option %q: value %s is greater than max %s", o.name(), val.String(), o.Max)
+		}
+	}
+	return nil
+}
+
+// FlagSet builds a FlagSet for the options, applying defaults and
+// values from the process environment.
+func (optSet *OptionSet) FlagSet(name string) *FlagSet {
+	return optSet.flagSet(name, os.LookupEnv)
+}
+
+// flagSet is FlagSet with env values resolved through lookupEnv.
+func (optSet *OptionSet) flagSet(name string, lookupEnv func(string) (string, bool)) *FlagSet {
 	if optSet == nil {
-		return &pflag.FlagSet{}
+		return NewFlagSet(name)
 	}
 
-	fs := pflag.NewFlagSet(name, pflag.PanicOnError)
+	fs := NewFlagSet(name)
 	for _, opt := range *optSet {
 		if opt.Flag == "" {
 			continue
@@ -96,29 +402,35 @@ func (optSet *OptionSet) FlagSet(name string) *pflag.FlagSet {
 		if val == nil {
 			val = DiscardValue
 		}
+		if opt.builtin != "" {
+			val = freshBuiltinValue(val)
+		}
+		val = opt.wrapValue(val)
 
 		// Apply default value to the Value before adding the flag
 		if opt.Default != "" && val != DiscardValue {
-			_ = val.Set(opt.Default) // Ignore error, will be caught during validation
+			_ = opt.set(val, opt.Default, lookupEnv) // Ignore error, will be caught during validation
+		}
+
+		var annotations map[string][]string
+		if opt.ExpandEnv && val != DiscardValue {
+			annotations = map[string][]string{expandEnvAnnotation: {"true"}}
 		}
 
-		fs.AddFlag(&pflag.Flag{
+		fs.AddFlag(&Flag{
 			Name:        opt.Flag,
 			Shorthand:   opt.Shorthand,
 			Usage:       opt.Description,
 			Value:       val,
 			DefValue:    opt.Default,
 			Changed:     false,
-			Deprecated:  opt.Deprecated,
+			Deprecated:  deprecationSchedule(opt.Deprecated, opt.RemovedIn),
 			NoOptDefVal: noOptDefValue,
 			Hidden:      opt.Hidden,
+			Annotations: annotations,
 		})
 	}
 
-	fs.Usage = func() {
-		_, _ = os.Stderr.WriteString("Override (*FlagSet).Usage() to print help text.\n")
-	}
-
 	// Read environment variables and set flag values
 	// Use the first non-empty environment variable value
 	for _, opt := range *optSet {
@@ -128,9 +440,9 @@ func (optSet *OptionSet) FlagSet(name string) *pflag.FlagSet {
 
 		// Try each environment variable in order, use the first non-empty one
 		for _, envName := range opt.Envs {
-			if envValue := os.Getenv(envName); envValue != "" {
+			if envValue, _ := lookupEnv(envName); envValue != "" {
 				if flag := fs.Lookup(opt.Flag); flag != nil {
-					if err := flag.Value.Set(envValue); err == nil {
+					if err := opt.setFromEnv(flag.Value, envValue, lookupEnv); err == nil {
 						flag.Changed = true
 						break // Use the first non-empty value
 					}
@@ -141,3 +453,24 @@ func (optSet *OptionSet) FlagSet(name string) *pflag.FlagSet {
 
 	return fs
 }
+
+// freshBuiltinValue returns a new value of the kind of the built-in global
+// flag value val, so that each invocation parses built-ins into its own
+// state instead of the values shared through the root command's options.
+func freshBuiltinValue(val Value) Value {
+	switch v := val.(type) {
+	case *Bool:
+		return BoolOf(new(bool))
+	case *String:
+		return StringOf(new(string))
+	case *StringArray:
+		return StringArrayOf(new([]string))
+	case *countValue:
+		return new(countValue)
+	case *listCommandsValue:
+		return new(listCommandsValue)
+	case *Enum:
+		return &Enum{Choices: v.Choices, ChoicesFunc: v.ChoicesFunc, Value: new(string)}
+	}
+	return val
+}
@@ -0,0 +1,136 @@
+package redant
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+
+	"github.com/pubgo/redant/internal/pretty"
+)
+
+// Verbosity levels returned by Invocation.Verbosity.
+const (
+	VerbosityQuiet   = -1
+	VerbosityNormal  = 0
+	VerbosityVerbose = 1
+	VerbosityDebug   = 2
+)
+
+// Verbosity returns the output level selected by the built-in --quiet and
+// --verbose flags: VerbosityQuiet under --quiet, otherwise the number of
+// times --verbose was given, VerbosityDebug from "--verbose --verbose" or
+// "--verbose=2".
+func (inv *Invocation) Verbosity() int {
+	if inv.builtinBool(builtinQuiet) {
+		return VerbosityQuiet
+	}
+	name := inv.Command.builtinFlagName(builtinVerbose)
+	if inv.Flags == nil || name == "" {
+		return VerbosityNormal
+	}
+	if f := inv.Flags.Lookup(name); f != nil {
+		if n, err := strconv.Atoi(f.Value.String()); err == nil {
+			return n
+		}
+	}
+	return VerbosityNormal
+}
+
+// Printf writes to Stdout unless --quiet was given. Handlers use it for
+// regular output, leaving Stdout to data when the user asks for quiet.
+func (inv *Invocation) Printf(format string, args ...any) {
+	if inv.Verbosity() > VerbosityQuiet {
+		_, _ = fmt.Fprintf(inv.Stdout, format, args...)
+	}
+}
+
+// Errorf writes an "error: " line to Stderr, colored when Stderr is a
+// terminal. It is never suppressed.
+func (inv *Invocation) Errorf(format string, args ...any) {
+	inv.printLine(inv.Stderr, pretty.Style{pretty.FgColor(termenv.ANSIRed), pretty.Bold()}, inv.localize("error")+": ", format, args)
+}
+
+// Verbosef writes a line to Stderr when --verbose was given.
+func (inv *Invocation) Verbosef(format string, args ...any) {
+	if inv.Verbosity() >= VerbosityVerbose {
+		inv.printLine(inv.Stderr, nil, "", format, args)
+	}
+}
+
+// Debugf writes a "debug: " line to Stderr, faint on a terminal, when
+// --verbose was given twice.
+func (inv *Invocation) Debugf(format string, args ...any) {
+	if inv.Verbosity() >= VerbosityDebug {
+		inv.printLine(inv.Stderr, pretty.Style{pretty.CSI(termenv.FaintSeq)}, inv.localize("debug")+": ", format, args)
+	}
+}
+
+// printLine writes prefix and the formatted message to w as one line,
+// styling the prefix when w supports color.
+func (inv *Invocation) printLine(w io.Writer, style pretty.Style, prefix, format string, args []any) {
+	msg := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	if prefix != "" && len(style) > 0 && inv.colorEnabled(w) {
+		txt := pretty.String(prefix)
+		style.Format(txt)
+		prefix = txt.String()
+	}
+	_, _ = fmt.Fprintf(w, "%s%s\n", prefix, msg)
+}
+
+// colorEnabled reports whether output to w may be colored: w is a
+// terminal, --no-color was not given, NO_COLOR is unset and TERM is not
+// "dumb".
+func (inv *Invocation) colorEnabled(w io.Writer) bool {
+	if inv.builtinBool(builtinNoColor) {
+		return false
+	}
+	if _, ok := inv.LookupEnv("NO_COLOR"); ok || inv.Getenv("TERM") == "dumb" {
+		return false
+	}
+	_, ok := terminalFd(w)
+	return ok
+}
+
+// terminalFd returns the file descriptor of v, a Stdin, Stdout or Stderr,
+// when it is a terminal.
+func terminalFd(v any) (int, bool) {
+	f, ok := v.(interface{ Fd() uintptr })
+	if !ok {
+		return 0, false
+	}
+	fd := int(f.Fd())
+	return fd, term.IsTerminal(fd)
+}
+
+// countValue is the value of a flag counting its occurrences, like
+// --verbose: given without a value it increments, "--verbose=2" sets 2.
+type countValue int64
+
+func (c *countValue) Set(s string) error {
+	if s == "+1" {
+		*c++
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*c = countValue(n)
+	return nil
+}
+
+func (*countValue) NoOptDefValue() string {
+	return "+1"
+}
+
+func (c countValue) String() string {
+	return strconv.FormatInt(int64(c), 10)
+}
+
+func (countValue) Type() string {
+	return "count"
+}
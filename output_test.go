@@ -0,0 +1,81 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestOutputHelpers(t *testing.T) {
+	root := &Command{
+		Use: "app",
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			inv.Printf("result %d\n", 42)
+			inv.Verbosef("connecting to %s", "db")
+			inv.Debugf("query took %dms", 3)
+			inv.Errorf("%s failed", "cleanup")
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		wantVerbosity int
+		wantStdout    string
+		wantStderr    string
+	}{
+		{
+			name:       "normal",
+			wantStdout: "result 42\n",
+			wantStderr: "error: cleanup failed\n",
+		},
+		{
+			name:          "quiet",
+			args:          []string{"--quiet", "--verbose"},
+			wantVerbosity: VerbosityQuiet,
+			wantStderr:    "error: cleanup failed\n",
+		},
+		{
+			name:          "verbose",
+			args:          []string{"--verbose"},
+			wantVerbosity: VerbosityVerbose,
+			wantStdout:    "result 42\n",
+			wantStderr:    "connecting to db\nerror: cleanup failed\n",
+		},
+		{
+			name:          "debug",
+			args:          []string{"--verbose", "--verbose"},
+			wantVerbosity: VerbosityDebug,
+			wantStdout:    "result 42\n",
+			wantStderr:    "connecting to db\ndebug: query took 3ms\nerror: cleanup failed\n",
+		},
+		{
+			name:          "debug level",
+			args:          []string{"--verbose=2"},
+			wantVerbosity: VerbosityDebug,
+			wantStdout:    "result 42\n",
+			wantStderr:    "connecting to db\ndebug: query took 3ms\nerror: cleanup failed\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = &stdout
+			inv.Stderr = &stderr
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if got := inv.Verbosity(); got != tt.wantVerbosity {
+				t.Errorf("verbosity %d, want %d", got, tt.wantVerbosity)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
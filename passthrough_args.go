@@ -0,0 +1,59 @@
+package redant
+
+import "strings"
+
+// splitPassthroughArgs separates the flags of fs from args for commands
+// with PassthroughArgs. flagArgs holds the declared flags and their values;
+// rest keeps every other arg, unknown flags included, in order. Flag
+// parsing stops at "--", which is dropped.
+func splitPassthroughArgs(fs *FlagSet, args []string) (flagArgs, rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+		declared, needsValue := declaredFlagArg(fs, arg)
+		if !declared {
+			rest = append(rest, arg)
+			continue
+		}
+		flagArgs = append(flagArgs, arg)
+		if needsValue && i+1 < len(args) {
+			i++
+			flagArgs = append(flagArgs, args[i])
+		}
+	}
+	return flagArgs, rest
+}
+
+// declaredFlagArg reports whether arg is "--name[=value]" or a group of
+// shorthands "-abc" naming only flags of fs, and whether the next arg is
+// its value. A shorthand taking a value ends the group, the remainder
+// being its value as in "-ofile".
+func declaredFlagArg(fs *FlagSet, arg string) (declared, needsValue bool) {
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		name, _, hasValue := strings.Cut(name, "=")
+		f := fs.Lookup(name)
+		if name == "" || f == nil {
+			return false, false
+		}
+		return true, !hasValue && f.NoOptDefVal == ""
+	}
+	if len(arg) < 2 || arg[0] != '-' {
+		return false, false
+	}
+	for i := 1; i < len(arg); i++ {
+		f := fs.ShorthandLookup(arg[i : i+1])
+		if f == nil {
+			return false, false
+		}
+		if f.NoOptDefVal == "" {
+			return true, i == len(arg)-1
+		}
+		if i+1 < len(arg) && arg[i+1] == '=' {
+			return true, false
+		}
+	}
+	return true, false
+}
@@ -0,0 +1,100 @@
+package redant
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPassthroughArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantArgs    []string
+		wantName    string
+		wantDetach  bool
+		wantVerbose bool
+	}{
+		{
+			name:     "unknown flags kept in order",
+			args:     []string{"run", "--name", "web", "nginx", "-p", "80:80", "--rm"},
+			wantArgs: []string{"nginx", "-p", "80:80", "--rm"},
+			wantName: "web",
+		},
+		{
+			name:       "shorthand group",
+			args:       []string{"run", "-dn", "db", "postgres", "-x"},
+			wantArgs:   []string{"postgres", "-x"},
+			wantName:   "db",
+			wantDetach: true,
+		},
+		{
+			name:     "inline values",
+			args:     []string{"run", "--name=api", "-nweb2", "img"},
+			wantArgs: []string{"img"},
+			wantName: "web2",
+		},
+		{
+			name:     "mixed group is unknown",
+			args:     []string{"run", "-dz", "img"},
+			wantArgs: []string{"-dz", "img"},
+		},
+		{
+			name:        "global and parent flags",
+			args:        []string{"--verbose", "run", "img", "--verbose", "-1"},
+			wantArgs:    []string{"img", "-1"},
+			wantVerbose: true,
+		},
+		{
+			name:     "double dash",
+			args:     []string{"run", "img", "--", "--name", "x"},
+			wantArgs: []string{"img", "--name", "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var name string
+			var detach, verbose bool
+			var gotArgs []string
+			root := &Command{
+				Use:     "app",
+				Options: OptionSet{{Flag: "verbose", Value: BoolOf(&verbose)}},
+			}
+			root.Children = append(root.Children, &Command{
+				Use:             "run",
+				PassthroughArgs: true,
+				Options: OptionSet{
+					{Flag: "name", Shorthand: "n", Value: StringOf(&name)},
+					{Flag: "detach", Shorthand: "d", Value: BoolOf(&detach)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					gotArgs = inv.Args
+					return nil
+				},
+			})
+			if err := root.Invoke(tt.args...).Run(); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if !slices.Equal(gotArgs, tt.wantArgs) {
+				t.Fatalf("args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+			if name != tt.wantName || detach != tt.wantDetach || verbose != tt.wantVerbose {
+				t.Fatalf("name=%q detach=%v verbose=%v", name, detach, verbose)
+			}
+		})
+	}
+}
+
+func TestPassthroughArgsRequiresDeclaredValue(t *testing.T) {
+	cmd := &Command{
+		Use:             "run",
+		PassthroughArgs: true,
+		Options:         OptionSet{{Flag: "name", Value: StringOf(new(string))}},
+		Handler:         func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	err := cmd.Invoke("--name").Run()
+	if err == nil || !strings.Contains(err.Error(), "flag needs an argument") {
+		t.Fatalf("expected missing value error, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package redant
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pubgo/redant/internal/gitshell"
+)
+
+// PinToRepoRoot returns a middleware that sets Invocation.Dir to the root
+// of the git repository (or worktree) containing the invocation's
+// directory for the duration of the handler, so repo-centric commands
+// behave the same from any subdirectory. The process working directory is
+// left untouched: handlers resolve files with inv.Path and run programs
+// with inv.Exec, which both follow Dir.
+func PinToRepoRoot() MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) error {
+			wd := inv.Dir
+			if wd == "" {
+				var err error
+				if wd, err = os.Getwd(); err != nil {
+					return err
+				}
+			}
+			root, err := gitshell.RepoRoot(wd)
+			if err != nil {
+				return fmt.Errorf("%s must be run inside a git repository: %w", inv.Command.FullName(), err)
+			}
+			prev := inv.Dir
+			inv.Dir = root
+			defer func() { inv.Dir = prev }()
+			return next(ctx, inv)
+		}
+	}
+}
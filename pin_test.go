@@ -0,0 +1,54 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPinToRepoRoot(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "pkg", "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	var dir, path string
+	cmd := &Command{
+		Use:        "build",
+		Middleware: PinToRepoRoot(),
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			dir, path = inv.Dir, inv.Path("go.mod")
+			return nil
+		},
+	}
+	inv := cmd.Invoke()
+	inv.Stdout = &bytes.Buffer{}
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	wantRoot, _ := filepath.EvalSymlinks(repo)
+	if got, _ := filepath.EvalSymlinks(dir); got != wantRoot {
+		t.Fatalf("handler ran in %q, want %q", dir, wantRoot)
+	}
+	if path != filepath.Join(dir, "go.mod") {
+		t.Fatalf("Path = %q, want it under %q", path, dir)
+	}
+	if inv.Dir != "" {
+		t.Fatalf("Dir not restored: %q", inv.Dir)
+	}
+	// The process working directory is never changed.
+	after, _ := os.Getwd()
+	wantSub, _ := filepath.EvalSymlinks(sub)
+	if got, _ := filepath.EvalSymlinks(after); got != wantSub {
+		t.Fatalf("working directory changed to %q", after)
+	}
+}
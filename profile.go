@@ -0,0 +1,87 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profileMiddleware returns middleware writing the profiles requested with
+// the hidden built-in --pprof-cpu, --pprof-mem and --trace flags, or nil
+// when none was given. Relative paths are resolved against Dir. The CPU profile and the execution trace cover the
+// rest of the chain and the handler; the heap profile is written once
+// they return.
+func (inv *Invocation) profileMiddleware() MiddlewareFunc {
+	cpuPath := inv.builtinString(builtinPprofCPU)
+	memPath := inv.builtinString(builtinPprofMem)
+	tracePath := inv.builtinString(builtinTrace)
+	if cpuPath == "" && memPath == "" && tracePath == "" {
+		return nil
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) (err error) {
+			if cpuPath != "" {
+				stop, err := startProfile(inv.Path(cpuPath), "CPU profile", pprof.StartCPUProfile, pprof.StopCPUProfile)
+				if err != nil {
+					return err
+				}
+				defer func() { err = errors.Join(err, stop()) }()
+			}
+			if tracePath != "" {
+				stop, err := startProfile(inv.Path(tracePath), "trace", trace.Start, trace.Stop)
+				if err != nil {
+					return err
+				}
+				defer func() { err = errors.Join(err, stop()) }()
+			}
+			if memPath != "" {
+				defer func() { err = errors.Join(err, writeHeapProfile(inv.Path(memPath))) }()
+			}
+			return next(ctx, inv)
+		}
+	}
+}
+
+// startProfile creates path and starts writing the profile called what to
+// it. The returned function stops the profile and closes the file.
+func startProfile(path, what string, start func(w io.Writer) error, stop func()) (func() error, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", what, err)
+	}
+	if err := start(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("starting %s: %w", what, err)
+	}
+	return func() error {
+		stop()
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("writing %s: %w", what, err)
+		}
+		return nil
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile, up to date as of a garbage
+// collection, to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating heap profile: %w", err)
+	}
+	runtime.GC()
+	err = pprof.WriteHeapProfile(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing heap profile: %w", err)
+	}
+	return nil
+}
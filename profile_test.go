@@ -0,0 +1,78 @@
+package redant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		// relative passes paths relative to the invocation Dir.
+		relative bool
+	}{
+		{name: "none"},
+		{name: "cpu", flags: []string{"pprof-cpu"}},
+		{name: "mem", flags: []string{"pprof-mem"}},
+		{name: "trace", flags: []string{"trace"}},
+		{name: "all", flags: []string{"pprof-cpu", "pprof-mem", "trace"}},
+		{name: "relative to dir", flags: []string{"pprof-cpu", "pprof-mem", "trace"}, relative: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var args []string
+			for _, flag := range tt.flags {
+				path := flag + ".out"
+				if !tt.relative {
+					path = filepath.Join(dir, path)
+				}
+				args = append(args, "--"+flag, path)
+			}
+			ran := false
+			root := &Command{
+				Use: "app",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					ran = true
+					return nil
+				},
+			}
+			inv := root.Invoke(args...)
+			if tt.relative {
+				inv.Dir = dir
+			}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if !ran {
+				t.Fatal("handler did not run")
+			}
+			for _, flag := range tt.flags {
+				info, err := os.Stat(filepath.Join(dir, flag+".out"))
+				if err != nil {
+					t.Fatalf("--%s: %v", flag, err)
+				}
+				if info.Size() == 0 {
+					t.Errorf("--%s wrote an empty file", flag)
+				}
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != len(tt.flags) {
+				t.Errorf("wrote %d files, want %d", len(entries), len(tt.flags))
+			}
+		})
+	}
+}
+
+func TestProfileFlagsCreateError(t *testing.T) {
+	root := &Command{
+		Use:     "app",
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	missing := filepath.Join(t.TempDir(), "missing", "cpu.out")
+	if err := root.Invoke("--pprof-cpu", missing).Run(); err == nil {
+		t.Fatal("want error for unwritable profile path")
+	}
+}
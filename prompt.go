@@ -0,0 +1,197 @@
+package redant
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// promptInput buffers the invocation's Stdin across prompts, so that a
+// line read ahead by one prompt is not lost to the next.
+type promptInput struct {
+	src    io.Reader
+	reader *bufio.Reader
+}
+
+// Prompt asks question on Stderr and returns the line read from Stdin,
+// trimmed of surrounding space, or def when the answer is empty. Reading
+// past the end of Stdin fails with an error wrapping io.EOF.
+func (inv *Invocation) Prompt(question, def string) (string, error) {
+	if def != "" {
+		question = fmt.Sprintf("%s [%s]", question, def)
+	}
+	answer, err := inv.ask(question)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// Confirm asks a yes/no question and returns the answer, def when the
+// answer is empty. Other answers than y, yes, n or no ask again.
+func (inv *Invocation) Confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := inv.ask(fmt.Sprintf("%s [%s]", question, hint))
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		_, _ = fmt.Fprintln(inv.Stderr, `Please answer "y" or "n".`)
+	}
+}
+
+// Select lists choices under question and returns the one picked by its
+// number or its text, or def, which must be one of the choices or empty,
+// when the answer is empty. Invalid answers ask again.
+func (inv *Invocation) Select(question string, choices []string, def string) (string, error) {
+	if len(choices) == 0 {
+		return "", errors.New("select: no choices")
+	}
+	_, _ = fmt.Fprintln(inv.Stderr, question)
+	for i, choice := range choices {
+		_, _ = fmt.Fprintf(inv.Stderr, "  %d) %s\n", i+1, choice)
+	}
+	label := "Choose"
+	if def != "" {
+		label = fmt.Sprintf("Choose [%s]", def)
+	}
+	for {
+		answer, err := inv.ask(label)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" && def != "" {
+			return def, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		if slices.Contains(choices, answer) {
+			return answer, nil
+		}
+		_, _ = fmt.Fprintf(inv.Stderr, "Please enter a number from 1 to %d.\n", len(choices))
+	}
+}
+
+// Password asks question and returns the answer without echoing it when
+// Stdin is a terminal. Otherwise the answer is read as a line, which keeps
+// the prompt scriptable and testable.
+func (inv *Invocation) Password(question string) (string, error) {
+	fd, ok := terminalFd(inv.Stdin)
+	if !ok {
+		return inv.ask(question)
+	}
+	_, _ = fmt.Fprintf(inv.Stderr, "%s: ", question)
+	b, err := term.ReadPassword(fd)
+	_, _ = fmt.Fprintln(inv.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return string(b), nil
+}
+
+// ask writes "question: " to Stderr and reads the answer line from Stdin.
+func (inv *Invocation) ask(question string) (string, error) {
+	_, _ = fmt.Fprintf(inv.Stderr, "%s: ", question)
+	line, err := inv.promptReader().ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("reading answer to %q: %w", question, err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptReader returns the buffered reader of Stdin shared by prompts.
+func (inv *Invocation) promptReader() *bufio.Reader {
+	if inv.promptInput == nil || inv.promptInput.src != inv.Stdin {
+		stdin := inv.Stdin
+		if stdin == nil {
+			stdin = strings.NewReader("")
+		}
+		inv.promptInput = &promptInput{src: inv.Stdin, reader: bufio.NewReader(stdin)}
+	}
+	return inv.promptInput.reader
+}
+
+// Interactive reports whether the invocation may prompt the user: Stdin is
+// a terminal and --non-interactive was not given.
+func (inv *Invocation) Interactive() bool {
+	if inv.builtinBool(builtinNonInteractive) {
+		return false
+	}
+	if inv.stdinTerminal != nil {
+		return *inv.stdinTerminal
+	}
+	_, ok := terminalFd(inv.Stdin)
+	return ok
+}
+
+// promptsMissing reports whether missing required options are prompted
+// for, see Command.PromptMissing.
+func (inv *Invocation) promptsMissing() bool {
+	for c := inv.Command; c != nil; c = c.parent {
+		if c.PromptMissing {
+			return inv.Interactive()
+		}
+	}
+	return false
+}
+
+// promptMissingOptions asks for the value of each of opts until a valid
+// one is given.
+func (inv *Invocation) promptMissingOptions(opts []Option) error {
+	for _, opt := range opts {
+		question := opt.name()
+		for {
+			var (
+				answer string
+				err    error
+			)
+			if opt.Secret {
+				answer, err = inv.Password(question)
+			} else {
+				answer, err = inv.ask(question)
+			}
+			if err != nil {
+				return err
+			}
+			if answer == "" {
+				continue
+			}
+			if err := inv.setPromptedOption(opt, answer); err != nil {
+				_, _ = fmt.Fprintf(inv.Stderr, "Invalid value: %v\n", err)
+				continue
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// setPromptedOption sets opt to the prompted value, through its flag when
+// it has one so that the flag counts as set.
+func (inv *Invocation) setPromptedOption(opt Option, value string) error {
+	if opt.Flag != "" && inv.Flags != nil && inv.Flags.Lookup(opt.Flag) != nil {
+		return inv.Flags.Set(opt.Flag, value)
+	}
+	return opt.Value.Set(value)
+}
@@ -0,0 +1,174 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPrompts(t *testing.T) {
+	type answers struct {
+		name     string
+		deploy   bool
+		region   string
+		password string
+	}
+	tests := []struct {
+		name       string
+		stdin      string
+		want       answers
+		wantErr    error
+		wantStderr string
+	}{
+		{
+			name:  "answers",
+			stdin: "web\nyes\n2\ns3cret\n",
+			want:  answers{name: "web", deploy: true, region: "eu", password: "s3cret"},
+		},
+		{
+			name:  "defaults",
+			stdin: "\n\n\nx",
+			want:  answers{name: "app", region: "us", password: "x"},
+		},
+		{
+			name:       "invalid answers ask again",
+			stdin:      "web\nmaybe\nn\n7\nasia\npw\n",
+			want:       answers{name: "web", region: "asia", password: "pw"},
+			wantStderr: "Name [app]: Deploy now? [y/N]: Please answer \"y\" or \"n\".\nDeploy now? [y/N]: Region\n  1) us\n  2) eu\n  3) asia\nChoose [us]: Please enter a number from 1 to 3.\nChoose [us]: Password: ",
+		},
+		{
+			name:    "end of input",
+			stdin:   "web\n",
+			want:    answers{name: "web"},
+			wantErr: io.EOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got answers
+			cmd := &Command{
+				Use: "init",
+				Handler: func(ctx context.Context, inv *Invocation) (err error) {
+					if got.name, err = inv.Prompt("Name", "app"); err != nil {
+						return err
+					}
+					if got.deploy, err = inv.Confirm("Deploy now?", false); err != nil {
+						return err
+					}
+					if got.region, err = inv.Select("Region", []string{"us", "eu", "asia"}, "us"); err != nil {
+						return err
+					}
+					got.password, err = inv.Password("Password")
+					return err
+				},
+			}
+
+			var stderr bytes.Buffer
+			inv := cmd.Invoke()
+			inv.Stdin = strings.NewReader(tt.stdin)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &stderr
+			err := inv.Run()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("answers %+v, want %+v", got, tt.want)
+			}
+			if tt.wantStderr != "" && stderr.String() != tt.wantStderr {
+				t.Fatalf("stderr %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestPromptMissingRequiredOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		terminal    bool
+		stdin       string
+		wantErr     string
+		wantToken   string
+		wantLevel   string
+		wantStderr  string
+		wantHandler bool
+	}{
+		{
+			name:        "prompted",
+			terminal:    true,
+			stdin:       "\nloud\nwarn\nt0k3n\n",
+			wantToken:   "t0k3n",
+			wantLevel:   "warn",
+			wantHandler: true,
+			wantStderr:  "level: level: Invalid value: invalid argument \"loud\" for \"--level\" flag: invalid choice: loud, should be one of [info warn]\nlevel: token: ",
+		},
+		{
+			name:        "only missing options",
+			args:        []string{"--level", "info"},
+			terminal:    true,
+			stdin:       "t0k3n\n",
+			wantToken:   "t0k3n",
+			wantLevel:   "info",
+			wantHandler: true,
+		},
+		{
+			name:    "not a terminal",
+			stdin:   "t0k3n\nwarn\n",
+			wantErr: "missing values for the required flags: level (checked --level), token (checked --token)",
+		},
+		{
+			name:     "non-interactive",
+			args:     []string{"--non-interactive"},
+			terminal: true,
+			stdin:    "t0k3n\nwarn\n",
+			wantErr:  "missing values for the required flags",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				token, level string
+				ran          bool
+			)
+			cmd := &Command{
+				Use:           "login",
+				PromptMissing: true,
+				Options: OptionSet{
+					{Flag: "token", Required: true, Secret: true, Value: StringOf(&token)},
+					{Flag: "level", Required: true, Value: EnumOf(&level, "info", "warn")},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					ran = true
+					return nil
+				},
+			}
+
+			var stderr bytes.Buffer
+			inv := cmd.Invoke(tt.args...)
+			inv.Stdin = strings.NewReader(tt.stdin)
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &stderr
+			inv.stdinTerminal = &tt.terminal
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if ran != tt.wantHandler || token != tt.wantToken || level != tt.wantLevel {
+				t.Fatalf("ran=%v token=%q level=%q", ran, token, level)
+			}
+			if tt.wantStderr != "" && stderr.String() != tt.wantStderr {
+				t.Fatalf("stderr %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package redantmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pubgo/redant"
+)
+
+var _ redant.Reporter = (*Pushgateway)(nil)
+
+// Pushgateway reports finished command runs to a Prometheus Pushgateway,
+// as batch jobs do. Each run replaces the metrics of the group
+// job/<job>/command/<command> with:
+//
+//	redant_command_duration_seconds{class="ok"} 1.5
+//	redant_command_last_run_timestamp_seconds{class="ok"} 1.7e+09
+//
+// Pushes run in the background: Finished waits for at most
+// pushFlushTimeout, so that a slow or unreachable gateway delays the exit
+// of the CLI only briefly. Push failures are ignored.
+type Pushgateway struct {
+	url    string
+	job    string
+	client *http.Client
+}
+
+// pushFlushTimeout bounds how long Finished waits for its push.
+const pushFlushTimeout = 250 * time.Millisecond
+
+// NewPushgateway returns a reporter pushing to the Pushgateway at baseURL,
+// e.g. "http://pushgateway:9091", under job.
+func NewPushgateway(baseURL, job string) *Pushgateway {
+	return &Pushgateway{
+		url:    strings.TrimRight(baseURL, "/"),
+		job:    job,
+		client: &http.Client{Timeout: time.Second},
+	}
+}
+
+// Started implements redant.Reporter. Only finished runs are pushed.
+func (p *Pushgateway) Started(context.Context, string) {}
+
+// Finished implements redant.Reporter.
+func (p *Pushgateway) Finished(ctx context.Context, command string, duration time.Duration, class redant.ErrorClass) {
+	var body bytes.Buffer
+	labels := fmt.Sprintf("{class=%q}", class)
+	fmt.Fprintf(&body, "# TYPE redant_command_duration_seconds gauge\n")
+	fmt.Fprintf(&body, "redant_command_duration_seconds%s %g\n", labels, duration.Seconds())
+	fmt.Fprintf(&body, "# TYPE redant_command_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&body, "redant_command_last_run_timestamp_seconds%s %d\n", labels, time.Now().Unix())
+
+	target := fmt.Sprintf("%s/metrics/job/%s/command/%s", p.url, url.PathEscape(p.job), url.PathEscape(command))
+	// The run may end because its context was canceled; still push.
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPut, target, &body)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return
+		}
+		_ = resp.Body.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(pushFlushTimeout):
+	}
+}
@@ -0,0 +1,81 @@
+package redantmetrics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pubgo/redant"
+)
+
+func TestStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s := NewStatsd(conn.LocalAddr().String(), "cli.")
+	defer s.Close()
+	s.Started(context.Background(), "app repo:x commit")
+	s.Finished(context.Background(), "app repo:x commit", 1500*time.Millisecond, redant.ErrorClassOK)
+
+	want := []string{
+		"cli.app.repo_x.commit.started:1|c",
+		"cli.app.repo_x.commit.finished.ok:1|c\ncli.app.repo_x.commit.duration:1500|ms",
+	}
+	buf := make([]byte, 1024)
+	for _, w := range want {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != w {
+			t.Fatalf("packet %q, want %q", got, w)
+		}
+	}
+}
+
+func TestPushgateway(t *testing.T) {
+	var gotPath, gotMethod, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotPath, gotBody = r.Method, r.URL.EscapedPath(), string(body)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := NewPushgateway(srv.URL+"/", "ops")
+	p.Started(ctx, "app deploy")
+	p.Finished(ctx, "app deploy", 2*time.Second, redant.ErrorClassCanceled)
+
+	if gotMethod != http.MethodPut || gotPath != "/metrics/job/ops/command/app%20deploy" {
+		t.Fatalf("%s %s", gotMethod, gotPath)
+	}
+	if !strings.Contains(gotBody, `redant_command_duration_seconds{class="canceled"} 2`+"\n") ||
+		!strings.Contains(gotBody, `redant_command_last_run_timestamp_seconds{class="canceled"} `) {
+		t.Fatalf("unexpected body:\n%s", gotBody)
+	}
+}
+
+func TestPushgatewayBoundsSlowPushes(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	NewPushgateway(srv.URL, "ops").Finished(context.Background(), "app deploy", time.Second, redant.ErrorClassOK)
+	if elapsed := time.Since(start); elapsed > 10*pushFlushTimeout {
+		t.Fatalf("Finished blocked for %s", elapsed)
+	}
+}
@@ -0,0 +1,95 @@
+// Package redantmetrics provides redant.Reporter implementations sending
+// command usage metrics to common backends.
+package redantmetrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pubgo/redant"
+)
+
+var _ redant.Reporter = (*Statsd)(nil)
+
+// Statsd reports command runs to a statsd server over UDP. For a command
+// "app repo commit" and the prefix "cli" it sends:
+//
+//	cli.app.repo.commit.started:1|c
+//	cli.app.repo.commit.finished.<class>:1|c
+//	cli.app.repo.commit.duration:<ms>|ms
+//
+// Metrics are best-effort: send failures are ignored.
+type Statsd struct {
+	addr   string
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewStatsd returns a reporter sending to the statsd server at addr, e.g.
+// "127.0.0.1:8125", with metric names starting with prefix, if not empty.
+func NewStatsd(addr, prefix string) *Statsd {
+	return &Statsd{addr: addr, prefix: strings.Trim(prefix, ".")}
+}
+
+// Started implements redant.Reporter.
+func (s *Statsd) Started(_ context.Context, command string) {
+	s.send(s.name(command, "started") + ":1|c")
+}
+
+// Finished implements redant.Reporter.
+func (s *Statsd) Finished(_ context.Context, command string, duration time.Duration, class redant.ErrorClass) {
+	s.send(
+		s.name(command, "finished."+string(class))+":1|c",
+		fmt.Sprintf("%s:%d|ms", s.name(command, "duration"), duration.Milliseconds()),
+	)
+}
+
+// Close closes the connection to the server.
+func (s *Statsd) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// name returns the metric name of the command followed by suffix.
+func (s *Statsd) name(command, suffix string) string {
+	parts := strings.Fields(command)
+	for i, part := range parts {
+		parts[i] = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+				return r
+			}
+			return '_'
+		}, part)
+	}
+	if s.prefix != "" {
+		parts = append([]string{s.prefix}, parts...)
+	}
+	return strings.Join(append(parts, suffix), ".")
+}
+
+// send writes the metric lines as one packet.
+func (s *Statsd) send(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.Dial("udp", s.addr)
+		if err != nil {
+			return
+		}
+		s.conn = conn
+	}
+	_, _ = s.conn.Write([]byte(strings.Join(lines, "\n")))
+}
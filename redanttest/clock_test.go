@@ -0,0 +1,51 @@
+package redanttest
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/pubgo/redant"
+)
+
+func TestFakeClockDrivesInvocation(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	var slept error
+	cmd := &redant.Command{
+		Use: "wait",
+		Handler: func(ctx context.Context, inv *redant.Invocation) error {
+			if !inv.Now().Equal(start) {
+				t.Errorf("Now() = %v, want %v", inv.Now(), start)
+			}
+			slept = inv.Sleep(ctx, time.Minute)
+			return nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Invoke().WithClock(clock).Run() }()
+
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if slept != nil {
+		t.Fatalf("sleep: %v", slept)
+	}
+}
+
+func TestRandSourceIsDeterministic(t *testing.T) {
+	inv := (&redant.Command{Use: "x"}).Invoke()
+	a := inv.WithRandSource(rand.NewPCG(1, 2)).Rand().Uint64()
+	b := inv.WithRandSource(rand.NewPCG(1, 2)).Rand().Uint64()
+	if a != b {
+		t.Fatalf("expected deterministic values, got %d and %d", a, b)
+	}
+}
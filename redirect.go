@@ -0,0 +1,63 @@
+package redant
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxRedirects bounds chained RedirectTo hops so that cycles fail.
+const maxRedirects = 8
+
+// followRedirect replaces inv.Command by the target of its RedirectTo,
+// following chained redirects, and warns once per redirected command.
+// Subcommand names left in the args after the old command resolve against
+// the target, so redirecting "cfg" to "config" also covers "cfg show".
+func (inv *Invocation) followRedirect(state *runState) error {
+	for hops := 0; inv.Command.RedirectTo != ""; hops++ {
+		old := inv.Command
+		if hops == maxRedirects {
+			return fmt.Errorf("command %q: too many redirects", old.FullName())
+		}
+		target := old.root().lookupPath(old.RedirectTo)
+		if target == nil {
+			return fmt.Errorf("command %q redirects to unknown command %q", old.FullName(), old.RedirectTo)
+		}
+
+		if !state.deprecationChecked[old] {
+			if state.deprecationChecked == nil {
+				state.deprecationChecked = make(map[*Command]bool)
+			}
+			state.deprecationChecked[old] = true
+			if _, err := fmt.Fprintf(inv.Stderr, "%s "+inv.localize("%q is deprecated!. Use %q instead.")+"\n",
+				inv.palette(inv.Stderr).header(inv.localize("warning")), old.FullName(), target.FullName(),
+			); err != nil {
+				return fmt.Errorf("write deprecated warning: %w", err)
+			}
+		}
+		inv.Command = target
+		for len(state.allArgs) > 0 {
+			child := inv.Command.lookupColonPath(state.allArgs[0])
+			if child == nil {
+				break
+			}
+			inv.Command = child
+			state.allArgs = state.allArgs[1:]
+		}
+	}
+	return nil
+}
+
+// lookupPath returns the descendant of c at the space separated path of
+// names or aliases, or nil.
+func (c *Command) lookupPath(path string) *Command {
+	cmd := c
+	for _, name := range strings.Fields(path) {
+		child, ok := cmd.children()[name]
+		if !ok {
+			return nil
+		}
+		child.setParent(cmd)
+		cmd = child
+	}
+	return cmd
+}
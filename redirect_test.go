@@ -0,0 +1,77 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRedirectTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantRan  string
+		wantArgs string
+		wantWarn string
+		wantErr  string
+	}{
+		{name: "old leaf", args: []string{"cfg-show", "key"}, wantRan: "app config show", wantArgs: "key", wantWarn: `"app cfg-show" is deprecated!. Use "app config show" instead.`},
+		{name: "old group", args: []string{"cfg", "show", "--json", "key"}, wantRan: "app config show", wantArgs: "key", wantWarn: `"app cfg" is deprecated!. Use "app config" instead.`},
+		{name: "flags first", args: []string{"--verbose", "cfg", "show"}, wantRan: "app config show", wantWarn: `"app cfg" is deprecated!`},
+		{name: "new path", args: []string{"config", "show"}, wantRan: "app config show"},
+		{name: "unknown target", args: []string{"broken"}, wantErr: `command "app broken" redirects to unknown command "nope"`},
+		{name: "cycle", args: []string{"loop-a"}, wantErr: "too many redirects"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			var gotArgs []string
+			var jsonOut, verbose bool
+			show := &Command{
+				Use:     "show",
+				Options: OptionSet{{Flag: "json", Value: BoolOf(&jsonOut)}},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					ran = inv.Command.FullName()
+					gotArgs = inv.Args
+					return nil
+				},
+			}
+			root := &Command{
+				Use:     "app",
+				Options: OptionSet{{Flag: "verbose", Value: BoolOf(&verbose)}},
+				Children: []*Command{
+					{Use: "config", Children: []*Command{show}},
+					{Use: "cfg", Hidden: true, RedirectTo: "config"},
+					{Use: "cfg-show", Hidden: true, RedirectTo: "config show"},
+					{Use: "broken", RedirectTo: "nope"},
+					{Use: "loop-a", RedirectTo: "loop-b"},
+					{Use: "loop-b", RedirectTo: "loop-a"},
+				},
+			}
+
+			var stderr bytes.Buffer
+			inv := root.Invoke(tt.args...)
+			inv.Stderr = &stderr
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if ran != tt.wantRan || strings.Join(gotArgs, " ") != tt.wantArgs {
+				t.Fatalf("ran %q with args %q", ran, gotArgs)
+			}
+			if tt.wantWarn == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantWarn) {
+				t.Fatalf("stderr = %q, want %q", stderr.String(), tt.wantWarn)
+			}
+			if strings.Count(stderr.String(), "deprecated") > 1 {
+				t.Fatalf("warned more than once: %q", stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrorClass groups the outcomes of command runs for metrics, see
+// Reporter.
+type ErrorClass string
+
+const (
+	ErrorClassOK       ErrorClass = "ok"
+	ErrorClassUsage    ErrorClass = "usage"
+	ErrorClassTimeout  ErrorClass = "timeout"
+	ErrorClassCanceled ErrorClass = "canceled"
+	ErrorClassError    ErrorClass = "error"
+)
+
+// ClassifyError returns the class of an error returned by a run: ok for
+// nil, usage for usage errors and unknown subcommands, timeout and
+// canceled for context errors, and error otherwise.
+func ClassifyError(err error) ErrorClass {
+	var (
+		usageErr   *UsageError
+		unknownErr *UnknownSubcommandError
+	)
+	switch {
+	case err == nil:
+		return ErrorClassOK
+	case errors.As(err, &usageErr), errors.As(err, &unknownErr):
+		return ErrorClassUsage
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	default:
+		return ErrorClassError
+	}
+}
+
+// Reporter receives the start and the end of every command run, for
+// usage metrics, including runs rejected with a usage error and runs
+// ending in a panic, reported as errors. command is the full name of the
+// command, e.g. "app repo commit". Reporters are called synchronously and
+// should not block; failures to deliver metrics are theirs to ignore. See
+// the redantmetrics package for statsd and Prometheus Pushgateway
+// reporters.
+type Reporter interface {
+	Started(ctx context.Context, command string)
+	Finished(ctx context.Context, command string, duration time.Duration, class ErrorClass)
+}
+
+// reportRun calls fn, reporting the run to the root Reporter, if any.
+func (inv *Invocation) reportRun(ctx context.Context, fn func() error) (err error) {
+	reporter := inv.Command.root().Reporter
+	if reporter == nil {
+		return fn()
+	}
+	name := inv.Command.FullName()
+	start := inv.Now()
+	reporter.Started(ctx, name)
+	defer func() {
+		class := ClassifyError(err)
+		r := recover()
+		if r != nil {
+			class = ErrorClassError
+		}
+		reporter.Finished(ctx, name, inv.Now().Sub(start), class)
+		if r != nil {
+			panic(r)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,95 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type recordingReporter struct{ events []string }
+
+func (r *recordingReporter) Started(_ context.Context, command string) {
+	r.events = append(r.events, "started "+command)
+}
+
+func (r *recordingReporter) Finished(_ context.Context, command string, d time.Duration, class ErrorClass) {
+	r.events = append(r.events, fmt.Sprintf("finished %s %s %s", command, d, class))
+}
+
+func TestReporter(t *testing.T) {
+	reporter := &recordingReporter{}
+	clock := &stepClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tick := func(err error) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) error {
+			clock.now = clock.now.Add(time.Second)
+			return err
+		}
+	}
+	root := &Command{
+		Use:      "app",
+		Reporter: reporter,
+		Children: []*Command{
+			{Use: "ok", Handler: tick(nil)},
+			{Use: "fail", Handler: tick(errors.New("boom"))},
+			{Use: "slow", Handler: tick(fmt.Errorf("waiting: %w", context.DeadlineExceeded))},
+		},
+	}
+
+	for _, args := range [][]string{{"ok"}, {"fail"}, {"slow"}, {"ok", "--bogus"}, {"nope"}} {
+		_ = root.Invoke(args...).WithClock(clock).Run()
+	}
+	want := []string{
+		"started app ok", "finished app ok 1s ok",
+		"started app fail", "finished app fail 1s error",
+		"started app slow", "finished app slow 1s timeout",
+		"started app ok", "finished app ok 0s usage",
+		"started app", "finished app 0s usage",
+	}
+	if fmt.Sprint(reporter.events) != fmt.Sprint(want) {
+		t.Fatalf("events %q, want %q", reporter.events, want)
+	}
+}
+
+func TestReporterPanic(t *testing.T) {
+	reporter := &recordingReporter{}
+	root := &Command{
+		Use:      "app",
+		Reporter: reporter,
+		Handler:  func(ctx context.Context, inv *Invocation) error { panic("boom") },
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("the panic was swallowed")
+			}
+		}()
+		_ = root.Invoke().WithClock(&stepClock{}).Run()
+	}()
+	want := []string{"started app", "finished app 0s error"}
+	if fmt.Sprint(reporter.events) != fmt.Sprint(want) {
+		t.Fatalf("events %q, want %q", reporter.events, want)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{nil, ErrorClassOK},
+		{&UsageError{Err: errors.New("bad flag")}, ErrorClassUsage},
+		{&UnknownSubcommandError{Args: []string{"x"}}, ErrorClassUsage},
+		{fmt.Errorf("x: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{context.Canceled, ErrorClassCanceled},
+		{errors.New("boom"), ErrorClassError},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.want), func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Fatalf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
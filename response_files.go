@@ -0,0 +1,89 @@
+package redant
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxResponseFileDepth bounds how deeply response files may include other
+// response files, so that cycles fail instead of recursing forever.
+const maxResponseFileDepth = 10
+
+// expandResponseFiles replaces every "@path" arg with the args read from
+// the file at path. Each non-blank line of the file is one arg; lines
+// starting with "#" are comments. A line wrapped in double quotes is
+// unquoted with Go rules (so "\t" or "" can be passed), one wrapped in
+// single quotes is taken literally. Args of a response file that are
+// themselves "@path" are expanded relative to that file. "@@x" passes a
+// literal "@x" and args after "--" are left untouched. Relative paths
+// given on the command line are resolved against dir, the invocation Dir.
+func expandResponseFiles(args []string, dir string) ([]string, error) {
+	return expandResponseFilesDepth(args, dir, 0)
+}
+
+func expandResponseFilesDepth(args []string, dir string, depth int) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+		if strings.HasPrefix(arg, "@@") {
+			out = append(out, arg[1:])
+			continue
+		}
+		if !strings.HasPrefix(arg, "@") || len(arg) == 1 {
+			out = append(out, arg)
+			continue
+		}
+
+		if depth >= maxResponseFileDepth {
+			return nil, fmt.Errorf("response file %s: nested more than %d levels deep", arg[1:], maxResponseFileDepth)
+		}
+		path := arg[1:]
+		if dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		fileArgs, err := readResponseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fileArgs, err = expandResponseFilesDepth(fileArgs, filepath.Dir(path), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, fileArgs...)
+	}
+	return out, nil
+}
+
+// readResponseFile returns the args listed in the response file at path.
+func readResponseFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading response file: %w", err)
+	}
+
+	var args []string
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case len(line) >= 2 && line[0] == '"' && line[len(line)-1] == '"':
+			unquoted, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("response file %s:%d: invalid quoted arg %s", path, n+1, line)
+			}
+			line = unquoted
+		case len(line) >= 2 && line[0] == '\'' && line[len(line)-1] == '\'':
+			line = line[1 : len(line)-1]
+		}
+		args = append(args, line)
+	}
+	return args, nil
+}
@@ -0,0 +1,91 @@
+package redant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	args := write("args.txt", "# build flags\n--output\nout bin\n\n\"tab\\there\"\n'  spaced  '\n@nested.txt\n")
+	write("nested.txt", "--tag\nv1\n")
+	loop := write("loop.txt", "@loop.txt\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		dir      string
+		wantArgs []string
+		wantOut  string
+		wantTag  string
+		wantErr  string
+	}{
+		{
+			name:     "expands file",
+			args:     []string{"build", "@" + args, "last"},
+			wantArgs: []string{"tab\there", "  spaced  ", "last"},
+			wantOut:  "out bin",
+			wantTag:  "v1",
+		},
+		{
+			name:     "relative to dir",
+			args:     []string{"build", "@args.txt"},
+			dir:      dir,
+			wantArgs: []string{"tab\there", "  spaced  "},
+			wantOut:  "out bin",
+			wantTag:  "v1",
+		},
+		{name: "escaped at", args: []string{"build", "@@user"}, wantArgs: []string{"@user"}},
+		{name: "after double dash", args: []string{"build", "--", "@" + args}, wantArgs: []string{"@" + args}},
+		{name: "missing file", args: []string{"build", "@" + filepath.Join(dir, "none.txt")}, wantErr: "reading response file"},
+		{name: "recursion limit", args: []string{"build", "@" + loop}, wantErr: "nested more than 10 levels deep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output, tag string
+			var gotArgs []string
+			root := &Command{Use: "app", ResponseFiles: true}
+			root.Children = append(root.Children, &Command{
+				Use: "build",
+				Options: OptionSet{
+					{Flag: "output", Value: StringOf(&output)},
+					{Flag: "tag", Value: StringOf(&tag)},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					gotArgs = inv.Args
+					return nil
+				},
+			})
+
+			inv := root.Invoke(tt.args...)
+			inv.Dir = tt.dir
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if strings.Join(gotArgs, "|") != strings.Join(tt.wantArgs, "|") {
+				t.Fatalf("args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+			if output != tt.wantOut || tag != tt.wantTag {
+				t.Fatalf("output=%q tag=%q", output, tag)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package redant
+
+import (
+	"context"
+	"slices"
+)
+
+// withRunHooks wraps handler with the run hooks of c and its parents:
+// PersistentPreRun of the root down to c, PreRun, the handler, PostRun,
+// then PersistentPostRun of c up to the root. A failing hook or handler
+// stops the chain, so post-run hooks only run after success.
+func (c *Command) withRunHooks(handler HandlerFunc) HandlerFunc {
+	var pre, post []HandlerFunc
+	for p := c; p != nil; p = p.parent {
+		if p.PersistentPreRun != nil {
+			pre = append(pre, p.PersistentPreRun)
+		}
+		if p.PersistentPostRun != nil {
+			post = append(post, p.PersistentPostRun)
+		}
+	}
+	slices.Reverse(pre)
+	if c.PreRun != nil {
+		pre = append(pre, c.PreRun)
+	}
+	if c.PostRun != nil {
+		post = append([]HandlerFunc{c.PostRun}, post...)
+	}
+	if len(pre) == 0 && len(post) == 0 {
+		return handler
+	}
+
+	steps := slices.Concat(pre, []HandlerFunc{handler}, post)
+	return func(ctx context.Context, inv *Invocation) error {
+		for _, step := range steps {
+			if err := step(ctx, inv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunHooksOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		failOn  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success",
+			want: "mw-root mw-leaf root-ppre group-ppre leaf-pre handler leaf-post group-ppost root-ppost",
+		},
+		{
+			name:    "handler fails",
+			failOn:  "handler",
+			want:    "mw-root mw-leaf root-ppre group-ppre leaf-pre handler",
+			wantErr: true,
+		},
+		{
+			name:    "pre-run fails",
+			failOn:  "group-ppre",
+			want:    "mw-root mw-leaf root-ppre group-ppre",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			hook := func(name string) HandlerFunc {
+				return func(ctx context.Context, inv *Invocation) error {
+					calls = append(calls, name)
+					if name == tt.failOn {
+						return errors.New(name + " failed")
+					}
+					return nil
+				}
+			}
+			middleware := func(name string) MiddlewareFunc {
+				return func(next HandlerFunc) HandlerFunc {
+					return func(ctx context.Context, inv *Invocation) error {
+						calls = append(calls, name)
+						return next(ctx, inv)
+					}
+				}
+			}
+
+			leaf := &Command{
+				Use:        "leaf",
+				PreRun:     hook("leaf-pre"),
+				PostRun:    hook("leaf-post"),
+				Middleware: middleware("mw-leaf"),
+				Handler:    hook("handler"),
+			}
+			group := &Command{
+				Use:               "group",
+				PersistentPreRun:  hook("group-ppre"),
+				PersistentPostRun: hook("group-ppost"),
+				PreRun:            hook("group-pre"),
+				Children:          []*Command{leaf},
+			}
+			root := &Command{
+				Use:               "app",
+				PersistentPreRun:  hook("root-ppre"),
+				PersistentPostRun: hook("root-ppost"),
+				Middleware:        middleware("mw-root"),
+				Children:          []*Command{group},
+			}
+
+			err := root.Invoke("group", "leaf").Run()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(calls, " "); got != tt.want {
+				t.Fatalf("calls = %q\nwant    %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package redant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// defaultNotifySignals cancel the handler context unless a command sets
+// NotifySignals.
+var defaultNotifySignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// notifySignals returns the NotifySignals of the command or its nearest
+// ancestor setting them, or the default signals.
+func (c *Command) notifySignals() []os.Signal {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.NotifySignals != nil {
+			return cmd.NotifySignals
+		}
+	}
+	return defaultNotifySignals
+}
+
+// DefaultSignalGracePeriod is how long a command may take to return after
+// a notify signal when no command in its path sets SignalGracePeriod.
+const DefaultSignalGracePeriod = 10 * time.Second
+
+// signalGracePeriod returns the SignalGracePeriod of the command or its
+// nearest ancestor setting one, or DefaultSignalGracePeriod.
+func (c *Command) signalGracePeriod() time.Duration {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.SignalGracePeriod != 0 {
+			return cmd.SignalGracePeriod
+		}
+	}
+	return DefaultSignalGracePeriod
+}
+
+// notifyOnSignals returns ctx canceled when one of the command's notify
+// signals arrives, see cancelOnSignals.
+func (inv *Invocation) notifyOnSignals(ctx context.Context) (context.Context, func(), func() os.Signal) {
+	return inv.cancelOnSignals(ctx, inv.Command.notifySignals(), inv.Command.signalGracePeriod())
+}
+
+// signalExitCode returns the status shells report for a process terminated
+// by sig: 128 plus the signal number, e.g. 130 for SIGINT and 143 for
+// SIGTERM.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return ExitSignal
+}
+
+// signalError is the error of a command that returned a context
+// cancellation after sig arrived. Its exit code is that of sig.
+type signalError struct {
+	sig os.Signal
+	err error
+}
+
+func (e *signalError) Error() string { return e.err.Error() }
+
+func (e *signalError) Unwrap() error { return e.err }
+
+func (e *signalError) ExitCode() int { return signalExitCode(e.sig) }
+
+// withSignal wraps err in a signalError when sig canceled the command and
+// err reports the cancellation.
+func withSignal(sig os.Signal, err error) error {
+	if sig == nil || !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &signalError{sig: sig, err: err}
+}
+
+// receivedSignal returns which of signals canceled ctx, read from the
+// cause set by signal.NotifyContext, or nil.
+func receivedSignal(ctx context.Context, signals []os.Signal) os.Signal {
+	cause := context.Cause(ctx)
+	if cause == nil {
+		return nil
+	}
+	for _, sig := range signals {
+		if cause.Error() == sig.String()+" signal received" {
+			return sig
+		}
+	}
+	return nil
+}
+
+// WithGracefulShutdown returns middleware that cancels the handler context
+// on SIGINT or SIGTERM. When the handler has not returned within grace
+// after the signal, the process exits with the status of the signal (130
+// for SIGINT, 143 for SIGTERM); a grace of zero or less never forces the
+// exit. A second signal terminates the process right away.
+//
+// It suits commands that set an empty NotifySignals, or a subtree needing
+// a different grace period than its ancestors':
+//
+//	serve := &redant.Command{
+//		Use:           "serve",
+//		NotifySignals: []os.Signal{},
+//		Middleware:    redant.WithGracefulShutdown(10 * time.Second),
+//	}
+func WithGracefulShutdown(grace time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, inv *Invocation) error {
+			ctx, stop, received := inv.cancelOnSignals(ctx, defaultNotifySignals, grace)
+			defer stop()
+			err := next(ctx, inv)
+			return withSignal(received(), err)
+		}
+	}
+}
+
+// cancelOnSignals returns ctx canceled when one of signals arrives. After
+// the first signal the default signal behavior is restored, so a second
+// one terminates the process; with a positive grace period the process
+// also exits with the status of the signal when the command is still
+// running once it has elapsed. The returned stop function must be called
+// when the command returns, after received, which reports the signal that
+// canceled the context, if any.
+func (inv *Invocation) cancelOnSignals(ctx context.Context, signals []os.Signal, grace time.Duration) (_ context.Context, stop func(), received func() os.Signal) {
+	if len(signals) == 0 {
+		return ctx, func() {}, func() os.Signal { return nil }
+	}
+	sigCtx, stopNotify := inv.SignalNotifyContext(ctx, signals...)
+
+	received = func() os.Signal {
+		if sigCtx.Err() == nil || ctx.Err() != nil {
+			// No signal, or canceled by the parent context.
+			return nil
+		}
+		if sig := receivedSignal(sigCtx, signals); sig != nil {
+			return sig
+		}
+		return signals[0]
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-sigCtx.Done():
+		}
+		select {
+		case <-done:
+			// Stopped, which also cancels sigCtx.
+			return
+		default:
+		}
+		sig := received()
+		if sig == nil {
+			return
+		}
+		stopNotify()
+		if grace <= 0 {
+			return
+		}
+		select {
+		case <-done:
+		case <-inv.Clock().After(grace):
+			_, _ = fmt.Fprintf(inv.Stderr, "command did not stop within %s after signal, exiting\n", grace)
+			inv.exit(signalExitCode(sig))
+		}
+	}()
+	stop = func() {
+		close(done)
+		stopNotify()
+	}
+	return sigCtx, stop, received
+}
+
+// exit terminates the process with code.
+func (inv *Invocation) exit(code int) {
+	if inv.exitFn != nil {
+		inv.exitFn(code)
+		return
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,223 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeSignals replaces SignalNotifyContext, recording the requested
+// signals and delivering sig, or the first requested signal, when send is
+// closed.
+type fakeSignals struct {
+	requested []os.Signal
+	sig       os.Signal
+	send      chan struct{}
+}
+
+func (f *fakeSignals) notifyContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	f.requested = signals
+	sig := f.sig
+	if sig == nil {
+		sig = signals[0]
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	go func() {
+		select {
+		case <-f.send:
+			// The cause set by signal.NotifyContext.
+			cancel(errors.New(sig.String() + " signal received"))
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+func TestNotifySignals(t *testing.T) {
+	tests := []struct {
+		name        string
+		signals     []os.Signal
+		wantSignals []os.Signal
+	}{
+		{name: "default", wantSignals: []os.Signal{os.Interrupt, syscall.SIGTERM}},
+		{name: "override", signals: []os.Signal{syscall.SIGHUP}, wantSignals: []os.Signal{syscall.SIGHUP}},
+		{name: "disabled", signals: []os.Signal{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSignals{send: make(chan struct{})}
+			var canceled bool
+			root := &Command{Use: "app", Children: []*Command{{
+				Use:           "serve",
+				NotifySignals: tt.signals,
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					close(fake.send)
+					select {
+					case <-ctx.Done():
+						canceled = true
+					case <-time.After(50 * time.Millisecond):
+					}
+					return nil
+				},
+			}}}
+
+			inv := root.Invoke("serve").WithTestSignalNotifyContext(t, fake.notifyContext)
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if !slices.Equal(fake.requested, tt.wantSignals) {
+				t.Fatalf("requested signals %v, want %v", fake.requested, tt.wantSignals)
+			}
+			if canceled != (len(tt.wantSignals) > 0) {
+				t.Fatalf("handler context canceled = %v", canceled)
+			}
+		})
+	}
+}
+
+func TestSignalGracePeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		sig       os.Signal
+		grace     time.Duration
+		wantGrace time.Duration
+		wantCode  int
+	}{
+		{name: "interrupt", grace: 10 * time.Millisecond, wantGrace: 10 * time.Millisecond, wantCode: ExitSignal},
+		{name: "terminate", sig: syscall.SIGTERM, grace: time.Second, wantGrace: time.Second, wantCode: 143},
+		{name: "default", wantGrace: DefaultSignalGracePeriod, wantCode: ExitSignal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSignals{sig: tt.sig, send: make(chan struct{})}
+			exited := make(chan int, 1)
+			release := make(chan struct{})
+			root := &Command{
+				Use:               "app",
+				SignalGracePeriod: tt.grace,
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					close(fake.send)
+					<-release // ignores the canceled context
+					return nil
+				},
+			}
+
+			var stderr bytes.Buffer
+			clock := &recordingClock{}
+			inv := root.Invoke().WithTestSignalNotifyContext(t, fake.notifyContext).WithClock(clock)
+			inv.Stderr = &stderr
+			inv.exitFn = func(code int) {
+				exited <- code
+				close(release)
+			}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if code := <-exited; code != tt.wantCode {
+				t.Fatalf("exit code %d, want %d", code, tt.wantCode)
+			}
+			if !slices.Equal(clock.waits, []time.Duration{tt.wantGrace}) {
+				t.Fatalf("waited %v, want %v", clock.waits, tt.wantGrace)
+			}
+			if want := "did not stop within " + tt.wantGrace.String(); !bytes.Contains(stderr.Bytes(), []byte(want)) {
+				t.Fatalf("unexpected stderr %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestSignalExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		sig      os.Signal
+		wantCode int
+	}{
+		{name: "interrupt", sig: os.Interrupt, wantCode: ExitSignal},
+		{name: "terminate", sig: syscall.SIGTERM, wantCode: 143},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSignals{sig: tt.sig, send: make(chan struct{})}
+			root := &Command{
+				Use: "app",
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					close(fake.send)
+					<-ctx.Done()
+					return fmt.Errorf("serve: %w", ctx.Err())
+				},
+			}
+			err := root.Invoke().WithTestSignalNotifyContext(t, fake.notifyContext).Run()
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want a cancellation", err)
+			}
+			if code := ExitCode(err); code != tt.wantCode {
+				t.Fatalf("ExitCode = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWithGracefulShutdown(t *testing.T) {
+	fake := &fakeSignals{send: make(chan struct{})}
+	exited := make(chan int, 1)
+	release := make(chan struct{})
+	var canceled bool
+	root := &Command{
+		Use:           "serve",
+		NotifySignals: []os.Signal{},
+		Middleware:    WithGracefulShutdown(10 * time.Millisecond),
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			close(fake.send)
+			<-ctx.Done()
+			canceled = true
+			<-release // keeps running past the grace period
+			return nil
+		},
+	}
+
+	inv := root.Invoke().WithTestSignalNotifyContext(t, fake.notifyContext)
+	inv.exitFn = func(code int) {
+		exited <- code
+		close(release)
+	}
+	if err := inv.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !slices.Equal(fake.requested, defaultNotifySignals) {
+		t.Fatalf("requested signals %v", fake.requested)
+	}
+	if code := <-exited; !canceled || code != ExitSignal {
+		t.Fatalf("canceled=%v exit code %d", canceled, code)
+	}
+}
+
+func TestWithGracefulShutdownWithoutGrace(t *testing.T) {
+	fake := &fakeSignals{sig: syscall.SIGTERM, send: make(chan struct{})}
+	root := &Command{
+		Use:           "serve",
+		NotifySignals: []os.Signal{},
+		Middleware:    WithGracefulShutdown(0),
+		Handler: func(ctx context.Context, inv *Invocation) error {
+			close(fake.send)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	clock := &recordingClock{}
+	inv := root.Invoke().WithTestSignalNotifyContext(t, fake.notifyContext).WithClock(clock)
+	inv.exitFn = func(code int) { t.Errorf("exited with %d, want no forced exit", code) }
+	err := inv.Run()
+	if code := ExitCode(err); code != 143 {
+		t.Fatalf("ExitCode = %d (err %v), want 143", code, err)
+	}
+	if len(clock.waits) != 0 {
+		t.Fatalf("waited %v for a forced exit", clock.waits)
+	}
+}
@@ -0,0 +1,89 @@
+package redant
+
+import (
+	"runtime"
+	"strings"
+)
+
+// slashFlagsSupported reports whether SlashFlags takes effect on this
+// platform. It is a variable so tests can exercise the translation.
+var slashFlagsSupported = runtime.GOOS == "windows"
+
+// knownFlagNames collects long and short flag names declared anywhere in the
+// command tree, including global flags.
+func (c *Command) knownFlagNames() (long, short map[string]bool) {
+	long = make(map[string]bool)
+	short = make(map[string]bool)
+	normalize := c.flagNormalizer()
+	_ = c.Walk(func(cmd *Command) error {
+		for _, opt := range cmd.localOptions() {
+			if opt.Flag != "" {
+				name := opt.Flag
+				if normalize != nil {
+					name = normalize(name)
+				}
+				long[name] = true
+			}
+			if opt.Shorthand != "" {
+				short[opt.Shorthand] = true
+			}
+		}
+		return nil
+	})
+	for _, opt := range c.GetGlobalFlags() {
+		if opt.Flag != "" {
+			long[opt.Flag] = true
+		}
+		if opt.Shorthand != "" {
+			short[opt.Shorthand] = true
+		}
+	}
+	return long, short
+}
+
+// translateSlashFlags rewrites Windows style flags into their GNU forms:
+// "/name:value" becomes "--name=value", "/name" becomes "--name", "/n"
+// becomes "-n" and "/?" becomes "--help". Only names declared in the tree
+// are translated so that paths such as "/tmp" pass through untouched.
+func (c *Command) translateSlashFlags(args []string) []string {
+	long, short := c.knownFlagNames()
+	normalize := c.flagNormalizer()
+	help := c.builtinFlagName(builtinHelp)
+
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "/") || len(arg) < 2 {
+			out = append(out, arg)
+			continue
+		}
+
+		token := arg[1:]
+		if token == "?" && help != "" {
+			out = append(out, "--"+help)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(token, ":")
+		lookup := name
+		if normalize != nil {
+			lookup = normalize(name)
+		}
+		switch {
+		case long[lookup] && hasValue:
+			out = append(out, "--"+name+"="+value)
+		case long[lookup]:
+			out = append(out, "--"+name)
+		case len(name) == 1 && short[name] && hasValue:
+			out = append(out, "-"+name+"="+value)
+		case len(name) == 1 && short[name]:
+			out = append(out, "-"+name)
+		default:
+			out = append(out, arg)
+		}
+	}
+	return out
+}
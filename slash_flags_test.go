@@ -0,0 +1,65 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSlashFlags(t *testing.T) {
+	prev := slashFlagsSupported
+	slashFlagsSupported = true
+	t.Cleanup(func() { slashFlagsSupported = prev })
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutput string
+		wantArgs   string
+		wantHelp   bool
+	}{
+		{name: "colon value", args: []string{"copy", "/output:out.txt", "src"}, wantOutput: "out.txt", wantArgs: "src"},
+		{name: "separate value", args: []string{"copy", "/output", "out.txt", "src"}, wantOutput: "out.txt", wantArgs: "src"},
+		{name: "shorthand", args: []string{"copy", "/o:x", "src"}, wantOutput: "x", wantArgs: "src"},
+		{name: "paths untouched", args: []string{"copy", "/tmp/file"}, wantArgs: "/tmp/file"},
+		{name: "after double dash", args: []string{"copy", "--", "/output:x"}, wantArgs: "/output:x"},
+		{name: "help", args: []string{"copy", "/?"}, wantHelp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output string
+			var gotArgs []string
+			root := &Command{Use: "app", SlashFlags: true}
+			root.Children = append(root.Children, &Command{
+				Use:     "copy",
+				Options: OptionSet{{Flag: "output", Shorthand: "o", Value: StringOf(&output)}},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					gotArgs = inv.Args
+					return nil
+				},
+			})
+
+			stdout := &bytes.Buffer{}
+			inv := root.Invoke(tt.args...)
+			inv.Stdout = stdout
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantHelp {
+				if gotArgs != nil {
+					t.Fatal("handler must not run for /?")
+				}
+				return
+			}
+			if output != tt.wantOutput {
+				t.Fatalf("output = %q, want %q", output, tt.wantOutput)
+			}
+			if strings.Join(gotArgs, " ") != tt.wantArgs {
+				t.Fatalf("args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
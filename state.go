@@ -0,0 +1,156 @@
+package redant
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const usageStatsFileName = "usage-stats.json"
+
+// DefaultStateDir returns the conventional per-user state directory for app:
+// $XDG_STATE_HOME/app, falling back to ~/.local/state/app.
+func DefaultStateDir(app string) (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, app), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", app), nil
+}
+
+// stateDir returns the state directory configured on the root command.
+func (c *Command) stateDir() string {
+	return c.root().StateDir
+}
+
+// UsageStats holds local usage counters of commands and flags.
+type UsageStats struct {
+	// Commands maps a full command name ("app deploy") to its run count.
+	Commands map[string]int `json:"commands"`
+	// Flags maps a flag ("--force") to the number of runs that set it.
+	Flags map[string]int `json:"flags"`
+}
+
+// UsageCount is a single usage counter entry.
+type UsageCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// LoadUsageStats reads the usage counters recorded in stateDir.
+// A missing stats file yields empty stats.
+func LoadUsageStats(stateDir string) (*UsageStats, error) {
+	stats := &UsageStats{}
+	if err := readStateFile(stateDir, usageStatsFileName, stats); err != nil {
+		return nil, err
+	}
+	if stats.Commands == nil {
+		stats.Commands = map[string]int{}
+	}
+	if stats.Flags == nil {
+		stats.Flags = map[string]int{}
+	}
+	return stats, nil
+}
+
+// ResetUsageStats removes the usage counters recorded in stateDir.
+func ResetUsageStats(stateDir string) error {
+	err := os.Remove(filepath.Join(stateDir, usageStatsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// SortedCommands returns command counters, most used first.
+func (s *UsageStats) SortedCommands() []UsageCount {
+	return sortUsage(s.Commands)
+}
+
+// SortedFlags returns flag counters, most used first.
+func (s *UsageStats) SortedFlags() []UsageCount {
+	return sortUsage(s.Flags)
+}
+
+func sortUsage(m map[string]int) []UsageCount {
+	out := make([]UsageCount, 0, len(m))
+	for name, count := range m {
+		out = append(out, UsageCount{Name: name, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (s *UsageStats) save(stateDir string) error {
+	return writeStateFile(stateDir, usageStatsFileName, s)
+}
+
+// readStateFile decodes the JSON state file name in stateDir into v,
+// leaving v untouched when the file does not exist.
+func readStateFile(stateDir, name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(stateDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeStateFile atomically replaces the JSON state file name in stateDir.
+func writeStateFile(stateDir, name string, v any) error {
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(stateDir, name+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(stateDir, name))
+}
+
+// recordUsage increments the counters of the resolved command and the flags
+// set for it on the command line. It is a no-op unless the root enables
+// TrackUsage and StateDir. The stats file is read and replaced without a
+// lock, so concurrent runs may lose increments, see TrackUsage.
+func (inv *Invocation) recordUsage() error {
+	root := inv.Command.root()
+	if !root.TrackUsage || root.StateDir == "" {
+		return nil
+	}
+
+	stats, err := LoadUsageStats(root.StateDir)
+	if err != nil {
+		return err
+	}
+	stats.Commands[inv.Command.FullName()]++
+	for name := range inv.argvFlags {
+		stats.Flags["--"+name]++
+	}
+	return stats.save(root.StateDir)
+}
@@ -0,0 +1,62 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestUsageTracking(t *testing.T) {
+	tests := []struct {
+		name         string
+		trackUsage   bool
+		stateDir     bool
+		wantCommands map[string]int
+	}{
+		{name: "disabled", trackUsage: false, stateDir: true, wantCommands: map[string]int{}},
+		{name: "no state dir", trackUsage: true, stateDir: false, wantCommands: map[string]int{}},
+		{name: "enabled", trackUsage: true, stateDir: true, wantCommands: map[string]int{"app run": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var verbose bool
+			root := &Command{Use: "app", TrackUsage: tt.trackUsage}
+			if tt.stateDir {
+				root.StateDir = dir
+			}
+			root.Children = append(root.Children, &Command{
+				Use: "run",
+				Options: OptionSet{
+					{Flag: "verbose", Value: BoolOf(&verbose)},
+					{Flag: "region", Envs: []string{"APP_REGION"}, Value: StringOf(new(string))},
+				},
+				Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+			})
+
+			inv := root.Invoke("run", "--verbose").WithEnviron([]string{"APP_REGION=eu"})
+			inv.Stdout = &bytes.Buffer{}
+			inv.Stderr = &bytes.Buffer{}
+			if err := inv.Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			stats, err := LoadUsageStats(dir)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(stats.Commands) != len(tt.wantCommands) {
+				t.Fatalf("commands = %v, want %v", stats.Commands, tt.wantCommands)
+			}
+			for name, count := range tt.wantCommands {
+				if stats.Commands[name] != count {
+					t.Fatalf("commands[%q] = %d, want %d", name, stats.Commands[name], count)
+				}
+			}
+			if len(tt.wantCommands) > 0 && (stats.Flags["--verbose"] != 1 || len(stats.Flags) != 1) {
+				t.Fatalf("expected only --verbose counted once, got %v", stats.Flags)
+			}
+		})
+	}
+}
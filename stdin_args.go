@@ -0,0 +1,52 @@
+package redant
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// readStdinArgs returns the newline-delimited args piped to the invocation,
+// skipping blank lines. Nothing is read from an interactive terminal.
+func (inv *Invocation) readStdinArgs() ([]string, error) {
+	if inv.Stdin == nil {
+		return nil, nil
+	}
+	if _, ok := terminalFd(inv.Stdin); ok {
+		return nil, nil
+	}
+
+	var args []string
+	scanner := bufio.NewScanner(inv.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(line) != "" {
+			args = append(args, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading args from stdin: %w", err)
+	}
+	return args, nil
+}
+
+// appendStdinArgs parses the args read from stdin after those of the
+// command line and returns the positional args extended with them. Stdin
+// args are all positional when the command line ended flags with "--".
+func (inv *Invocation) appendStdinArgs(state *runState, parsedArgs []string) ([]string, error) {
+	args, err := inv.readStdinArgs()
+	if err != nil || len(args) == 0 {
+		return parsedArgs, err
+	}
+	state.allArgs = append(slices.Clip(state.allArgs), args...)
+
+	if slices.Contains(state.allArgs[:len(state.allArgs)-len(args)], "--") {
+		return append(parsedArgs, args...), nil
+	}
+	protected, restore := inv.protectNegativeNumbers(args)
+	if err := inv.parseFlags(protected); err != nil && state.flagParseErr == nil {
+		state.flagParseErr = err
+	}
+	return append(parsedArgs, restore(inv.Flags.Args())...), nil
+}
@@ -0,0 +1,56 @@
+package redant
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStdinArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		stdin     string
+		wantArgs  []string
+		wantForce bool
+		wantErr   string
+	}{
+		{name: "appends lines", args: []string{"rm", "a"}, stdin: "b\n\nc d\r\n", wantArgs: []string{"a", "b", "c d"}},
+		{name: "empty stdin", args: []string{"rm", "a"}, wantArgs: []string{"a"}},
+		{name: "flags on stdin", args: []string{"rm"}, stdin: "--force\nx\n", wantArgs: []string{"x"}, wantForce: true},
+		{name: "after double dash", args: []string{"rm", "--"}, stdin: "--force\n", wantArgs: []string{"--force"}},
+		{name: "unknown flag on stdin", args: []string{"rm"}, stdin: "--nope\n", wantErr: "unknown flag: --nope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var force bool
+			var gotArgs []string
+			root := &Command{Use: "app"}
+			root.Children = append(root.Children, &Command{
+				Use:       "rm",
+				StdinArgs: true,
+				Options:   OptionSet{{Flag: "force", Value: BoolOf(&force)}},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					gotArgs = inv.Args
+					return nil
+				},
+			})
+
+			inv := root.Invoke(tt.args...)
+			inv.Stdin = strings.NewReader(tt.stdin)
+			err := inv.Run()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if strings.Join(gotArgs, "|") != strings.Join(tt.wantArgs, "|") || force != tt.wantForce {
+				t.Fatalf("args = %q force = %v, want %q %v", gotArgs, force, tt.wantArgs, tt.wantForce)
+			}
+		})
+	}
+}
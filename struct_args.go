@@ -0,0 +1,98 @@
+package redant
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ArgSetFromStruct builds an ArgSet bound to the tagged fields of the
+// struct pointed to by v, in field order:
+//
+//	type deployParams struct {
+//		Env      string   `arg:"env,required" desc:"Target environment."`
+//		Replicas int64    `arg:"replicas" default:"2"`
+//		Services []string `arg:"services,variadic"`
+//	}
+//
+// The arg tag holds the name (the lower-cased field name when empty)
+// followed by the "required" and "variadic" flags. Positional and
+// key=value args then set the fields directly. Fields may be string, bool,
+// int64, float64, []string, time.Duration, url.URL or implement Value;
+// untagged fields and fields tagged "-" are skipped.
+func ArgSetFromStruct(v any) (ArgSet, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("ArgSetFromStruct: want a pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var args ArgSet
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("arg")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return nil, fmt.Errorf("ArgSetFromStruct: field %s is not exported", field.Name)
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		arg := Arg{
+			Name:        name,
+			Description: field.Tag.Get("desc"),
+			Default:     field.Tag.Get("default"),
+		}
+		for _, f := range strings.Split(flags, ",") {
+			switch strings.TrimSpace(f) {
+			case "":
+			case "required":
+				arg.Required = true
+			case "variadic":
+				arg.Variadic = true
+			default:
+				return nil, fmt.Errorf("ArgSetFromStruct: field %s: unknown arg tag option %q", field.Name, f)
+			}
+		}
+
+		val, err := fieldValue(rv.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("ArgSetFromStruct: field %s: %w", field.Name, err)
+		}
+		arg.Value = val
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// fieldValue returns a Value setting the addressable struct field fv.
+func fieldValue(fv reflect.Value) (Value, error) {
+	if val, ok := fv.Addr().Interface().(Value); ok {
+		return val, nil
+	}
+	switch p := fv.Addr().Interface().(type) {
+	case *string:
+		return StringOf(p), nil
+	case *bool:
+		return BoolOf(p), nil
+	case *int64:
+		return Int64Of(p), nil
+	case *float64:
+		return Float64Of(p), nil
+	case *[]string:
+		return StringArrayOf(p), nil
+	case *time.Duration:
+		return DurationOf(p), nil
+	case *url.URL:
+		return URLOf(p), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", fv.Type())
+	}
+}
@@ -0,0 +1,74 @@
+package redant
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// suggestionDistance is the largest edit distance at which a subcommand
+// name or alias is suggested for a mistyped one.
+const suggestionDistance = 2
+
+// SuggestionsFor returns the names of the visible subcommands of c that
+// the user may have meant by typed: those whose name or an alias is within
+// a small edit distance of typed or starts with it, and those listing typed
+// in SuggestFor.
+func (c *Command) SuggestionsFor(typed string) []string {
+	if typed == "" {
+		return nil
+	}
+	var out []string
+	for _, child := range c.Children {
+		if child.Hidden || child.Deprecated != "" {
+			continue
+		}
+		if slices.ContainsFunc(child.SuggestFor, func(s string) bool { return strings.EqualFold(s, typed) }) {
+			out = append(out, child.Name())
+			continue
+		}
+		for _, name := range append([]string{child.Name()}, child.Aliases...) {
+			lower, lowerTyped := strings.ToLower(name), strings.ToLower(typed)
+			if levenshtein(lower, lowerTyped) <= suggestionDistance || strings.HasPrefix(lower, lowerTyped) {
+				out = append(out, child.Name())
+				break
+			}
+		}
+	}
+	return out
+}
+
+// suggestionText returns the hint appended to unknown subcommand errors,
+// or "" when there is nothing to suggest.
+func suggestionText(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return ", did you mean " + strings.Join(quoted, " or ") + "?"
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
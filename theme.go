@@ -0,0 +1,74 @@
+package redant
+
+import (
+	"io"
+	"strings"
+
+	"github.com/muesli/termenv"
+
+	"github.com/pubgo/redant/internal/pretty"
+)
+
+// Theme holds the colors of help and listings, as "#RRGGBB" hex values or
+// ANSI color numbers such as "6". An empty color leaves the text unstyled.
+// See Command.Theme.
+type Theme struct {
+	// Header colors section headers such as "USAGE:".
+	Header string
+	// Keyword colors command, flag, env and arg names.
+	Keyword string
+}
+
+// DefaultTheme returns the colors used when the root command sets no
+// Theme.
+func DefaultTheme() Theme {
+	return Theme{
+		Header:  "#337CA0",
+		Keyword: "#04A777",
+	}
+}
+
+// palette applies a Theme with the colors supported by an output.
+type palette struct {
+	profile termenv.Profile
+	theme   Theme
+}
+
+// header formats a section header: uppercased, followed by a colon and
+// colored.
+func (p palette) header(s string) string {
+	return p.paint(p.theme.Header, strings.ToUpper(s)+":")
+}
+
+// keyword colors a command, flag, env or arg name.
+func (p palette) keyword(s string) string {
+	return p.paint(p.theme.Keyword, s)
+}
+
+func (p palette) paint(color, s string) string {
+	if color == "" {
+		return s
+	}
+	c := p.profile.Color(color)
+	if c == nil {
+		return s
+	}
+	txt := pretty.String(s)
+	pretty.FgColor(c).Format(txt)
+	return txt.String()
+}
+
+// palette returns the palette for output to w: the Theme of the root
+// command, or DefaultTheme, in the colors w supports, none when
+// colorEnabled reports false.
+func (inv *Invocation) palette(w io.Writer) palette {
+	theme := DefaultTheme()
+	if inv.Command != nil && inv.Command.root().Theme != nil {
+		theme = *inv.Command.root().Theme
+	}
+	profile := termenv.Ascii
+	if inv.colorEnabled(w) {
+		profile = termenv.NewOutput(w, termenv.WithEnvironment(inv)).ColorProfile()
+	}
+	return palette{profile: profile, theme: theme}
+}
@@ -0,0 +1,97 @@
+package redant
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/creack/pty"
+	"github.com/muesli/termenv"
+)
+
+func TestPalette(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    termenv.Profile
+		theme      Theme
+		wantHeader string
+		wantKey    string
+	}{
+		{
+			name:       "default theme",
+			profile:    termenv.TrueColor,
+			theme:      DefaultTheme(),
+			wantHeader: "\x1b[38;2;51;124;160mUSAGE:\x1b[0m",
+			wantKey:    "\x1b[38;2;4;167;119m--port\x1b[0m",
+		},
+		{
+			name:       "ansi colors",
+			profile:    termenv.ANSI,
+			theme:      Theme{Header: "1", Keyword: "2"},
+			wantHeader: "\x1b[31mUSAGE:\x1b[0m",
+			wantKey:    "\x1b[32m--port\x1b[0m",
+		},
+		{name: "unstyled", profile: termenv.TrueColor, theme: Theme{}, wantHeader: "USAGE:", wantKey: "--port"},
+		{name: "no color", profile: termenv.Ascii, theme: DefaultTheme(), wantHeader: "USAGE:", wantKey: "--port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := palette{profile: tt.profile, theme: tt.theme}
+			if got := p.header("Usage"); got != tt.wantHeader {
+				t.Errorf("header = %q, want %q", got, tt.wantHeader)
+			}
+			if got := p.keyword("--port"); got != tt.wantKey {
+				t.Errorf("keyword = %q, want %q", got, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("no pty: %v", err)
+	}
+	defer func() { _ = ptmx.Close() }()
+	defer func() { _ = tty.Close() }()
+
+	tests := []struct {
+		name    string
+		args    []string
+		environ []string
+		want    bool
+	}{
+		{name: "terminal", environ: []string{"TERM=xterm-256color"}, want: true},
+		{name: "no-color flag", args: []string{"--no-color"}, environ: []string{"TERM=xterm-256color"}},
+		{name: "NO_COLOR", environ: []string{"TERM=xterm-256color", "NO_COLOR="}},
+		{name: "dumb terminal", environ: []string{"TERM=dumb"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			var header string
+			root := &Command{
+				Use:   "app",
+				Theme: &Theme{Header: "1"},
+				Handler: func(ctx context.Context, inv *Invocation) error {
+					got = inv.colorEnabled(tty)
+					header = inv.palette(tty).header("usage")
+					return nil
+				},
+			}
+			if err := root.Invoke(tt.args...).WithEnviron(tt.environ).Run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("colorEnabled = %v, want %v", got, tt.want)
+			}
+			if colored := strings.Contains(header, "\x1b["); colored != tt.want {
+				t.Fatalf("header %q colored = %v, want %v", header, colored, tt.want)
+			}
+		})
+	}
+
+	if (&Invocation{Command: &Command{Use: "app"}}).colorEnabled(&strings.Builder{}) {
+		t.Fatal("color enabled for output that is not a terminal")
+	}
+}
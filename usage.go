@@ -0,0 +1,59 @@
+package redant
+
+import "strings"
+
+// usage returns the usage of c without its ancestors: Use when it has more
+// than the command name, else the name followed by a synopsis generated
+// from the command's own visible flags and its args, e.g.
+// "commit [-m <message>] [--amend] <files...>".
+func (c *Command) usage() string {
+	if strings.ContainsAny(strings.TrimSpace(c.Use), " \t") {
+		return c.Use
+	}
+	parts := []string{c.Name()}
+	for _, opt := range c.localOptions() {
+		if opt.Flag == "" || opt.Hidden || opt.Deprecated != "" || opt.builtin != "" {
+			continue
+		}
+		parts = append(parts, opt.synopsis())
+	}
+	for i, arg := range c.Args {
+		parts = append(parts, arg.synopsis(i))
+	}
+	return strings.Join(parts, " ")
+}
+
+// synopsis formats the option for a usage line: "--name <name>", or
+// "-n <name>" when it has a shorthand, "[...]" unless required and "..."
+// after the value of repeatable flags. Flags taking no value, such as
+// bools, show no value.
+func (o Option) synopsis() string {
+	s := "--" + o.Flag
+	if o.Shorthand != "" {
+		s = "-" + o.Shorthand
+	}
+	if no, ok := o.Value.(NoOptDefValuer); !ok || no.NoOptDefValue() == "" {
+		s += " <" + o.Flag + ">"
+		if _, ok := o.Value.(SliceValue); ok {
+			s += "..."
+		}
+	}
+	if o.Required && o.Default == "" {
+		return s
+	}
+	return "[" + s + "]"
+}
+
+// synopsis formats the arg at index i for a usage line: "<name>", with
+// "..." when variadic, in "[...]" when optional.
+func (a Arg) synopsis(i int) string {
+	s := "<" + a.displayName(i)
+	if a.Variadic {
+		s += "..."
+	}
+	s += ">"
+	if a.Required && a.Default == "" {
+		return s
+	}
+	return "[" + s + "]"
+}
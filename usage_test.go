@@ -0,0 +1,56 @@
+package redant
+
+import "testing"
+
+func TestGeneratedUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Command
+		want string
+	}{
+		{
+			name: "flags and args",
+			cmd: &Command{
+				Use: "commit",
+				Options: OptionSet{
+					{Flag: "message", Shorthand: "m", Value: StringOf(new(string))},
+					{Flag: "amend", Value: BoolOf(new(bool))},
+					{Flag: "author", Required: true, Value: StringOf(new(string))},
+					{Flag: "trailer", Value: StringArrayOf(new([]string))},
+					{Flag: "debug", Hidden: true, Value: BoolOf(new(bool))},
+					{Flag: "old", Deprecated: "use --message", Value: StringOf(new(string))},
+					{Envs: []string{"GIT_EDITOR"}, Value: StringOf(new(string))},
+				},
+				Args: ArgSet{
+					{Name: "branch", Required: true},
+					{Name: "files", Variadic: true, Value: StringArrayOf(new([]string))},
+				},
+			},
+			want: "app commit [--amend] --author <author> [-m <message>] [--trailer <trailer>...] <branch> [<files...>]",
+		},
+		{
+			name: "unnamed args",
+			cmd:  &Command{Use: "cp", Args: ArgSet{{Required: true}, {Default: "."}}},
+			want: "app cp <arg1> [<arg2>]",
+		},
+		{
+			name: "hand-written use",
+			cmd: &Command{
+				Use:     "push [remote]",
+				Options: OptionSet{{Flag: "force", Value: BoolOf(new(bool))}},
+			},
+			want: "app push [remote]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Command{Use: "app", Children: []*Command{tt.cmd}}
+			if err := root.Lint(); err != nil {
+				t.Fatalf("lint: %v", err)
+			}
+			if got := tt.cmd.FullUsage(); got != tt.want {
+				t.Fatalf("FullUsage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
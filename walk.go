@@ -0,0 +1,49 @@
+package redant
+
+import "errors"
+
+// WalkOrder is the order in which Walk visits commands.
+type WalkOrder int
+
+const (
+	// WalkPreOrder visits a command before its children.
+	WalkPreOrder WalkOrder = iota
+	// WalkPostOrder visits a command after its children.
+	WalkPostOrder
+)
+
+// SkipChildren can be returned by a pre-order Walk function to skip the
+// children of the command being visited. Walk itself then continues.
+var SkipChildren = errors.New("skip children")
+
+// Walk calls fn for c and all of its descendants, in pre-order unless
+// WalkPostOrder is given, setting parent links on the way so that FullName
+// and Parent work in fn. Hidden commands are visited too. Walk stops at
+// the first error returned by fn other than SkipChildren and returns it.
+func (c *Command) Walk(fn func(cmd *Command) error, order ...WalkOrder) error {
+	post := len(order) > 0 && order[len(order)-1] == WalkPostOrder
+	return c.walk(fn, post)
+}
+
+func (c *Command) walk(fn func(cmd *Command) error, post bool) error {
+	if !post {
+		if err := fn(c); err != nil {
+			if errors.Is(err, SkipChildren) {
+				return nil
+			}
+			return err
+		}
+	}
+	for _, child := range c.Children {
+		child.setParent(c)
+		if err := child.walk(fn, post); err != nil {
+			return err
+		}
+	}
+	if post {
+		if err := fn(c); err != nil && !errors.Is(err, SkipChildren) {
+			return err
+		}
+	}
+	return nil
+}
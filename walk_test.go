@@ -0,0 +1,70 @@
+package redant
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	newTree := func() *Command {
+		return &Command{
+			Use: "app",
+			Children: []*Command{
+				{Use: "repo", Children: []*Command{{Use: "create"}, {Use: "delete"}}},
+				{Use: "secret", Hidden: true},
+			},
+		}
+	}
+	stop := errors.New("stop")
+
+	tests := []struct {
+		name    string
+		order   []WalkOrder
+		fn      func(cmd *Command) error
+		want    string
+		wantErr error
+	}{
+		{name: "pre-order", want: "app,app repo,app repo create,app repo delete,app secret"},
+		{name: "post-order", order: []WalkOrder{WalkPostOrder}, want: "app repo create,app repo delete,app repo,app secret,app"},
+		{
+			name: "skip children",
+			fn: func(cmd *Command) error {
+				if cmd.Name() == "repo" {
+					return SkipChildren
+				}
+				return nil
+			},
+			want: "app,app repo,app secret",
+		},
+		{
+			name: "stop on error",
+			fn: func(cmd *Command) error {
+				if cmd.Name() == "create" {
+					return stop
+				}
+				return nil
+			},
+			want:    "app,app repo,app repo create",
+			wantErr: stop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var visited []string
+			err := newTree().Walk(func(cmd *Command) error {
+				visited = append(visited, cmd.FullName())
+				if tt.fn != nil {
+					return tt.fn(cmd)
+				}
+				return nil
+			}, tt.order...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := strings.Join(visited, ","); got != tt.want {
+				t.Fatalf("visited %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
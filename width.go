@@ -0,0 +1,64 @@
+package redant
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultWidth is used when no width can be detected.
+const defaultWidth = 80
+
+// SetWidth sets an explicit output width for the invocation. There is no
+// built-in --width flag: applications wanting one declare it and call
+// SetWidth with its value, e.g. from middleware. It takes precedence over
+// detection; a non-positive width restores detection.
+func (inv *Invocation) SetWidth(width int) {
+	inv.explicitWidth = width
+	inv.cachedWidth = 0
+}
+
+// Width returns the output width for the invocation. It is resolved once
+// and cached, in order: SetWidth, the COLUMNS env var, the terminal size of
+// Stdout, then 80.
+func (inv *Invocation) Width() int {
+	if inv.explicitWidth > 0 {
+		return inv.explicitWidth
+	}
+	if inv.cachedWidth > 0 {
+		return inv.cachedWidth
+	}
+	width, ok := columnsWidth(inv.Getenv)
+	if !ok {
+		width, ok = terminalWidth(inv.Stdout)
+	}
+	if !ok {
+		width = defaultWidth
+	}
+	inv.cachedWidth = width
+	return width
+}
+
+// columnsWidth reads a positive width from the COLUMNS env var.
+func columnsWidth(getenv func(string) string) (int, bool) {
+	w, err := strconv.Atoi(strings.TrimSpace(getenv("COLUMNS")))
+	if err != nil || w <= 0 {
+		return 0, false
+	}
+	return w, true
+}
+
+// terminalWidth returns the width of w when it is a terminal.
+func terminalWidth(w io.Writer) (int, bool) {
+	fd, ok := terminalFd(w)
+	if !ok {
+		return 0, false
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil || width <= 0 {
+		return 0, false
+	}
+	return width, true
+}
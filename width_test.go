@@ -0,0 +1,109 @@
+package redant
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/creack/pty"
+)
+
+func TestInvocationWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		environ  []string
+		explicit int
+		want     int
+	}{
+		{name: "fallback", want: 80},
+		{name: "columns", environ: []string{"COLUMNS=120"}, want: 120},
+		{name: "invalid columns", environ: []string{"COLUMNS=wide"}, want: 80},
+		{name: "explicit wins", environ: []string{"COLUMNS=120"}, explicit: 40, want: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := (&Command{Use: "app"}).Invoke().WithEnviron(tt.environ)
+			inv.Stdout = &bytes.Buffer{}
+			if tt.explicit > 0 {
+				inv.SetWidth(tt.explicit)
+			}
+			if got := inv.Width(); got != tt.want {
+				t.Fatalf("Width() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelpWrapsToInvocationWidth(t *testing.T) {
+	cmd := &Command{
+		Use:     "app",
+		Short:   strings.Repeat("word ", 30),
+		Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+	}
+	stdout := &bytes.Buffer{}
+	inv := cmd.Invoke("--help").WithEnviron([]string{"COLUMNS=40"})
+	inv.Stdout = stdout
+	inv.Stderr = &bytes.Buffer{}
+	if err := inv.Run(); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.Contains(line, "word") && len(line) > 42 {
+			t.Fatalf("line exceeds width 40: %q", line)
+		}
+	}
+}
+
+func TestListingsUseInvocationOutput(t *testing.T) {
+	long := strings.Repeat("word ", 30)
+	for _, flag := range []string{"--list-commands", "--list-flags"} {
+		t.Run(flag, func(t *testing.T) {
+			root := &Command{
+				Use: "app",
+				Children: []*Command{{
+					Use:     "serve",
+					Short:   long,
+					Options: OptionSet{{Flag: "port", Description: long, Value: Int64Of(new(int64))}},
+					Handler: func(ctx context.Context, inv *Invocation) error { return nil },
+				}},
+			}
+			stdout := &bytes.Buffer{}
+			inv := root.Invoke(flag).WithEnviron([]string{"COLUMNS=40"})
+			inv.Stdout = stdout
+			if err := inv.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(strings.ToLower(stdout.String()), "word") {
+				t.Fatalf("%s wrote nothing to Stdout: %q", flag, stdout.String())
+			}
+			for _, line := range strings.Split(stdout.String(), "\n") {
+				if strings.Contains(strings.ToLower(line), "word") && len(line) > 42 {
+					t.Fatalf("line exceeds width 40: %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("no pty: %v", err)
+	}
+	defer func() { _ = ptmx.Close() }()
+	defer func() { _ = tty.Close() }()
+	if err := pty.Setsize(ptmx, &pty.Winsize{Rows: 24, Cols: 57}); err != nil {
+		t.Skipf("setting pty size: %v", err)
+	}
+
+	inv := (&Command{Use: "app"}).Invoke().WithEnviron(nil)
+	inv.Stdout = tty
+	if got := inv.Width(); got != 57 {
+		t.Fatalf("Width() = %d, want the terminal width 57", got)
+	}
+	if _, ok := terminalWidth(&bytes.Buffer{}); ok {
+		t.Fatal("terminalWidth reported a width for a buffer")
+	}
+}